@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/trafilea/go-template/pkg/insomnia"
+)
+
+// runDiff implements the diff subcommand, which compares two versions of an OpenAPI spec
+// and exits with status 2 when the new version breaks existing clients
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	var (
+		baseFile     = flags.String("base", "", "Path to the old version of the OpenAPI spec")
+		revision     = flags.String("rev", "", "Git revision holding the old version of -input, e.g. HEAD or main")
+		openAPIFile  = flags.String("input", "", "Path to the new version of the OpenAPI spec (required)")
+		breakingOnly = flags.Bool("breaking-only", false, "Only list breaking changes")
+	)
+	flags.Usage = showDiffHelp
+	flags.Parse(args)
+
+	if *openAPIFile == "" || (*baseFile == "") == (*revision == "") {
+		fmt.Println("Error: -input and exactly one of -base or -rev are required")
+		showDiffHelp()
+		os.Exit(1)
+	}
+
+	generator := insomnia.NewGenerator()
+
+	var diff *insomnia.SpecDiff
+	var err error
+	if *revision != "" {
+		diff, err = generator.DiffRevision(*openAPIFile, *revision)
+	} else {
+		diff, err = generator.DiffFiles(*baseFile, *openAPIFile)
+	}
+	if err != nil {
+		log.Fatalf("Failed to compare OpenAPI specs: %v", err)
+	}
+
+	changes := diff.Changes
+	if *breakingOnly {
+		changes = diff.Breaking()
+	}
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+
+	breaking := len(diff.Breaking())
+	if breaking > 0 {
+		fmt.Printf("❌ %d breaking change(s), %d change(s) in total\n", breaking, len(diff.Changes))
+		os.Exit(2)
+	}
+	fmt.Printf("✅ No breaking changes, %d change(s) in total\n", len(diff.Changes))
+}
+
+func showDiffHelp() {
+	fmt.Println("Insomnia Generator diff - Detect breaking changes between two versions of an OpenAPI spec")
+	fmt.Println("")
+	fmt.Println("Usage:")
+	fmt.Println("  insomnia-generator diff -base <old-openapi-file> -input <new-openapi-file> [-breaking-only]")
+	fmt.Println("  insomnia-generator diff -rev <git-revision> -input <openapi-file> [-breaking-only]")
+	fmt.Println("")
+	fmt.Println("Reports added and removed operations, removed or newly required parameters, request")
+	fmt.Println("body and response schema changes, enum value changes and status code changes. Exits")
+	fmt.Println("with status 2 when any change is breaking.")
+	fmt.Println("")
+	fmt.Println("Flags:")
+	fmt.Println("  -base           Path to the old version of the OpenAPI spec")
+	fmt.Println("  -rev            Git revision holding the old version of -input, e.g. HEAD or main")
+	fmt.Println("  -input          Path to the new version of the OpenAPI spec")
+	fmt.Println("  -breaking-only  Only list breaking changes")
+	fmt.Println("")
+	fmt.Println("Examples:")
+	fmt.Println("  insomnia-generator diff -base address-v1.yml -input address.yml")
+	fmt.Println("  insomnia-generator diff -rev main -input address.yml")
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/trafilea/go-template/internal/cliflags"
+	"github.com/trafilea/go-template/pkg/insomnia"
+)
+
+// runExport implements the export subcommand, which renders operations as curl or HTTPie
+// commands or as a HAR file
+func runExport(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	var (
+		openAPIFile = flags.String("input", "", "Path to OpenAPI spec file (required)")
+		outputFile  = flags.String("output", "", "Output file (optional, prints to stdout if not provided)")
+		formatName  = flags.String("format", "curl", "Export format: curl, httpie or har")
+		tags        = flags.String("tag", "", "Comma-separated tags of the operations to export (optional)")
+		operations  = flags.String("operation", "", "Comma-separated operationIds of the operations to export (optional)")
+		settings    = cliflags.Add(flags)
+	)
+	flags.Usage = showExportHelp
+	flags.Parse(args)
+
+	if *openAPIFile == "" {
+		fmt.Println("Error: OpenAPI spec file is required")
+		showExportHelp()
+		os.Exit(1)
+	}
+
+	format, err := insomnia.ParseFormat(*formatName)
+	if err != nil || (format != insomnia.FormatCurl && format != insomnia.FormatHTTPie && format != insomnia.FormatHAR) {
+		log.Fatalf("Invalid -format: expected curl, httpie or har, got %q", *formatName)
+	}
+
+	config, err := settings.Load()
+	if err != nil {
+		log.Fatalf("Invalid settings: %v", err)
+	}
+
+	generator := insomnia.NewGenerator(config.Options()...)
+
+	api, err := generator.ParseFile(*openAPIFile)
+	if err != nil {
+		log.Fatalf("Failed to parse OpenAPI spec: %v", err)
+	}
+	api = api.Filter(splitList(*tags), splitList(*operations))
+	if len(api.Folders) == 0 {
+		log.Fatalf("No operations match the given -tag and -operation filters")
+	}
+
+	if *outputFile == "" {
+		data, err := generator.RenderCommands(api, format)
+		if err != nil {
+			log.Fatalf("Failed to export %s: %v", format, err)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+
+	if err := generator.WriteCommands(api, format, *outputFile); err != nil {
+		log.Fatalf("Failed to export %s: %v", format, err)
+	}
+	fmt.Printf("✅ Successfully exported %s file: %s\n", format, *outputFile)
+}
+
+// splitList splits a comma-separated flag value, ignoring empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func showExportHelp() {
+	fmt.Println("Insomnia Generator export - Render OpenAPI operations as curl or HTTPie commands, or a HAR file")
+	fmt.Println("")
+	fmt.Println("Usage:")
+	fmt.Println("  insomnia-generator export -input <openapi-file> [-format curl|httpie|har] [-tag <tags>] [-operation <ids>] [-output <file>]")
+	fmt.Println("")
+	fmt.Println("Flags:")
+	fmt.Println("  -input      Path to OpenAPI spec file (YAML or JSON format)")
+	fmt.Println("  -format     Export format: curl (default), httpie or har")
+	fmt.Println("  -tag        Only export operations with one of these comma-separated tags")
+	fmt.Println("  -operation  Only export operations with one of these comma-separated operationIds")
+	fmt.Println("  -output     Output file (optional, prints to stdout if not provided)")
+	fmt.Println("  -deterministic")
+	fmt.Println("              Use a fixed timestamp in HAR files (default: true)")
+	fmt.Println("  -env-overlay")
+	fmt.Println("              Comma-separated YAML, JSON or dotenv files providing variable defaults")
+	fmt.Println("  -overlay-server")
+	fmt.Println("              Comma-separated environment=server pairs mapping overlay environments to servers")
+	fmt.Println("  -config     Configuration file with generator settings (default: .insomnia-generator.yaml)")
+	fmt.Println("")
+	fmt.Println("The other generator settings, such as -request-name and the -include-*/-exclude-* filters,")
+	fmt.Println("are accepted as for generation; see 'insomnia-generator -help'.")
+	fmt.Println("")
+	fmt.Println("Examples:")
+	fmt.Println("  insomnia-generator export -input address.yml")
+	fmt.Println("  insomnia-generator export -input address.yml -format httpie -tag addresses")
+	fmt.Println("  insomnia-generator export -input address.yml -operation getCustomerAddress")
+	fmt.Println("  insomnia-generator export -input address.yml -format har -output address.har")
+}
@@ -6,15 +6,34 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
 
+	"github.com/trafilea/go-template/internal/cliflags"
 	"github.com/trafilea/go-template/pkg/insomnia"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			runExport(os.Args[2:])
+			return
+		case "reverse":
+			runReverse(os.Args[2:])
+			return
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		}
+	}
+
 	var (
 		openAPIFile = flag.String("input", "", "Path to OpenAPI spec file (required)")
-		outputFile  = flag.String("output", "", "Output path for Insomnia file (optional, auto-generated if not provided)")
+		outputFile  = flag.String("output", "", "Output path for the generated file (optional, auto-generated if not provided)")
+		formatName  = flag.String("format", "insomnia", "Output format: insomnia, postman, bruno or http")
+		update      = flag.Bool("update", false, "Merge into an existing output file, preserving IDs and edits made in Insomnia")
+		prune       = flag.Bool("prune", false, "With -update, delete requests whose operations were removed instead of moving them to a \"Removed\" folder")
+		split       = flag.Bool("split", false, "Write one Insomnia workspace per tag, named after the output file and the tag")
+		settings    = cliflags.Add(flag.CommandLine)
 		help        = flag.Bool("help", false, "Show help message")
 	)
 	flag.Parse()
@@ -30,6 +49,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	format, err := insomnia.ParseFormat(*formatName)
+	if err != nil {
+		log.Fatalf("Invalid -format: %v", err)
+	}
+	config, err := settings.Load()
+	if err != nil {
+		log.Fatalf("Invalid settings: %v", err)
+	}
+	if *update && format != insomnia.FormatInsomnia {
+		log.Fatalf("-update is only supported for the insomnia format")
+	}
+	if *split && (*update || format != insomnia.FormatInsomnia) {
+		log.Fatalf("-split is only supported for the insomnia format, without -update")
+	}
+
 	// Check if input file exists
 	if _, err := os.Stat(*openAPIFile); os.IsNotExist(err) {
 		log.Fatalf("OpenAPI file does not exist: %s", *openAPIFile)
@@ -37,44 +71,121 @@ func main() {
 
 	// Generate output filename if not provided
 	if *outputFile == "" {
-		*outputFile = generateOutputFileName(*openAPIFile)
+		*outputFile = generateOutputFileName(*openAPIFile, format)
 	}
 
 	// Create generator and process file
-	generator := insomnia.NewGenerator()
+	generator := insomnia.NewGenerator(config.Options()...)
 
-	fmt.Printf("Generating Insomnia file from OpenAPI spec...\n")
+	fmt.Printf("Generating %s file from OpenAPI spec...\n", format)
 	fmt.Printf("Input:  %s\n", *openAPIFile)
 	fmt.Printf("Output: %s\n", *outputFile)
 
-	err := generator.GenerateToFile(*openAPIFile, *outputFile)
+	if *split {
+		files, err := generator.GenerateSplitToFiles(*openAPIFile, *outputFile)
+		if err != nil {
+			log.Fatalf("Failed to generate %s files: %v", format, err)
+		}
+		for _, file := range files {
+			fmt.Printf("✅ Successfully generated %s file: %s\n", format, file)
+		}
+		return
+	}
+
+	if *update {
+		err = generator.UpdateToFile(*openAPIFile, *outputFile, *prune)
+	} else {
+		err = generator.ExportToFile(*openAPIFile, *outputFile, format)
+	}
 	if err != nil {
-		log.Fatalf("Failed to generate Insomnia file: %v", err)
+		log.Fatalf("Failed to generate %s file: %v", format, err)
 	}
 
-	fmt.Printf("✅ Successfully generated Insomnia file: %s\n", *outputFile)
+	fmt.Printf("✅ Successfully generated %s file: %s\n", format, *outputFile)
 }
 
 func showHelp() {
-	fmt.Println("Insomnia Generator - Convert OpenAPI specs to Insomnia workspace files")
+	fmt.Println("Insomnia Generator - Convert OpenAPI specs to Insomnia workspace files, Postman, Bruno or .http collections")
 	fmt.Println("")
 	fmt.Println("Usage:")
-	fmt.Println("  insomnia-generator -input <openapi-file> [-output <insomnia-file>]")
+	fmt.Println("  insomnia-generator -input <openapi-file> [-output <insomnia-file>] [-update [-prune]]")
+	fmt.Println("  insomnia-generator -input <openapi-file> -format postman [-output <collection-file>]")
+	fmt.Println("  insomnia-generator export -input <openapi-file> -format curl|httpie|har [-tag <tags>] [-operation <ids>]")
+	fmt.Println("  insomnia-generator reverse -input <insomnia-file> [-output <openapi-file>]")
+	fmt.Println("  insomnia-generator diff -base <old-openapi-file>|-rev <git-revision> -input <openapi-file>")
 	fmt.Println("")
 	fmt.Println("Flags:")
-	fmt.Println("  -input   Path to OpenAPI spec file (YAML format)")
-	fmt.Println("  -output  Output path for Insomnia file (optional)")
+	fmt.Println("  -input   Path to OpenAPI spec file (YAML or JSON format)")
+	fmt.Println("  -output  Output path for the generated file (optional)")
+	fmt.Println("  -format  Output format: insomnia (default), postman, bruno or http. Postman environments")
+	fmt.Println("           are written next to the collection, one per server; bruno and http write")
+	fmt.Println("           a directory with one file per operation, grouped by tag")
+	fmt.Println("  -deterministic")
+	fmt.Println("           Derive IDs from the spec so regenerating is byte-identical (default: true)")
+	fmt.Println("  -update  Merge into an existing output file, preserving IDs and edits made in Insomnia")
+	fmt.Println("  -prune   With -update, delete removed operations instead of moving them to a \"Removed\" folder")
+	fmt.Println("  -nest-by-path")
+	fmt.Println("           Nest folders by path segment (/customers/{id}/address → customers › address)")
+	fmt.Println("           instead of grouping by tag")
+	fmt.Println("  -all-tags Place operations under every tag they list instead of only their first one")
+	fmt.Println("  -sort    Order of folders and requests: document (default, as in the spec),")
+	fmt.Println("           alphabetical or path (by path, then method)")
+	fmt.Println("  -env-overlay")
+	fmt.Println("           Comma-separated YAML, JSON or dotenv files applied in order to the environments;")
+	fmt.Println("           .env.<name> files set the variables of the <name> sub-environment")
+	fmt.Println("  -overlay-server")
+	fmt.Println("           Comma-separated environment=server pairs naming the server, by name or URL,")
+	fmt.Println("           an overlay environment overlays when it is not named after it")
+	fmt.Println("  -config  Configuration file with generator settings (default: .insomnia-generator.yaml,")
+	fmt.Println("           used when present); flags given on the command line override it")
+	fmt.Println("  -request-name")
+	fmt.Println("           Name template of requests: {{operationId}}, {{summary}}, {{method}} and {{path}}")
+	fmt.Println("           are replaced (default: the summary, or \"{{method}} {{path}}\" without one)")
+	fmt.Println("  -deprecated")
+	fmt.Println("           Deprecated operations: keep (default), prefix their names with \"[Deprecated] \"")
+	fmt.Println("           or move them to a \"Deprecated\" folder")
+	fmt.Println("  -response-examples")
+	fmt.Println("           Append the documented responses, with an example of their bodies, to request")
+	fmt.Println("           descriptions")
+	fmt.Println("  -response-tests")
+	fmt.Println("           Add after-response scripts testing the status code and body of responses")
+	fmt.Println("  -include-tag, -exclude-tag")
+	fmt.Println("           Comma-separated tags of the operations to generate or leave out")
+	fmt.Println("  -include-path, -exclude-path")
+	fmt.Println("           Comma-separated path globs; * matches within a segment, ** across segments")
+	fmt.Println("  -include-method, -exclude-method")
+	fmt.Println("           Comma-separated HTTP methods of the operations to generate or leave out")
+	fmt.Println("  -include-operation-id, -exclude-operation-id")
+	fmt.Println("           Regular expression matching the operationIds to generate or leave out")
+	fmt.Println("  -exclude-deprecated")
+	fmt.Println("           Leave out operations marked deprecated")
+	fmt.Println("  -include-extension, -exclude-extension")
+	fmt.Println("           Comma-separated x- extensions as name or name=value, e.g. x-internal=true")
+	fmt.Println("  -split   Write one Insomnia workspace per tag (<name>-<tag>-insomnia.yml)")
 	fmt.Println("  -help    Show this help message")
 	fmt.Println("")
 	fmt.Println("Examples:")
 	fmt.Println("  insomnia-generator -input api.yml")
 	fmt.Println("  insomnia-generator -input address.yml -output insomnia-address.yml")
+	fmt.Println("  insomnia-generator -input address.yml -deterministic=false")
+	fmt.Println("  insomnia-generator -input address.yml -output address_insomnia.yaml -update")
+	fmt.Println("  insomnia-generator -input address.yml -format postman")
+	fmt.Println("  insomnia-generator -input address.yml -format bruno -output ./collections/address")
+	fmt.Println("  insomnia-generator -input api.yml -nest-by-path")
+	fmt.Println("  insomnia-generator -input api.yml -sort alphabetical")
+	fmt.Println("  insomnia-generator -input address.yml -env-overlay configuration/eks/.env.dev,secrets.yml \\")
+	fmt.Println("    -overlay-server 'dev=Local development server'")
+	fmt.Println("  insomnia-generator -input api.yml -request-name '{{method}} {{path}}' -deprecated folder")
+	fmt.Println("  insomnia-generator -input api.yml -exclude-deprecated -exclude-extension x-internal=true")
+	fmt.Println("  insomnia-generator -input api.yml -include-path '/customers/**' -split")
+	fmt.Println("  insomnia-generator export -input address.yml -format curl -tag addresses")
+	fmt.Println("  insomnia-generator reverse -input legacy-insomnia.yml -output legacy.yml")
+	fmt.Println("  insomnia-generator diff -rev main -input address.yml")
+	fmt.Println("")
+	fmt.Println("Run 'insomnia-generator <export|reverse|diff> -help' for the flags of the subcommands.")
 }
 
-func generateOutputFileName(inputFile string) string {
-	// Get the base name without extension
-	baseName := strings.TrimSuffix(filepath.Base(inputFile), filepath.Ext(inputFile))
-
-	// Create output filename
-	return fmt.Sprintf("%s-insomnia.yml", baseName)
+func generateOutputFileName(inputFile string, format insomnia.Format) string {
+	// Create output filename in the current directory
+	return filepath.Base(insomnia.OutputFileName(inputFile, format))
 }
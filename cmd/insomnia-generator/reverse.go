@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/trafilea/go-template/pkg/insomnia"
+)
+
+// runReverse implements the reverse subcommand, which recovers an OpenAPI spec from an
+// Insomnia workspace and reports how its requests drifted from the embedded spec
+func runReverse(args []string) {
+	flags := flag.NewFlagSet("reverse", flag.ExitOnError)
+	var (
+		insomniaFile = flags.String("input", "", "Path to Insomnia workspace file (required)")
+		outputFile   = flags.String("output", "", "Output OpenAPI file, JSON when it ends in .json (optional, prints YAML to stdout if not provided)")
+		failOnDrift  = flags.Bool("fail-on-drift", false, "Exit with status 2 when the requests drifted from spec.contents")
+	)
+	flags.Usage = showReverseHelp
+	flags.Parse(args)
+
+	if *insomniaFile == "" {
+		fmt.Println("Error: Insomnia workspace file is required")
+		showReverseHelp()
+		os.Exit(1)
+	}
+
+	generator := insomnia.NewDeterministicGenerator()
+
+	var drift []insomnia.Drift
+	if *outputFile == "" {
+		result, err := generator.ReverseFile(*insomniaFile)
+		if err != nil {
+			log.Fatalf("Failed to recover OpenAPI spec: %v", err)
+		}
+		data, err := insomnia.MarshalOpenAPI(result.Document, false)
+		if err != nil {
+			log.Fatalf("Failed to recover OpenAPI spec: %v", err)
+		}
+		os.Stdout.Write(data)
+		drift = result.Drift
+	} else {
+		var err error
+		drift, err = generator.ReverseToFile(*insomniaFile, *outputFile)
+		if err != nil {
+			log.Fatalf("Failed to recover OpenAPI spec: %v", err)
+		}
+		fmt.Printf("✅ Successfully recovered OpenAPI spec: %s\n", *outputFile)
+	}
+
+	// Drift goes to stderr so it does not mix with a spec printed to stdout
+	for _, item := range drift {
+		fmt.Fprintf(os.Stderr, "⚠️  %s\n", item)
+	}
+	if len(drift) > 0 && *failOnDrift {
+		os.Exit(2)
+	}
+}
+
+func showReverseHelp() {
+	fmt.Println("Insomnia Generator reverse - Recover an OpenAPI spec from an Insomnia workspace")
+	fmt.Println("")
+	fmt.Println("Usage:")
+	fmt.Println("  insomnia-generator reverse -input <insomnia-file> [-output <openapi-file>] [-fail-on-drift]")
+	fmt.Println("")
+	fmt.Println("When the workspace embeds its spec (spec.contents), that spec is written and every")
+	fmt.Println("difference between it and the requests is reported. Otherwise an OpenAPI 3.0 skeleton")
+	fmt.Println("is built from the requests: folders become tags, {{ _.name }} URL variables become")
+	fmt.Println("path parameters and bodies become examples with inferred schemas.")
+	fmt.Println("")
+	fmt.Println("Flags:")
+	fmt.Println("  -input          Path to a spec.insomnia.rest/5.0 workspace file")
+	fmt.Println("  -output         Output OpenAPI file, JSON when it ends in .json (optional, prints YAML to stdout)")
+	fmt.Println("  -fail-on-drift  Exit with status 2 when the requests drifted from spec.contents")
+	fmt.Println("")
+	fmt.Println("Examples:")
+	fmt.Println("  insomnia-generator reverse -input legacy-insomnia.yml -output legacy.yml")
+	fmt.Println("  insomnia-generator reverse -input address-insomnia.yml -fail-on-drift > /dev/null")
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/trafilea/go-template/pkg/insomnia"
+)
+
+// execHook returns a hook running a shell command after each regeneration that changed an
+// output, passing the spec, output and format in INSOMNIA_SPEC, INSOMNIA_OUTPUT and
+// INSOMNIA_FORMAT. The command is killed when the context is canceled.
+func execHook(ctx context.Context, command string) func(insomnia.WatchEvent) {
+	return func(event insomnia.WatchEvent) {
+		if !event.Changed {
+			return
+		}
+
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+		} else {
+			cmd = exec.CommandContext(ctx, "sh", "-c", command)
+		}
+		cmd.Env = append(os.Environ(),
+			"INSOMNIA_SPEC="+event.Spec,
+			"INSOMNIA_OUTPUT="+event.Output,
+			"INSOMNIA_FORMAT="+string(event.Format),
+		)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		log.Printf("Running: %s", command)
+		if err := cmd.Run(); err != nil && ctx.Err() == nil {
+			log.Printf("Command failed after regenerating %s: %v", event.Output, err)
+		}
+	}
+}
@@ -1,24 +1,31 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
-	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/trafilea/go-template/internal/cliflags"
 	"github.com/trafilea/go-template/pkg/insomnia"
 )
 
 func main() {
 	var (
-		directory = flag.String("dir", ".", "Directory to watch for OpenAPI files")
-		interval  = flag.Int("interval", 2, "Polling interval in seconds")
-		file      = flag.String("file", "", "Specific OpenAPI file to watch (optional)")
-		output    = flag.String("output", "", "Output file for specific file watching (optional)")
-		help      = flag.Bool("help", false, "Show help message")
+		directory     = flag.String("dir", ".", "Directory to watch for OpenAPI files")
+		interval      = flag.Int("interval", 2, "Polling interval in seconds, with -poll, on network filesystems and outside Linux")
+		poll          = flag.Bool("poll", false, "Poll files every -interval instead of using inotify file events")
+		debounce      = flag.Int("debounce", 100, "Milliseconds to wait for more file events before regenerating")
+		execCommand   = flag.String("exec", "", "Shell command to run after each regeneration that changed an output")
+		removeOutputs = flag.Bool("remove-outputs", false, "Delete the outputs of specs deleted from the watched directory")
+		file          = flag.String("file", "", "Specific OpenAPI file to watch (optional)")
+		output        = flag.String("output", "", "Output file for specific file watching (optional)")
+		formats       = flag.String("format", "insomnia", "Comma-separated output formats: insomnia, postman, bruno, http, curl, httpie, har")
+		settings      = cliflags.Add(flag.CommandLine)
+		help          = flag.Bool("help", false, "Show help message")
 	)
 	flag.Parse()
 
@@ -27,12 +34,31 @@ func main() {
 		return
 	}
 
+	outputFormats, err := insomnia.ParseFormats(*formats)
+	if err != nil {
+		log.Fatalf("Invalid -format: %v", err)
+	}
+	config, err := settings.Load()
+	if err != nil {
+		log.Fatalf("Invalid settings: %v", err)
+	}
+
 	// Create file watcher
 	watcher := insomnia.NewFileWatcher(time.Duration(*interval) * time.Second)
+	watcher.SetPolling(*poll)
+	watcher.SetDebounce(time.Duration(*debounce) * time.Millisecond)
+	watcher.SetRemoveOutputs(*removeOutputs)
+	watcher.SetDeterministicIDs(*config.Deterministic)
+	watcher.SetFormats(outputFormats...)
+	watcher.SetGeneratorOptions(config.Options()...)
 
-	// Set up signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// Cancel the watcher on SIGINT or SIGTERM, letting a regeneration in progress finish
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if *execCommand != "" {
+		watcher.SetHooks(insomnia.WatchHooks{OnRegenerated: execHook(ctx, *execCommand)})
+	}
 
 	if *file != "" {
 		// Watch specific file
@@ -47,25 +73,27 @@ func main() {
 		}
 		fmt.Printf("Polling interval: %d seconds\n", *interval)
 		fmt.Println("Press Ctrl+C to stop watching...")
-
-		// Start watching in a goroutine
-		go watcher.StartWatching()
 	} else {
 		// Auto-detect and watch directory
 		fmt.Printf("Setting up auto-detection for directory: %s\n", *directory)
+		fmt.Println("New, renamed and deleted OpenAPI files are followed while watching")
 		fmt.Printf("Polling interval: %d seconds\n", *interval)
 		fmt.Println("Press Ctrl+C to stop watching...")
 
-		// Start auto-detection in a goroutine
-		go func() {
-			if err := watcher.AutoDetectAndWatch(*directory); err != nil {
-				log.Printf("Error in auto-detection: %v", err)
-			}
-		}()
+		if err := watcher.DetectFiles(*directory); err != nil {
+			log.Fatalf("Error in auto-detection: %v", err)
+		}
+		if count := len(watcher.GetWatchedFiles()); count > 0 {
+			log.Printf("Found %d OpenAPI files to watch", count)
+		} else {
+			log.Printf("No OpenAPI files found in directory: %s", *directory)
+		}
 	}
 
-	// Wait for interrupt signal
-	<-sigChan
+	// Watch until interrupted
+	if err := watcher.Start(ctx); err != nil {
+		log.Fatalf("Failed to start watcher: %v", err)
+	}
 	fmt.Println("\n🛑 Shutting down watcher...")
 
 	// Show final status
@@ -93,8 +121,35 @@ func showHelp() {
 	fmt.Println("Flags:")
 	fmt.Println("  -dir      Directory to watch for OpenAPI files (default: current directory)")
 	fmt.Println("  -file     Specific OpenAPI file to watch")
-	fmt.Println("  -output   Output Insomnia file (only used with -file)")
-	fmt.Println("  -interval Polling interval in seconds (default: 2)")
+	fmt.Println("  -output   Output file of the first format (only used with -file)")
+	fmt.Println("  -format   Comma-separated output formats: insomnia, postman, bruno, http, curl, httpie, har (default: insomnia)")
+	fmt.Println("  -interval Polling interval in seconds (default: 2), used with -poll, for network")
+	fmt.Println("            filesystems and outside Linux")
+	fmt.Println("  -poll     Poll files instead of reacting to inotify file events")
+	fmt.Println("  -debounce Milliseconds to wait for more file events before regenerating (default: 100)")
+	fmt.Println("  -exec     Shell command to run after each regeneration that changed an output, given")
+	fmt.Println("            INSOMNIA_SPEC, INSOMNIA_OUTPUT and INSOMNIA_FORMAT")
+	fmt.Println("  -remove-outputs")
+	fmt.Println("            Delete the outputs of specs deleted from the watched directory")
+	fmt.Println("  -deterministic")
+	fmt.Println("            Derive IDs from the spec so regenerating is byte-identical (default: true)")
+	fmt.Println("  -nest-by-path")
+	fmt.Println("            Nest folders by path segment instead of grouping by tag")
+	fmt.Println("  -all-tags Place operations under every tag they list instead of only their first one")
+	fmt.Println("  -sort     Order of folders and requests: document (default, as in the spec),")
+	fmt.Println("            alphabetical or path (by path, then method)")
+	fmt.Println("  -env-overlay")
+	fmt.Println("            Comma-separated YAML, JSON or dotenv files applied in order to the environments")
+	fmt.Println("  -overlay-server")
+	fmt.Println("            Comma-separated environment=server pairs mapping overlay environments to servers")
+	fmt.Println("  -config   Configuration file with generator settings (default: .insomnia-generator.yaml,")
+	fmt.Println("            used when present); flags given on the command line override it")
+	fmt.Println("  -request-name, -deprecated, -response-examples, -response-tests")
+	fmt.Println("            Request names and contents, as for insomnia-generator")
+	fmt.Println("  -include-tag, -exclude-tag, -include-path, -exclude-path, -include-method, -exclude-method,")
+	fmt.Println("  -include-operation-id, -exclude-operation-id, -exclude-deprecated, -include-extension,")
+	fmt.Println("  -exclude-extension")
+	fmt.Println("            Operations to generate or leave out, as for insomnia-generator")
 	fmt.Println("  -help     Show this help message")
 	fmt.Println("")
 	fmt.Println("Examples:")
@@ -110,9 +165,23 @@ func showHelp() {
 	fmt.Println("  # Watch specific file with custom output")
 	fmt.Println("  insomnia-watcher -file address.yml -output my-insomnia.yml")
 	fmt.Println("")
+	fmt.Println("  # Run the linters after each regeneration")
+	fmt.Println("  insomnia-watcher -dir ./api-specs -exec 'make lint'")
+	fmt.Println("")
+	fmt.Println("  # Delete the outputs of specs deleted from the directory")
+	fmt.Println("  insomnia-watcher -dir ./api-specs -remove-outputs")
+	fmt.Println("")
+	fmt.Println("  # Keep Insomnia and Postman files up to date")
+	fmt.Println("  insomnia-watcher -dir ./api-specs -format insomnia,postman")
+	fmt.Println("")
 	fmt.Println("Notes:")
-	fmt.Println("  - Supported file extensions: .yml, .yaml")
+	fmt.Println("  - On Linux, changes are picked up through inotify within milliseconds")
+	fmt.Println("  - Supported file extensions: .yml, .yaml, .json")
 	fmt.Println("  - Auto-detection looks for files containing 'openapi:', 'swagger:', or 'info:' + 'paths:'")
+	fmt.Println("  - Generated outputs, Insomnia exports and '.backup' files are never picked up as specs")
+	fmt.Println("  - Specs created, renamed or deleted in the watched directory are followed")
 	fmt.Println("  - Generated Insomnia files have '-insomnia.yml' suffix by default")
+	fmt.Println("  - Generated Postman collections have '.postman_collection.json' suffix by default")
+	fmt.Println("  - Bruno and .http files are written to '-bruno' and '-http' directories by default")
 	fmt.Println("  - Use Ctrl+C to stop the watcher")
 }
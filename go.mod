@@ -2,7 +2,10 @@ module github.com/trafilea/go-template
 
 go 1.18
 
-require github.com/gin-gonic/gin v1.7.7
+require (
+	github.com/gin-gonic/gin v1.7.7
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/gin-contrib/sse v0.1.0 // indirect
@@ -19,5 +22,4 @@ require (
 	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9 // indirect
 	golang.org/x/sys v0.0.0-20200116001909-b77594299b42 // indirect
 	gopkg.in/yaml.v2 v2.2.8 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
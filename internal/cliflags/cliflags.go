@@ -0,0 +1,187 @@
+// Package cliflags defines the command line flags of the generator settings shared by the
+// insomnia-generator and insomnia-watcher CLIs
+package cliflags
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/trafilea/go-template/pkg/insomnia"
+)
+
+// Settings are the command line flags of the generator settings. Flags given on the
+// command line override the configuration file they load.
+type Settings struct {
+	flags *flag.FlagSet
+
+	configFile       *string
+	deterministic    *bool
+	nestByPath       *bool
+	allTags          *bool
+	sort             *string
+	envOverlays      *string
+	overlayServers   *string
+	requestName      *string
+	deprecated       *string
+	responseExamples *bool
+	responseTests    *bool
+
+	includeTags, excludeTags       *string
+	includePaths, excludePaths     *string
+	includeMethods, excludeMethods *string
+	includeOperationID             *string
+	excludeOperationID             *string
+	excludeDeprecated              *bool
+	includeExtensions              *string
+	excludeExtensions              *string
+}
+
+// Add defines the flags of the generator settings on a flag set
+func Add(flags *flag.FlagSet) *Settings {
+	return &Settings{
+		flags: flags,
+
+		configFile:       flags.String("config", insomnia.ConfigFileName, "Configuration file with generator settings, overridden by the flags given"),
+		deterministic:    flags.Bool("deterministic", true, "Derive IDs and timestamps from the spec so unchanged specs regenerate identically"),
+		nestByPath:       flags.Bool("nest-by-path", false, "Nest folders by path segment (/customers/{id}/address → customers › address) instead of grouping by tag"),
+		allTags:          flags.Bool("all-tags", false, "Place operations under every tag they list instead of only their first one"),
+		sort:             flags.String("sort", "document", "Order of folders and requests: document, alphabetical or path"),
+		envOverlays:      flags.String("env-overlay", "", "Comma-separated YAML, JSON or dotenv files adding or overriding environment variables"),
+		overlayServers:   flags.String("overlay-server", "", "Comma-separated environment=server pairs mapping overlay environments to the name or URL of the server they overlay"),
+		requestName:      flags.String("request-name", "", "Name template of requests with {{operationId}}, {{summary}}, {{method}} and {{path}} (default: the summary)"),
+		deprecated:       flags.String("deprecated", "keep", "Handling of deprecated operations: keep, prefix (\"[Deprecated] \" names) or folder"),
+		responseExamples: flags.Bool("response-examples", false, "Append the documented responses, with an example of their bodies, to request descriptions"),
+		responseTests:    flags.Bool("response-tests", false, "Add after-response scripts testing status codes and response bodies against the spec"),
+
+		includeTags:        flags.String("include-tag", "", "Comma-separated tags of the operations to generate"),
+		excludeTags:        flags.String("exclude-tag", "", "Comma-separated tags of the operations to leave out"),
+		includePaths:       flags.String("include-path", "", "Comma-separated path globs of the operations to generate, e.g. /customers/**"),
+		excludePaths:       flags.String("exclude-path", "", "Comma-separated path globs of the operations to leave out"),
+		includeMethods:     flags.String("include-method", "", "Comma-separated HTTP methods of the operations to generate"),
+		excludeMethods:     flags.String("exclude-method", "", "Comma-separated HTTP methods of the operations to leave out"),
+		includeOperationID: flags.String("include-operation-id", "", "Regular expression matching the operationIds to generate"),
+		excludeOperationID: flags.String("exclude-operation-id", "", "Regular expression matching the operationIds to leave out"),
+		excludeDeprecated:  flags.Bool("exclude-deprecated", false, "Leave out deprecated operations"),
+		includeExtensions:  flags.String("include-extension", "", "Comma-separated x- extensions, as name or name=value, of the operations to generate"),
+		excludeExtensions:  flags.String("exclude-extension", "", "Comma-separated x- extensions, as name or name=value, of the operations to leave out, e.g. x-internal=true"),
+	}
+}
+
+// Load reads the configuration file, which may be missing unless -config was given, and
+// applies the flags given on the command line over it. Overlays, overlay servers and filter
+// criteria given on the command line are added to the configured ones. Call it once the
+// flags are parsed.
+func (f *Settings) Load() (*insomnia.Config, error) {
+	set := make(map[string]bool)
+	f.flags.Visit(func(given *flag.Flag) {
+		set[given.Name] = true
+	})
+
+	config, err := insomnia.LoadConfig(*f.configFile)
+	if errors.Is(err, fs.ErrNotExist) && !set["config"] {
+		config, err = &insomnia.Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid -config: %w", err)
+	}
+
+	if set["sort"] || config.Sort == "" {
+		if config.Sort, err = insomnia.ParseSortOrder(*f.sort); err != nil {
+			return nil, fmt.Errorf("invalid -sort: %w", err)
+		}
+	}
+	if set["deterministic"] || config.Deterministic == nil {
+		deterministic := *f.deterministic
+		config.Deterministic = &deterministic
+	}
+	if set["nest-by-path"] {
+		config.NestByPath = *f.nestByPath
+	}
+	if set["all-tags"] {
+		config.AllTags = *f.allTags
+	}
+	if set["request-name"] {
+		config.RequestName = *f.requestName
+	}
+	if set["deprecated"] {
+		if config.Deprecated, err = insomnia.ParseDeprecatedHandling(*f.deprecated); err != nil {
+			return nil, fmt.Errorf("invalid -deprecated: %w", err)
+		}
+	}
+	if set["response-examples"] {
+		config.ResponseExamples = *f.responseExamples
+	}
+	if set["response-tests"] {
+		config.ResponseTests = *f.responseTests
+	}
+	config.EnvOverlays = append(config.EnvOverlays, splitFlagList(*f.envOverlays)...)
+	overlayServers, err := insomnia.ParseOverlayServers(*f.overlayServers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -overlay-server: %w", err)
+	}
+	for name, server := range overlayServers {
+		if config.OverlayServers == nil {
+			config.OverlayServers = make(map[string]string)
+		}
+		config.OverlayServers[name] = server
+	}
+
+	if err := f.applyFilter(&config.Filter); err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	return config, nil
+}
+
+// applyFilter adds the criteria given on the command line to a filter
+func (f *Settings) applyFilter(filter *insomnia.OperationFilter) error {
+	filter.Include.Tags = append(filter.Include.Tags, splitFlagList(*f.includeTags)...)
+	filter.Exclude.Tags = append(filter.Exclude.Tags, splitFlagList(*f.excludeTags)...)
+	filter.Include.Paths = append(filter.Include.Paths, splitFlagList(*f.includePaths)...)
+	filter.Exclude.Paths = append(filter.Exclude.Paths, splitFlagList(*f.excludePaths)...)
+	filter.Include.Methods = append(filter.Include.Methods, splitFlagList(*f.includeMethods)...)
+	filter.Exclude.Methods = append(filter.Exclude.Methods, splitFlagList(*f.excludeMethods)...)
+	if *f.includeOperationID != "" {
+		filter.Include.OperationID = *f.includeOperationID
+	}
+	if *f.excludeOperationID != "" {
+		filter.Exclude.OperationID = *f.excludeOperationID
+	}
+	if *f.excludeDeprecated {
+		filter.Exclude.Deprecated = true
+	}
+
+	for _, extensions := range []struct {
+		list   string
+		target *map[string]string
+	}{
+		{*f.includeExtensions, &filter.Include.Extensions},
+		{*f.excludeExtensions, &filter.Exclude.Extensions},
+	} {
+		parsed, err := insomnia.ParseExtensions(extensions.list)
+		if err != nil {
+			return err
+		}
+		for name, value := range parsed {
+			if *extensions.target == nil {
+				*extensions.target = make(map[string]string)
+			}
+			(*extensions.target)[name] = value
+		}
+	}
+
+	return filter.Validate()
+}
+
+// splitFlagList splits a comma-separated flag value, dropping empty items
+func splitFlagList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
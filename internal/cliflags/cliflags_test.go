@@ -0,0 +1,63 @@
+package cliflags
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/trafilea/go-template/pkg/insomnia"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, insomnia.ConfigFileName)
+	err := os.WriteFile(file, []byte(`
+sort: alphabetical
+requestName: "{{operationId}}"
+deprecated: prefix
+envOverlays: [.env.dev]
+filter:
+  exclude:
+    tags: [internal]
+`), 0644)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	settings := Add(flags)
+	err = flags.Parse([]string{"-config", file, "-sort", "path", "-env-overlay", "secrets.yml", "-exclude-tag", "admin", "-response-tests", "-overlay-server", "dev=Local development server"})
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	config, err := settings.Load()
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if config.Sort != insomnia.SortByPath || config.RequestName != "{{operationId}}" || config.Deprecated != insomnia.DeprecatedPrefix {
+		t.Errorf("Test failed. Expected the flags given to override the file only, got %+v", config)
+	}
+	if config.Deterministic == nil || !*config.Deterministic || !config.ResponseTests || config.ResponseExamples {
+		t.Errorf("Test failed. Expected flag defaults for unset settings, got %+v", config)
+	}
+	if len(config.EnvOverlays) != 2 || config.EnvOverlays[1] != "secrets.yml" {
+		t.Errorf("Test failed. Expected overlays given on the command line after the configured ones, got %v", config.EnvOverlays)
+	}
+	if config.OverlayServers["dev"] != "Local development server" {
+		t.Errorf("Test failed. Expected the overlay servers given on the command line, got %v", config.OverlayServers)
+	}
+	if len(config.Filter.Exclude.Tags) != 2 || config.Filter.Exclude.Tags[1] != "admin" {
+		t.Errorf("Test failed. Expected filter criteria to be added, got %v", config.Filter.Exclude.Tags)
+	}
+
+	flags = flag.NewFlagSet("test", flag.ContinueOnError)
+	settings = Add(flags)
+	if err := flags.Parse([]string{"-config", filepath.Join(dir, "missing.yaml")}); err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if _, err := settings.Load(); err == nil {
+		t.Errorf("Test failed. Expected an error for a missing configuration file given explicitly")
+	}
+}
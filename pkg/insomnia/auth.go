@@ -0,0 +1,265 @@
+package insomnia
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RequestAuthentication configures how Insomnia authenticates a request
+type RequestAuthentication struct {
+	Type     string `yaml:"type"`
+	Disabled bool   `yaml:"disabled,omitempty"`
+
+	// Bearer
+	Token  string `yaml:"token,omitempty"`
+	Prefix string `yaml:"prefix,omitempty"`
+
+	// Basic and digest
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+
+	// API key
+	Key   string `yaml:"key,omitempty"`
+	Value string `yaml:"value,omitempty"`
+	AddTo string `yaml:"addTo,omitempty"`
+
+	// OAuth 2.0
+	GrantType        string `yaml:"grantType,omitempty"`
+	AuthorizationURL string `yaml:"authorizationUrl,omitempty"`
+	AccessTokenURL   string `yaml:"accessTokenUrl,omitempty"`
+	ClientID         string `yaml:"clientId,omitempty"`
+	ClientSecret     string `yaml:"clientSecret,omitempty"`
+	Scope            string `yaml:"scope,omitempty"`
+}
+
+// apiKeyLocations maps OpenAPI apiKey locations to Insomnia's addTo values
+var apiKeyLocations = map[string]string{
+	"header": "header",
+	"query":  "queryParams",
+	"cookie": "cookie",
+}
+
+// variableNamePattern matches runs of characters that are not allowed in environment variable names
+var variableNamePattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// securitySchemes maps OpenAPI security requirements to request authentication,
+// referencing secrets through variables of the API
+type securitySchemes struct {
+	schemes      map[string]map[string]interface{}
+	requirements []interface{}
+	prefixes     map[string]string
+}
+
+// newSecuritySchemes reads components.securitySchemes and the top-level security requirements
+func newSecuritySchemes(document map[string]interface{}, refs *refResolver) *securitySchemes {
+	s := &securitySchemes{
+		schemes:  make(map[string]map[string]interface{}),
+		prefixes: make(map[string]string),
+	}
+	s.requirements, _ = document["security"].([]interface{})
+
+	components, _ := document["components"].(map[string]interface{})
+	declared, _ := components["securitySchemes"].(map[string]interface{})
+
+	kinds := make(map[string][]string)
+	for name, scheme := range declared {
+		schemeMap := refs.resolveMap(scheme)
+		kind := schemeKind(schemeMap)
+		if kind == "" {
+			continue
+		}
+		s.schemes[name] = schemeMap
+		kinds[kind] = append(kinds[kind], name)
+	}
+
+	// Variables are named after the kind of scheme (bearer_token, basic_username, ...)
+	// unless several schemes of the same kind need telling apart
+	for kind, names := range kinds {
+		for _, name := range names {
+			if len(names) == 1 {
+				s.prefixes[name] = kind
+			} else {
+				s.prefixes[name] = strings.Trim(variableNamePattern.ReplaceAllString(toSnakeCase(name), "_"), "_")
+			}
+		}
+	}
+
+	return s
+}
+
+// authentication returns the authentication of an operation, honoring operation-level
+// security overrides such as `security: []` for public endpoints
+func (s *securitySchemes) authentication(operation map[string]interface{}) *Auth {
+	requirements := s.requirements
+	if opRequirements, ok := operation["security"]; ok {
+		requirements, _ = opRequirements.([]interface{})
+	}
+
+	for _, requirement := range requirements {
+		requirementMap, ok := requirement.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// An empty requirement makes authentication optional
+		if len(requirementMap) == 0 {
+			return nil
+		}
+
+		names := make([]string, 0, len(requirementMap))
+		for name := range requirementMap {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if auth := s.schemeAuthentication(name, mediaTypes(requirementMap[name])); auth != nil {
+				return auth
+			}
+		}
+	}
+
+	return nil
+}
+
+// schemeAuthentication builds the authentication for a single security scheme
+func (s *securitySchemes) schemeAuthentication(name string, scopes []string) *Auth {
+	scheme, ok := s.schemes[name]
+	if !ok {
+		return nil
+	}
+	prefix := s.prefixes[name]
+
+	switch schemeKind(scheme) {
+	case "bearer":
+		return &Auth{Type: "bearer", Token: variableRef(prefix + "_token")}
+	case "basic":
+		return &Auth{
+			Type:     "basic",
+			Username: variableRef(prefix + "_username"),
+			Password: variableRef(prefix + "_password"),
+		}
+	case "digest":
+		return &Auth{
+			Type:     "digest",
+			Username: variableRef(prefix + "_username"),
+			Password: variableRef(prefix + "_password"),
+		}
+	case "api_key":
+		key, _ := scheme["name"].(string)
+		in, _ := scheme["in"].(string)
+		return &Auth{
+			Type:  "apikey",
+			Key:   key,
+			Value: variableRef(prefix),
+			In:    in,
+		}
+	case "oauth2":
+		auth := &Auth{
+			Type:         "oauth2",
+			ClientID:     variableRef(prefix + "_client_id"),
+			ClientSecret: variableRef(prefix + "_client_secret"),
+			Scopes:       scopes,
+		}
+
+		flows, _ := scheme["flows"].(map[string]interface{})
+		if flow, ok := flows["clientCredentials"].(map[string]interface{}); ok {
+			auth.GrantType = "client_credentials"
+			auth.AccessTokenURL, _ = flow["tokenUrl"].(string)
+		} else if flow, ok := flows["authorizationCode"].(map[string]interface{}); ok {
+			auth.GrantType = "authorization_code"
+			auth.AuthorizationURL, _ = flow["authorizationUrl"].(string)
+			auth.AccessTokenURL, _ = flow["tokenUrl"].(string)
+		} else {
+			return nil
+		}
+		return auth
+	}
+
+	return nil
+}
+
+// variables returns the variables referenced by the declared schemes
+func (s *securitySchemes) variables() map[string]string {
+	variables := make(map[string]string)
+	for name, scheme := range s.schemes {
+		prefix := s.prefixes[name]
+		switch schemeKind(scheme) {
+		case "bearer":
+			variables[prefix+"_token"] = ""
+		case "basic", "digest":
+			variables[prefix+"_username"] = ""
+			variables[prefix+"_password"] = ""
+		case "api_key":
+			variables[prefix] = ""
+		case "oauth2":
+			variables[prefix+"_client_id"] = ""
+			variables[prefix+"_client_secret"] = ""
+		}
+	}
+	return variables
+}
+
+// insomniaAuthentication converts the authentication of an operation to Insomnia's format
+func insomniaAuthentication(auth *Auth) *RequestAuthentication {
+	if auth == nil {
+		return nil
+	}
+
+	return &RequestAuthentication{
+		Type:             auth.Type,
+		Token:            insomniaTemplate(auth.Token),
+		Username:         insomniaTemplate(auth.Username),
+		Password:         insomniaTemplate(auth.Password),
+		Key:              auth.Key,
+		Value:            insomniaTemplate(auth.Value),
+		AddTo:            apiKeyLocations[auth.In],
+		GrantType:        auth.GrantType,
+		AuthorizationURL: auth.AuthorizationURL,
+		AccessTokenURL:   auth.AccessTokenURL,
+		ClientID:         insomniaTemplate(auth.ClientID),
+		ClientSecret:     insomniaTemplate(auth.ClientSecret),
+		Scope:            strings.Join(auth.Scopes, " "),
+	}
+}
+
+// schemeKind classifies a security scheme, returning an empty string for unsupported ones
+func schemeKind(scheme map[string]interface{}) string {
+	switch scheme["type"] {
+	case "http":
+		httpScheme, _ := scheme["scheme"].(string)
+		switch strings.ToLower(httpScheme) {
+		case "bearer":
+			return "bearer"
+		case "basic":
+			return "basic"
+		case "digest":
+			return "digest"
+		}
+	case "apiKey":
+		return "api_key"
+	case "oauth2":
+		return "oauth2"
+	}
+	return ""
+}
+
+// variableRef returns the reference to a variable of the API
+func variableRef(name string) string {
+	return "{{" + name + "}}"
+}
+
+// toSnakeCase converts a camelCase name such as bearerAuth into bearer_auth
+func toSnakeCase(name string) string {
+	var builder strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				builder.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
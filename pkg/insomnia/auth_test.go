@@ -0,0 +1,112 @@
+package insomnia
+
+import (
+	"testing"
+)
+
+const securitySpec = `
+openapi: 3.0.3
+info:
+  title: Secure API
+  version: 1.0.0
+security:
+  - bearerAuth: []
+paths:
+  /health:
+    get:
+      summary: Health check
+      security: []
+  /orders:
+    get:
+      summary: List orders
+    post:
+      summary: Create order
+      security:
+        - partnerKey: []
+  /reports:
+    get:
+      summary: Get reports
+      security:
+        - oauth: [reports:read, reports:write]
+  /admin:
+    get:
+      summary: Admin
+      security:
+        - basicAuth: []
+components:
+  securitySchemes:
+    bearerAuth:
+      type: http
+      scheme: bearer
+      bearerFormat: JWT
+    basicAuth:
+      type: http
+      scheme: basic
+    partnerKey:
+      type: apiKey
+      in: query
+      name: key
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://auth.example.com/token
+          scopes:
+            reports:read: Read reports
+`
+
+func TestGenerateAuthentication(t *testing.T) {
+	spec, err := NewGenerator().GenerateFromOpenAPI([]byte(securitySpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if auth := findRequest(spec, "Health check").Authentication; auth != nil {
+		t.Errorf("Test failed. Expected security: [] to disable authentication, got %v", auth)
+	}
+
+	bearer := findRequest(spec, "List orders").Authentication
+	if bearer == nil || bearer.Type != "bearer" || bearer.Token != "{{ _.bearer_token }}" {
+		t.Errorf("Test failed. Expected global bearer authentication, got %v", bearer)
+	}
+
+	apiKey := findRequest(spec, "Create order").Authentication
+	if apiKey == nil || apiKey.Type != "apikey" || apiKey.Key != "key" || apiKey.AddTo != "queryParams" || apiKey.Value != "{{ _.api_key }}" {
+		t.Errorf("Test failed. Expected query API key authentication, got %v", apiKey)
+	}
+
+	oauth := findRequest(spec, "Get reports").Authentication
+	if oauth == nil || oauth.GrantType != "client_credentials" || oauth.AccessTokenURL != "https://auth.example.com/token" || oauth.Scope != "reports:read reports:write" {
+		t.Errorf("Test failed. Expected OAuth2 client credentials authentication, got %v", oauth)
+	}
+
+	basic := findRequest(spec, "Admin").Authentication
+	if basic == nil || basic.Type != "basic" || basic.Username != "{{ _.basic_username }}" || basic.Password != "{{ _.basic_password }}" {
+		t.Errorf("Test failed. Expected basic authentication, got %v", basic)
+	}
+
+	for _, variable := range []string{"bearer_token", "basic_username", "basic_password", "api_key", "oauth2_client_id", "oauth2_client_secret"} {
+		if _, ok := spec.Environments.Data.Extra[variable]; !ok {
+			t.Errorf("Test failed. Expected base environment variable %s", variable)
+		}
+	}
+}
+
+func TestSecurityVariablesForSameKind(t *testing.T) {
+	document := map[string]interface{}{
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"userToken":    map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"serviceToken": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+	security := newSecuritySchemes(document, newRefResolver(document))
+
+	variables := security.variables()
+	for _, variable := range []string{"user_token_token", "service_token_token"} {
+		if _, ok := variables[variable]; !ok {
+			t.Errorf("Test failed. Expected variable %s, got %v", variable, variables)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package insomnia
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSchemaDepth limits how deep example synthesis descends into nested schemas
+const maxSchemaDepth = 8
+
+// preferredMediaTypes lists request body media types in order of preference
+var preferredMediaTypes = []string{
+	"application/json",
+	"application/x-www-form-urlencoded",
+	"multipart/form-data",
+	"text/plain",
+}
+
+// buildRequestBody creates the request body from the operation's requestBody,
+// using its examples or synthesizing one from the schema
+func (g *Generator) buildRequestBody(operation map[string]interface{}, refs *refResolver) *Body {
+	requestBody := refs.resolveMap(operation["requestBody"])
+	if requestBody == nil {
+		return nil
+	}
+
+	content := refs.resolveMap(requestBody["content"])
+	mimeType := selectMediaType(content)
+	if mimeType == "" {
+		return nil
+	}
+
+	media := refs.resolveMap(content[mimeType])
+	value, found := exampleFromMediaType(media, refs)
+
+	body := &Body{MimeType: mimeType}
+	if !found {
+		return body
+	}
+
+	switch {
+	case isFormMediaType(mimeType):
+		body.Params = formParams(value)
+	case isJSONMediaType(mimeType):
+		body.Text = renderJSON(value)
+	default:
+		if text, ok := value.(string); ok {
+			body.Text = text
+		} else {
+			body.Text = renderJSON(value)
+		}
+	}
+
+	return body
+}
+
+// selectMediaType picks the most convenient media type offered by a content map
+func selectMediaType(content map[string]interface{}) string {
+	if len(content) == 0 {
+		return ""
+	}
+
+	for _, mimeType := range preferredMediaTypes {
+		if _, ok := content[mimeType]; ok {
+			return mimeType
+		}
+	}
+
+	mimeTypes := make([]string, 0, len(content))
+	for mimeType := range content {
+		mimeTypes = append(mimeTypes, mimeType)
+	}
+	sort.Strings(mimeTypes)
+
+	for _, mimeType := range mimeTypes {
+		if isJSONMediaType(mimeType) {
+			return mimeType
+		}
+	}
+
+	return mimeTypes[0]
+}
+
+// exampleFromMediaType returns the example of a media type object, falling back to its schema
+func exampleFromMediaType(media map[string]interface{}, refs *refResolver) (interface{}, bool) {
+	if media == nil {
+		return nil, false
+	}
+
+	if example, ok := media["example"]; ok {
+		return example, true
+	}
+
+	if examples := refs.resolveMap(media["examples"]); len(examples) > 0 {
+		names := make([]string, 0, len(examples))
+		for name := range examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if example := refs.resolveMap(examples[name]); example != nil {
+				if value, ok := example["value"]; ok {
+					return value, true
+				}
+			}
+		}
+	}
+
+	if schema, ok := media["schema"]; ok {
+		return exampleFromSchema(schema, refs, make(map[string]bool), 0), true
+	}
+
+	return nil, false
+}
+
+// exampleFromSchema synthesizes an example value from a schema, following $refs
+// and guarding against circular definitions
+func exampleFromSchema(node interface{}, refs *refResolver, visiting map[string]bool, depth int) interface{} {
+	if depth > maxSchemaDepth {
+		return nil
+	}
+
+	if ref, ok := refOf(node); ok {
+		if visiting[ref] {
+			return nil
+		}
+		visiting[ref] = true
+		defer delete(visiting, ref)
+		return exampleFromSchema(refs.lookup(ref), refs, visiting, depth+1)
+	}
+
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	for _, key := range []string{"example", "default", "const"} {
+		if value, ok := schema[key]; ok {
+			return value
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		merged := make(map[string]interface{})
+		for _, part := range allOf {
+			if value, ok := exampleFromSchema(part, refs, visiting, depth+1).(map[string]interface{}); ok {
+				for k, v := range value {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options, ok := schema[key].([]interface{}); ok && len(options) > 0 {
+			return exampleFromSchema(options[0], refs, visiting, depth+1)
+		}
+	}
+
+	schemaType, _ := schema["type"].(string)
+	if schemaType == "" {
+		if _, ok := schema["properties"]; ok {
+			schemaType = "object"
+		} else if _, ok := schema["items"]; ok {
+			schemaType = "array"
+		}
+	}
+
+	switch schemaType {
+	case "object":
+		result := make(map[string]interface{})
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, property := range properties {
+			if resolved := refs.resolveMap(property); resolved != nil && resolved["readOnly"] == true {
+				continue
+			}
+			result[name] = exampleFromSchema(property, refs, visiting, depth+1)
+		}
+		return result
+	case "array":
+		item := exampleFromSchema(schema["items"], refs, visiting, depth+1)
+		if item == nil {
+			return []interface{}{}
+		}
+		return []interface{}{item}
+	case "string":
+		return exampleString(schema)
+	case "integer":
+		if minimum, ok := schema["minimum"]; ok {
+			return minimum
+		}
+		return 0
+	case "number":
+		if minimum, ok := schema["minimum"]; ok {
+			return minimum
+		}
+		return 0.0
+	case "boolean":
+		return false
+	}
+
+	return nil
+}
+
+// exampleString returns a placeholder string matching the schema format
+func exampleString(schema map[string]interface{}) string {
+	format, _ := schema["format"].(string)
+	switch format {
+	case "date":
+		return "2024-01-01"
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "uri", "url":
+		return "https://example.com"
+	}
+	return "string"
+}
+
+// formParams converts an example object into form body parameters
+func formParams(value interface{}) []BodyParam {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]BodyParam, 0, len(names))
+	for _, name := range names {
+		params = append(params, BodyParam{Name: name, Value: scalarString(fields[name])})
+	}
+	return params
+}
+
+// scalarString renders a value for use in form fields, query strings and headers
+func scalarString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		return renderJSON(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// renderJSON pretty-prints a value as JSON
+func renderJSON(value interface{}) string {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// isJSONMediaType reports whether a media type carries JSON
+func isJSONMediaType(mimeType string) bool {
+	return mimeType == "application/json" || strings.HasSuffix(mimeType, "+json")
+}
+
+// isFormMediaType reports whether a media type is sent as form fields
+func isFormMediaType(mimeType string) bool {
+	return mimeType == "application/x-www-form-urlencoded" || mimeType == "multipart/form-data"
+}
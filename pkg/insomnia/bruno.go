@@ -0,0 +1,220 @@
+package insomnia
+
+import (
+	"fmt"
+	"strings"
+)
+
+// brunoBodyModes maps media types to the body mode of a Bruno request and the name of its body block
+var brunoBodyModes = map[string][2]string{
+	"application/json":                  {"json", "body:json"},
+	"application/x-www-form-urlencoded": {"formUrlEncoded", "body:form-urlencoded"},
+	"multipart/form-data":               {"multipartForm", "body:multipart-form"},
+	"application/xml":                   {"xml", "body:xml"},
+	"text/xml":                          {"xml", "body:xml"},
+}
+
+// brunoAPIKeyPlacements maps OpenAPI apiKey locations to Bruno's placement values
+var brunoAPIKeyPlacements = map[string]string{
+	"header": "header",
+	"query":  "queryparams",
+}
+
+// GenerateBrunoToDir writes a Bruno collection for an OpenAPI file to dir: a bruno.json,
+// one directory per tag holding one .bru file per operation, and environments/*.bru
+func (g *Generator) GenerateBrunoToDir(openAPIFile, dir string) error {
+	api, err := g.ParseFile(openAPIFile)
+	if err != nil {
+		return fmt.Errorf("failed to generate Bruno collection: %w", err)
+	}
+
+	_, err = writeFiles(dir, g.BuildBruno(api))
+	return err
+}
+
+// BuildBruno converts the API model to the files of a Bruno collection, keyed by their
+// path relative to the collection directory
+func (g *Generator) BuildBruno(api *API) map[string]string {
+	files := map[string]string{
+		"bruno.json": renderJSON(map[string]interface{}{
+			"version": "1",
+			"name":    api.Title,
+			"type":    "collection",
+			"ignore":  []string{"node_modules", ".git"},
+		}) + "\n",
+	}
+
+	brunoFolders(files, "", api.Folders)
+
+	for _, server := range environmentServers(api) {
+		var vars, secrets []string
+		vars = append(vars, "base_url", server.URL())
+		for _, name := range sortedKeys(server.Variables) {
+			// Bruno keeps the values of secret variables out of the collection
+			if server.Private {
+				secrets = append(secrets, name)
+			} else {
+				vars = append(vars, name, server.Variables[name])
+			}
+		}
+		for _, name := range sortedKeys(api.Variables) {
+			vars = append(vars, name, api.Variables[name])
+		}
+
+		var envFile brunoFile
+		envFile.block("vars", brunoDictionary(vars...))
+		if len(secrets) > 0 {
+			envFile.blocks = append(envFile.blocks, fmt.Sprintf("vars:secret [\n  %s\n]\n", strings.Join(secrets, ",\n  ")))
+		}
+		files["environments/"+fileName(server.Name)+".bru"] = envFile.String()
+	}
+
+	return files
+}
+
+// brunoFolders adds the files of a folder tree to files, one directory per folder
+func brunoFolders(files map[string]string, parent string, folders []Folder) {
+	for i, folder := range folders {
+		dir := parent + fileName(folder.Name)
+
+		var folderFile brunoFile
+		folderFile.block("meta", brunoDictionary("name", folder.Name, "seq", fmt.Sprint(i+1)))
+		folderFile.block("docs", folder.Description)
+		files[dir+"/folder.bru"] = folderFile.String()
+
+		brunoFolders(files, dir+"/", folder.Folders)
+		for j, name := range operationFileNames(folder, ".bru") {
+			files[dir+"/"+name] = brunoRequest(folder.Operations[j], j+1)
+		}
+	}
+}
+
+// brunoRequest renders the .bru file of an operation
+func brunoRequest(operation Operation, seq int) string {
+	bodyMode, bodyBlock := "none", ""
+	if body := operation.Body; body != nil {
+		bodyMode, bodyBlock = "text", "body:text"
+		if mode, ok := brunoBodyModes[body.MimeType]; ok {
+			bodyMode, bodyBlock = mode[0], mode[1]
+		} else if isJSONMediaType(body.MimeType) {
+			bodyMode, bodyBlock = "json", "body:json"
+		}
+	}
+
+	authMode := "none"
+	if auth := operation.Auth; auth != nil && !(auth.Type == "apikey" && auth.In == "cookie") {
+		authMode = auth.Type
+	}
+
+	requestURL := operation.URL
+	if !operation.Webhook {
+		requestURL = "{{base_url}}/" + strings.Join(pathVariableSegments(operation), "/")
+	}
+	if query := queryString(operation.Query, false); query != "" {
+		requestURL += "?" + query
+	}
+
+	var file brunoFile
+	file.block("meta", brunoDictionary("name", operation.Name, "type", "http", "seq", fmt.Sprint(seq)))
+	file.block(strings.ToLower(operation.Method), brunoDictionary("url", requestURL, "body", bodyMode, "auth", authMode))
+	file.block("params:query", brunoParameters(operation.Query))
+	file.block("params:path", brunoParameters(operation.PathParameters))
+
+	headers := append([]Parameter{}, operation.Headers...)
+	if auth := operation.Auth; auth != nil && auth.Type == "apikey" && auth.In == "cookie" {
+		headers = append(headers, Parameter{Name: "Cookie", Value: auth.Key + "=" + auth.Value})
+	}
+	file.block("headers", brunoParameters(headers))
+
+	if auth := operation.Auth; authMode != "none" {
+		file.block("auth:"+authMode, brunoAuth(auth))
+	}
+
+	if body := operation.Body; body != nil {
+		if isFormMediaType(body.MimeType) {
+			var fields []Parameter
+			for _, param := range body.Params {
+				fields = append(fields, Parameter{Name: param.Name, Value: param.Value})
+			}
+			file.block(bodyBlock, brunoParameters(fields))
+		} else {
+			file.block(bodyBlock, body.Text)
+		}
+	}
+
+	file.block("docs", operation.Description)
+	return file.String()
+}
+
+// brunoAuth renders the settings of an authentication block
+func brunoAuth(auth *Auth) string {
+	switch auth.Type {
+	case "bearer":
+		return brunoDictionary("token", auth.Token)
+	case "basic", "digest":
+		return brunoDictionary("username", auth.Username, "password", auth.Password)
+	case "apikey":
+		return brunoDictionary("key", auth.Key, "value", auth.Value, "placement", brunoAPIKeyPlacements[auth.In])
+	case "oauth2":
+		pairs := []string{"grant_type", auth.GrantType}
+		if auth.AuthorizationURL != "" {
+			pairs = append(pairs, "authorization_url", auth.AuthorizationURL)
+		}
+		pairs = append(pairs,
+			"access_token_url", auth.AccessTokenURL,
+			"client_id", auth.ClientID,
+			"client_secret", auth.ClientSecret,
+			"scope", strings.Join(auth.Scopes, " "),
+		)
+		return brunoDictionary(pairs...)
+	}
+	return ""
+}
+
+// brunoParameters renders parameters as dictionary entries, disabling optional ones with ~
+func brunoParameters(params []Parameter) string {
+	var pairs []string
+	for _, param := range params {
+		name := param.Name
+		if param.Disabled {
+			name = "~" + name
+		}
+		pairs = append(pairs, name, param.Value)
+	}
+	return brunoDictionary(pairs...)
+}
+
+// brunoDictionary renders key/value pairs as the "key: value" lines of a block
+func brunoDictionary(pairs ...string) string {
+	lines := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		value := strings.ReplaceAll(pairs[i+1], "\n", " ")
+		lines = append(lines, strings.TrimRight(pairs[i]+": "+value, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// brunoFile builds the blocks of a .bru file
+type brunoFile struct {
+	blocks []string
+}
+
+// block appends a block with the given content, skipping empty blocks
+func (f *brunoFile) block(name, content string) {
+	if strings.TrimSpace(content) == "" {
+		return
+	}
+
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "  " + line
+		}
+	}
+	f.blocks = append(f.blocks, fmt.Sprintf("%s {\n%s\n}\n", name, strings.Join(lines, "\n")))
+}
+
+// String returns the contents of the file
+func (f *brunoFile) String() string {
+	return strings.Join(f.blocks, "\n")
+}
@@ -0,0 +1,83 @@
+package insomnia
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildBruno(t *testing.T) {
+	g := NewDeterministicGenerator()
+	api, err := g.ParseOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	files := g.BuildBruno(api)
+
+	request, ok := files["addresses/Create customer address.bru"]
+	if !ok {
+		t.Fatalf("Test failed. Expected one file per operation in its tag directory, got %v", files)
+	}
+	for _, expected := range []string{
+		"  url: {{base_url}}/customers/:customerId/address\n",
+		"  body: json\n",
+		"params:path {\n  customerId:\n}",
+		"body:json {\n  {\n    \"isDefault\": false,",
+	} {
+		if !strings.Contains(request, expected) {
+			t.Errorf("Test failed. Expected %q in:\n%s", expected, request)
+		}
+	}
+
+	users := files["users/Get all users.bru"]
+	for _, expected := range []string{
+		"  url: {{base_url}}/users?status=active&status=pending\n",
+		"  ~limit: 10\n",
+		"headers {\n  X-Tenant: acme\n  ~X-Request-ID: abc-123\n}",
+	} {
+		if !strings.Contains(users, expected) {
+			t.Errorf("Test failed. Expected %q in:\n%s", expected, users)
+		}
+	}
+
+	env, ok := files["environments/OpenAPI env localhost-8082.bru"]
+	if !ok || !strings.Contains(env, "base_url: http://localhost:8082/api/v1") {
+		t.Errorf("Test failed. Expected environment per server, got %v", env)
+	}
+}
+
+func TestBuildBrunoAuthentication(t *testing.T) {
+	g := NewGenerator()
+	api, err := g.ParseOpenAPI([]byte(securitySpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	files := g.BuildBruno(api)
+
+	if health := files["default/Health check.bru"]; !strings.Contains(health, "auth: none") {
+		t.Errorf("Test failed. Expected no authentication, got:\n%s", health)
+	}
+	order := files["default/Create order.bru"]
+	if !strings.Contains(order, "auth:apikey {\n  key: key\n  value: {{api_key}}\n  placement: queryparams\n}") {
+		t.Errorf("Test failed. Expected query API key authentication, got:\n%s", order)
+	}
+}
+
+func TestGenerateBrunoToDir(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "api.yml")
+	if err := os.WriteFile(input, []byte(testSpec), 0644); err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if err := NewDeterministicGenerator().ExportToFile(input, OutputFileName(input, FormatBruno), FormatBruno); err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	for _, file := range []string{"bruno.json", "nodes/Replace node.bru", "nodes/folder.bru"} {
+		if _, err := os.Stat(filepath.Join(dir, "api-bruno", file)); err != nil {
+			t.Errorf("Test failed. Expected %s to be written: %v", file, err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package insomnia
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BuildCurl renders every operation of the API as a curl command of a shell script
+func (g *Generator) BuildCurl(api *API) string {
+	script := newShellScript()
+	walkOperations(api.Folders, nil, func(path []string, operation Operation) {
+		script.command(strings.Join(path, "/"), operation, curlCommand(script, operation))
+	})
+	return script.render("curl", api)
+}
+
+// BuildHTTPie renders every operation of the API as an HTTPie command of a shell script
+func (g *Generator) BuildHTTPie(api *API) string {
+	script := newShellScript()
+	walkOperations(api.Folders, nil, func(path []string, operation Operation) {
+		script.command(strings.Join(path, "/"), operation, httpieCommand(script, operation))
+	})
+	return script.render("HTTPie", api)
+}
+
+// curlCommand returns the lines of the curl command of an operation
+func curlCommand(script *shellScript, operation Operation) []string {
+	query := operation.Query
+	var options []string
+
+	for _, header := range operation.Headers {
+		if !header.Disabled && !isMultipartContentType(header, operation.Body) {
+			options = append(options, "-H "+script.word(header.Name+": "+header.Value))
+		}
+	}
+
+	if auth := operation.Auth; auth != nil {
+		switch auth.Type {
+		case "bearer":
+			options = append(options, "-H "+script.word("Authorization: Bearer "+auth.Token))
+		case "basic":
+			options = append(options, "-u "+script.word(auth.Username+":"+auth.Password))
+		case "digest":
+			options = append(options, "--digest -u "+script.word(auth.Username+":"+auth.Password))
+		case "apikey":
+			switch auth.In {
+			case "query":
+				query = append(append([]Parameter{}, query...), Parameter{Name: auth.Key, Value: auth.Value})
+			case "cookie":
+				options = append(options, "-b "+script.word(auth.Key+"="+auth.Value))
+			default:
+				options = append(options, "-H "+script.word(auth.Key+": "+auth.Value))
+			}
+		case "oauth2":
+			options = append(options, "-H "+script.word("Authorization: Bearer {{access_token}}"))
+		}
+	}
+
+	if body := operation.Body; body != nil {
+		switch body.MimeType {
+		case "application/x-www-form-urlencoded":
+			for _, param := range body.Params {
+				options = append(options, "--data-urlencode "+shellQuote(param.Name+"="+param.Value))
+			}
+		case "multipart/form-data":
+			for _, param := range body.Params {
+				options = append(options, "--form-string "+shellQuote(param.Name+"="+param.Value))
+			}
+		default:
+			if body.Text != "" {
+				options = append(options, "--data-raw "+shellQuote(body.Text))
+			}
+		}
+	}
+
+	requestURL := exampleURL(operation)
+	if q := queryString(query, true); q != "" {
+		requestURL += "?" + q
+	}
+
+	return append([]string{fmt.Sprintf("curl -X %s %s", operation.Method, script.word(requestURL))}, options...)
+}
+
+// httpieCommand returns the lines of the HTTPie command of an operation
+func httpieCommand(script *shellScript, operation Operation) []string {
+	var flags, items []string
+
+	for _, param := range operation.Query {
+		if !param.Disabled {
+			items = append(items, script.word(param.Name+"=="+param.Value))
+		}
+	}
+	for _, header := range operation.Headers {
+		if !header.Disabled && !isMultipartContentType(header, operation.Body) {
+			items = append(items, script.word(header.Name+":"+header.Value))
+		}
+	}
+
+	if auth := operation.Auth; auth != nil {
+		switch auth.Type {
+		case "bearer":
+			flags = append(flags, "-A bearer -a "+script.word(auth.Token))
+		case "basic":
+			flags = append(flags, "-a "+script.word(auth.Username+":"+auth.Password))
+		case "digest":
+			flags = append(flags, "-A digest -a "+script.word(auth.Username+":"+auth.Password))
+		case "apikey":
+			switch auth.In {
+			case "query":
+				items = append(items, script.word(auth.Key+"=="+auth.Value))
+			case "cookie":
+				items = append(items, script.word("Cookie:"+auth.Key+"="+auth.Value))
+			default:
+				items = append(items, script.word(auth.Key+":"+auth.Value))
+			}
+		case "oauth2":
+			flags = append(flags, "-A bearer -a "+script.word("{{access_token}}"))
+		}
+	}
+
+	var raw string
+	if body := operation.Body; body != nil {
+		switch body.MimeType {
+		case "application/x-www-form-urlencoded", "multipart/form-data":
+			if body.MimeType == "multipart/form-data" {
+				flags = append(flags, "--multipart")
+			} else {
+				flags = append(flags, "--form")
+			}
+			for _, param := range body.Params {
+				items = append(items, shellQuote(param.Name+"="+param.Value))
+			}
+		default:
+			if body.Text != "" {
+				raw = "--raw " + shellQuote(body.Text)
+			}
+		}
+	}
+
+	// Options go before the method so they are not mistaken for request items
+	command := strings.Join(append([]string{"http"}, flags...), " ")
+	request := fmt.Sprintf("%s %s", operation.Method, script.word(exampleURL(operation)))
+
+	lines := []string{command + " " + request}
+	if raw != "" {
+		lines = []string{command, raw, request}
+	}
+	return append(lines, items...)
+}
+
+// isMultipartContentType reports whether a header is the Content-Type of a multipart body,
+// which the tools set themselves to include the boundary
+func isMultipartContentType(header Parameter, body *Body) bool {
+	return body != nil && body.MimeType == "multipart/form-data" && strings.EqualFold(header.Name, "Content-Type")
+}
+
+// shellScript collects the commands of a script and the variables they reference
+type shellScript struct {
+	commands  strings.Builder
+	variables map[string]string
+}
+
+// newShellScript creates an empty script
+func newShellScript() *shellScript {
+	return &shellScript{variables: make(map[string]string)}
+}
+
+// word double-quotes a value for POSIX shells, turning {{name}} variable references
+// into ${NAME} expansions
+func (s *shellScript) word(value string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+
+	last := 0
+	for _, match := range variablePattern.FindAllStringSubmatchIndex(value, -1) {
+		name := value[match[2]:match[3]]
+		variable := shellVariable(name)
+		s.variables[variable] = name
+
+		builder.WriteString(escapeDoubleQuoted(value[last:match[0]]))
+		builder.WriteString("${" + variable + "}")
+		last = match[1]
+	}
+	builder.WriteString(escapeDoubleQuoted(value[last:]))
+
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+// command appends the lines of an operation's command, preceded by a comment naming it
+func (s *shellScript) command(folder string, operation Operation, lines []string) {
+	fmt.Fprintf(&s.commands, "# %s: %s\n", folder, operation.Name)
+	if operation.Auth != nil && operation.Auth.Type == "oauth2" {
+		fmt.Fprintf(&s.commands, "# OAuth 2.0 (%s): set ACCESS_TOKEN to a token from %s\n", operation.Auth.GrantType, operation.Auth.AccessTokenURL)
+	}
+	s.commands.WriteString(strings.Join(lines, " \\\n  "))
+	s.commands.WriteString("\n\n")
+}
+
+// render returns the script, assigning the referenced variables from the environment with
+// defaults taken from the first server and the variables of the API
+func (s *shellScript) render(tool string, api *API) string {
+	var builder strings.Builder
+	builder.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&builder, "# %s commands for %s %s, generated from its OpenAPI spec.\n", tool, api.Title, api.Version)
+	builder.WriteString("# Copy single commands, or run the script to send every request.\n")
+	builder.WriteString("# Set the variables below in the environment to override their defaults.\n\n")
+
+	variables := make([]string, 0, len(s.variables))
+	for variable := range s.variables {
+		variables = append(variables, variable)
+	}
+	sort.Strings(variables)
+
+	for _, variable := range variables {
+		name := s.variables[variable]
+		value := api.Variables[name]
+		if name == "base_url" {
+			value = environmentServers(api)[0].URL()
+		}
+		fmt.Fprintf(&builder, "%s=\"${%s:-%s}\"\n", variable, variable, escapeDoubleQuoted(value))
+	}
+	if len(variables) > 0 {
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString(s.commands.String())
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+// shellVariable names the shell variable holding a variable of the API, e.g. customerId
+// becomes CUSTOMER_ID
+func shellVariable(name string) string {
+	return strings.ToUpper(strings.Trim(variableNamePattern.ReplaceAllString(toSnakeCase(name), "_"), "_"))
+}
+
+// escapeDoubleQuoted escapes the characters that are special inside double quotes
+func escapeDoubleQuoted(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	return replacer.Replace(value)
+}
+
+// shellQuote single-quotes a value for POSIX shells
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
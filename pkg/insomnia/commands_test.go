@@ -0,0 +1,89 @@
+package insomnia
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildCurl(t *testing.T) {
+	g := NewDeterministicGenerator()
+	api, err := g.ParseOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	script := g.BuildCurl(api)
+
+	if !strings.HasPrefix(script, "#!/bin/sh\n") {
+		t.Errorf("Test failed. Expected a shell script, got:\n%s", script)
+	}
+	if !strings.Contains(script, "BASE_URL=\"${BASE_URL:-http://localhost:8082/api/v1}\"\n") {
+		t.Errorf("Test failed. Expected base URL defaulting to the first server, got:\n%s", script)
+	}
+	expected := "# users: Get all users\n" +
+		"curl -X GET \"${BASE_URL}/users?status=active&status=pending\" \\\n" +
+		"  -H \"X-Tenant: acme\"\n"
+	if !strings.Contains(script, expected) {
+		t.Errorf("Test failed. Expected:\n%s\ngot:\n%s", expected, script)
+	}
+	if !strings.Contains(script, "curl -X POST \"${BASE_URL}/customers/${CUSTOMER_ID}/address\" \\\n  -H \"Content-Type: application/json\" \\\n  --data-raw '{") {
+		t.Errorf("Test failed. Expected path variable, headers and body, got:\n%s", script)
+	}
+}
+
+func TestBuildCurlAuthentication(t *testing.T) {
+	g := NewGenerator()
+	api, err := g.ParseOpenAPI([]byte(securitySpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	script := g.BuildCurl(api)
+
+	for _, expected := range []string{
+		"curl -X GET \"${BASE_URL}/admin\" \\\n  -u \"${BASIC_USERNAME}:${BASIC_PASSWORD}\"\n",
+		"curl -X GET \"${BASE_URL}/orders\" \\\n  -H \"Authorization: Bearer ${BEARER_TOKEN}\"\n",
+		"curl -X POST \"${BASE_URL}/orders?key=${API_KEY}\"\n",
+		"curl -X GET \"${BASE_URL}/health\"\n",
+		"ACCESS_TOKEN=\"${ACCESS_TOKEN:-}\"\n",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("Test failed. Expected:\n%s\ngot:\n%s", expected, script)
+		}
+	}
+}
+
+func TestBuildHTTPie(t *testing.T) {
+	g := NewDeterministicGenerator()
+	api, err := g.ParseOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	script := g.BuildHTTPie(api)
+
+	expected := "http GET \"${BASE_URL}/users\" \\\n" +
+		"  \"status==active\" \\\n" +
+		"  \"status==pending\" \\\n" +
+		"  \"X-Tenant:acme\"\n"
+	if !strings.Contains(script, expected) {
+		t.Errorf("Test failed. Expected:\n%s\ngot:\n%s", expected, script)
+	}
+	if !strings.Contains(script, "http \\\n  --raw '{\n  \"name\": \"John\"\n}' \\\n  POST \"${BASE_URL}/customers\" \\\n") {
+		t.Errorf("Test failed. Expected raw body before the request line, got:\n%s", script)
+	}
+}
+
+func TestFilterAPI(t *testing.T) {
+	g := NewDeterministicGenerator()
+	api, err := g.ParseOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	byTag := api.Filter([]string{"users"}, nil)
+	if len(byTag.Folders) != 1 || byTag.Folders[0].Name != "users" {
+		t.Errorf("Test failed. Expected only the users folder, got %v", byTag.Folders)
+	}
+
+	if none := api.Filter([]string{"unknown"}, nil); len(none.Folders) != 0 {
+		t.Errorf("Test failed. Expected no folders, got %v", none.Folders)
+	}
+}
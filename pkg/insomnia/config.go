@@ -0,0 +1,151 @@
+package insomnia
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the configuration file the CLIs load from the working directory
+const ConfigFileName = ".insomnia-generator.yaml"
+
+// Config holds generator settings read from a configuration file such as
+//
+//	deterministic: true
+//	sort: alphabetical
+//	nestByPath: false
+//	allTags: false
+//	embedSpec: false
+//	specType: spec.insomnia.rest/5.0
+//	folderDescription: "Endpoints of %s"
+//	environmentName: "{description} ({host})"
+//	requestName: "{{operationId}}"
+//	deprecated: folder
+//	responseExamples: true
+//	responseTests: true
+//	envOverlays: [configuration/eks/.env.dev]
+//	overlayServers:
+//	  dev: Development server
+//	requestSettings:
+//	  followRedirects: "on"
+//	  cookies:
+//	    store: false
+//	filter:
+//	  include:
+//	    paths: [/customers/**]
+//	  exclude:
+//	    deprecated: true
+//	    extensions:
+//	      x-internal: "true"
+//
+// Unset fields keep the defaults of the generator; requestSettings only overrides the
+// settings it lists.
+type Config struct {
+	Deterministic     *bool              `yaml:"deterministic"`
+	Sort              SortOrder          `yaml:"sort"`
+	NestByPath        bool               `yaml:"nestByPath"`
+	AllTags           bool               `yaml:"allTags"`
+	EmbedSpec         *bool              `yaml:"embedSpec"`
+	SpecType          string             `yaml:"specType"`
+	FolderDescription string             `yaml:"folderDescription"`
+	EnvironmentName   string             `yaml:"environmentName"`
+	RequestName       string             `yaml:"requestName"`
+	Deprecated        DeprecatedHandling `yaml:"deprecated"`
+	ResponseExamples  bool               `yaml:"responseExamples"`
+	ResponseTests     bool               `yaml:"responseTests"`
+	EnvOverlays       []string           `yaml:"envOverlays"`
+	OverlayServers    map[string]string  `yaml:"overlayServers"`
+	RequestSettings   *RequestSettings   `yaml:"requestSettings"`
+	Filter            OperationFilter    `yaml:"filter"`
+}
+
+// LoadConfig reads a configuration file. Overlay files are resolved relative to it.
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	settings := defaultRequestSettings
+	config := &Config{RequestSettings: &settings}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", file, err)
+	}
+
+	if config.Sort != "" {
+		if config.Sort, err = ParseSortOrder(string(config.Sort)); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", file, err)
+		}
+	}
+
+	if config.Deprecated != "" {
+		if config.Deprecated, err = ParseDeprecatedHandling(string(config.Deprecated)); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", file, err)
+		}
+	}
+
+	if err := config.Filter.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", file, err)
+	}
+
+	for i, overlay := range config.EnvOverlays {
+		if !filepath.IsAbs(overlay) {
+			config.EnvOverlays[i] = filepath.Join(filepath.Dir(file), overlay)
+		}
+	}
+
+	return config, nil
+}
+
+// Options returns the generator options of the configuration
+func (c *Config) Options() []Option {
+	var options []Option
+	if c.Deterministic != nil && *c.Deterministic {
+		options = append(options, WithDeterministicIDs())
+	}
+	if c.Sort != "" {
+		options = append(options, WithSortOrder(c.Sort))
+	}
+	if c.NestByPath || c.AllTags {
+		options = append(options, WithFolderLayout(FolderLayout{ByPath: c.NestByPath, AllTags: c.AllTags}))
+	}
+	if c.EmbedSpec != nil && !*c.EmbedSpec {
+		options = append(options, WithoutEmbeddedSpec())
+	}
+	if c.SpecType != "" {
+		options = append(options, WithSpecType(c.SpecType))
+	}
+	if c.FolderDescription != "" {
+		options = append(options, WithFolderDescription(c.FolderDescription))
+	}
+	if c.EnvironmentName != "" {
+		options = append(options, WithEnvironmentName(c.EnvironmentName))
+	}
+	if c.RequestName != "" {
+		options = append(options, WithRequestName(c.RequestName))
+	}
+	if c.Deprecated != "" {
+		options = append(options, WithDeprecatedHandling(c.Deprecated))
+	}
+	if c.ResponseExamples {
+		options = append(options, WithResponseExamples())
+	}
+	if c.ResponseTests {
+		options = append(options, WithResponseTests())
+	}
+	if len(c.EnvOverlays) > 0 {
+		options = append(options, WithEnvironmentOverlays(c.EnvOverlays...))
+	}
+	if len(c.OverlayServers) > 0 {
+		options = append(options, WithOverlayServers(c.OverlayServers))
+	}
+	if c.RequestSettings != nil {
+		options = append(options, WithRequestSettings(*c.RequestSettings))
+	}
+	if !c.Filter.IsEmpty() {
+		options = append(options, WithOperationFilter(c.Filter))
+	}
+	return options
+}
@@ -0,0 +1,64 @@
+package insomnia
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	file := writeTestFile(t, dir, ConfigFileName, `
+deterministic: true
+sort: Alphabetical
+nestByPath: true
+embedSpec: false
+envOverlays: [.env.dev]
+requestSettings:
+  followRedirects: "off"
+filter:
+  exclude:
+    deprecated: true
+    extensions:
+      x-internal: "true"
+`)
+
+	config, err := LoadConfig(file)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if config.Sort != SortAlphabetically || !config.NestByPath || config.EmbedSpec == nil || *config.EmbedSpec {
+		t.Errorf("Test failed. Expected the settings of the file, got %+v", config)
+	}
+	if len(config.EnvOverlays) != 1 || config.EnvOverlays[0] != filepath.Join(dir, ".env.dev") {
+		t.Errorf("Test failed. Expected overlays relative to the config file, got %v", config.EnvOverlays)
+	}
+
+	settings := *config.RequestSettings
+	if settings.FollowRedirects != "off" || !settings.EncodeURL || !settings.Cookies.Send {
+		t.Errorf("Test failed. Expected listed request settings to override the defaults only, got %+v", settings)
+	}
+
+	if !config.Filter.Exclude.Deprecated || config.Filter.Exclude.Extensions["x-internal"] != "true" {
+		t.Errorf("Test failed. Expected the filter of the file, got %+v", config.Filter)
+	}
+
+	g := NewGenerator(config.Options()...)
+	if !g.deterministic || g.sortOrder != SortAlphabetically || !g.layout.ByPath || !g.omitSpec || g.settings != settings || g.filter.IsEmpty() {
+		t.Errorf("Test failed. Expected the options of the config, got %+v", g)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := LoadConfig(filepath.Join(dir, ConfigFileName)); err == nil {
+		t.Errorf("Test failed. Expected an error for a missing config file")
+	}
+	if _, err := LoadConfig(writeTestFile(t, dir, "bad.yaml", "sort: random\n")); err == nil {
+		t.Errorf("Test failed. Expected an error for an unknown sort order")
+	}
+	if _, err := LoadConfig(writeTestFile(t, dir, "filter.yaml", "filter:\n  include:\n    operationId: \"(\"\n")); err == nil {
+		t.Errorf("Test failed. Expected an error for an invalid operationId expression")
+	}
+}
@@ -0,0 +1,499 @@
+package insomnia
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Change is a difference between two versions of an OpenAPI spec
+type Change struct {
+	Operation string // method and path, e.g. GET /customers/{customerId}
+	Message   string
+	Breaking  bool
+}
+
+// String describes the change, flagging breaking ones
+func (c Change) String() string {
+	level := "non-breaking"
+	if c.Breaking {
+		level = "BREAKING"
+	}
+	return fmt.Sprintf("[%s] %s: %s", level, c.Operation, c.Message)
+}
+
+// SpecDiff lists the changes between two versions of an OpenAPI spec, in path order
+type SpecDiff struct {
+	Changes []Change
+}
+
+// Breaking returns the changes that break existing clients
+func (d *SpecDiff) Breaking() []Change {
+	var breaking []Change
+	for _, change := range d.Changes {
+		if change.Breaking {
+			breaking = append(breaking, change)
+		}
+	}
+	return breaking
+}
+
+// HasBreakingChanges reports whether any change breaks existing clients
+func (d *SpecDiff) HasBreakingChanges() bool {
+	return len(d.Breaking()) > 0
+}
+
+// DiffOpenAPI compares two versions of an OpenAPI spec. Operations are matched by method
+// and path, ignoring the names of path parameters; their parameters, request bodies and
+// responses are compared with $refs resolved.
+func (g *Generator) DiffOpenAPI(oldData, newData []byte) (*SpecDiff, error) {
+	return g.diff(oldData, "", newData, "")
+}
+
+// DiffFiles compares two OpenAPI files, resolving $refs to files next to each of them
+func (g *Generator) DiffFiles(oldFile, newFile string) (*SpecDiff, error) {
+	oldData, err := os.ReadFile(oldFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI file: %w", err)
+	}
+	newData, err := os.ReadFile(newFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI file: %w", err)
+	}
+
+	return g.diff(oldData, oldFile, newData, newFile)
+}
+
+// DiffRevision compares an OpenAPI file with its version at a git revision, such as HEAD
+// or main. $refs of the old version are resolved against the working tree.
+func (g *Generator) DiffRevision(openAPIFile, revision string) (*SpecDiff, error) {
+	oldData, err := ReadGitRevision(openAPIFile, revision)
+	if err != nil {
+		return nil, err
+	}
+	newData, err := os.ReadFile(openAPIFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI file: %w", err)
+	}
+
+	return g.diff(oldData, openAPIFile, newData, openAPIFile)
+}
+
+// ReadGitRevision returns the contents of a file at a git revision of its repository
+func ReadGitRevision(file, revision string) ([]byte, error) {
+	dir, name := filepath.Split(file)
+	if dir == "" {
+		dir = "."
+	}
+
+	cmd := exec.Command("git", "-C", dir, "show", revision+":./"+name)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s: %w: %s", file, revision, err, strings.TrimSpace(stderr.String()))
+	}
+	return data, nil
+}
+
+// diff compares OpenAPI data read from the given sources (empty when unknown)
+func (g *Generator) diff(oldData []byte, oldSource string, newData []byte, newSource string) (*SpecDiff, error) {
+	_, oldDocument, oldRefs, err := loadDocument(oldData, oldSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load old spec: %w", err)
+	}
+	_, newDocument, newRefs, err := loadDocument(newData, newSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load new spec: %w", err)
+	}
+
+	d := &specDiffer{old: oldRefs, new: newRefs, diff: &SpecDiff{}}
+	d.comparePaths(oldRefs.resolveMap(oldDocument["paths"]), newRefs.resolveMap(newDocument["paths"]))
+	return d.diff, nil
+}
+
+// diffOperation is an operation of one version of a spec
+type diffOperation struct {
+	name      string // method and path
+	pathItem  map[string]interface{}
+	operation map[string]interface{}
+}
+
+// specDiffer compares the documents of two versions of a spec, each with its own resolver
+type specDiffer struct {
+	old, new *refResolver
+	diff     *SpecDiff
+}
+
+// report records a change of an operation
+func (d *specDiffer) report(operation string, breaking bool, format string, args ...interface{}) {
+	d.diff.Changes = append(d.diff.Changes, Change{
+		Operation: operation,
+		Message:   fmt.Sprintf(format, args...),
+		Breaking:  breaking,
+	})
+}
+
+// diffOperations lists the operations of the paths of a document, keyed by method and path
+// with the names of path parameters removed
+func diffOperations(paths map[string]interface{}, refs *refResolver) (map[string]diffOperation, []string) {
+	byKey := make(map[string]diffOperation)
+	var keys []string
+
+	names := make([]string, 0, len(paths))
+	for path := range paths {
+		names = append(names, path)
+	}
+	sort.Strings(names)
+
+	for _, path := range names {
+		pathItem := refs.resolveMap(paths[path])
+		for _, method := range httpMethods {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			key := strings.ToUpper(method) + " " + pathParameterPattern.ReplaceAllString(path, "{}")
+			byKey[key] = diffOperation{name: strings.ToUpper(method) + " " + path, pathItem: pathItem, operation: operation}
+			keys = append(keys, key)
+		}
+	}
+	return byKey, keys
+}
+
+// comparePaths reports removed and added operations and compares the ones in both versions
+func (d *specDiffer) comparePaths(oldPaths, newPaths map[string]interface{}) {
+	oldOperations, oldKeys := diffOperations(oldPaths, d.old)
+	newOperations, newKeys := diffOperations(newPaths, d.new)
+
+	for _, key := range oldKeys {
+		oldOperation := oldOperations[key]
+		newOperation, ok := newOperations[key]
+		if !ok {
+			d.report(oldOperation.name, true, "operation removed")
+			continue
+		}
+		d.compareOperation(newOperation.name, oldOperation, newOperation)
+	}
+
+	for _, key := range newKeys {
+		if _, ok := oldOperations[key]; !ok {
+			d.report(newOperations[key].name, false, "operation added")
+		}
+	}
+}
+
+// compareOperation compares an operation present in both versions
+func (d *specDiffer) compareOperation(name string, oldOperation, newOperation diffOperation) {
+	if oldOperation.operation["deprecated"] != true && newOperation.operation["deprecated"] == true {
+		d.report(name, false, "operation deprecated")
+	}
+
+	d.compareParameters(name,
+		collectParameters(oldOperation.pathItem, oldOperation.operation, d.old),
+		collectParameters(newOperation.pathItem, newOperation.operation, d.new))
+	d.compareRequestBody(name, d.old.resolveMap(oldOperation.operation["requestBody"]), d.new.resolveMap(newOperation.operation["requestBody"]))
+	d.compareResponses(name, d.old.resolveMap(oldOperation.operation["responses"]), d.new.resolveMap(newOperation.operation["responses"]))
+}
+
+// parameterKey identifies a parameter by location and name; header names are case-insensitive
+func parameterKey(param map[string]interface{}) string {
+	name, _ := param["name"].(string)
+	in, _ := param["in"].(string)
+	if in == "header" {
+		name = strings.ToLower(name)
+	}
+	return in + ":" + name
+}
+
+// compareParameters reports removed, added and newly required parameters and changes to their schemas
+func (d *specDiffer) compareParameters(operation string, oldParams, newParams []map[string]interface{}) {
+	newByKey := make(map[string]map[string]interface{})
+	for _, param := range newParams {
+		newByKey[parameterKey(param)] = param
+	}
+	oldByKey := make(map[string]map[string]interface{})
+	for _, param := range oldParams {
+		oldByKey[parameterKey(param)] = param
+	}
+
+	for _, oldParam := range oldParams {
+		label := fmt.Sprintf("%s parameter %q", oldParam["in"], oldParam["name"])
+		newParam, ok := newByKey[parameterKey(oldParam)]
+		if !ok {
+			// Path parameters are matched positionally through the path, only their names changed
+			if oldParam["in"] != "path" {
+				d.report(operation, true, "%s removed", label)
+			}
+			continue
+		}
+
+		// Path parameters are always required
+		switch {
+		case oldParam["in"] == "path":
+		case oldParam["required"] != true && newParam["required"] == true:
+			d.report(operation, true, "%s became required", label)
+		case oldParam["required"] == true && newParam["required"] != true:
+			d.report(operation, false, "%s became optional", label)
+		}
+		d.compareSchema(operation, label, oldParam["schema"], newParam["schema"], true, make(map[string]bool))
+	}
+
+	for _, newParam := range newParams {
+		if _, ok := oldByKey[parameterKey(newParam)]; ok || newParam["in"] == "path" {
+			continue
+		}
+		label := fmt.Sprintf("%s parameter %q", newParam["in"], newParam["name"])
+		if newParam["required"] == true {
+			d.report(operation, true, "required %s added", label)
+		} else {
+			d.report(operation, false, "optional %s added", label)
+		}
+	}
+}
+
+// compareRequestBody reports changes to whether a body is required, its media types and schemas
+func (d *specDiffer) compareRequestBody(operation string, oldBody, newBody map[string]interface{}) {
+	switch {
+	case oldBody == nil && newBody == nil:
+		return
+	case oldBody == nil:
+		d.report(operation, newBody["required"] == true, "request body added")
+		return
+	case newBody == nil:
+		d.report(operation, true, "request body removed")
+		return
+	}
+
+	switch {
+	case oldBody["required"] != true && newBody["required"] == true:
+		d.report(operation, true, "request body became required")
+	case oldBody["required"] == true && newBody["required"] != true:
+		d.report(operation, false, "request body became optional")
+	}
+	d.compareContent(operation, "request body", d.old.resolveMap(oldBody["content"]), d.new.resolveMap(newBody["content"]), true)
+}
+
+// compareResponses reports removed and added status codes and compares the responses in both versions
+func (d *specDiffer) compareResponses(operation string, oldResponses, newResponses map[string]interface{}) {
+	for _, status := range sortedMapKeys(oldResponses) {
+		label := "response " + status
+		newResponse, ok := newResponses[status]
+		if !ok {
+			d.report(operation, true, "%s removed", label)
+			continue
+		}
+
+		oldContent := d.old.resolveMap(d.old.resolveMap(oldResponses[status])["content"])
+		newContent := d.new.resolveMap(d.new.resolveMap(newResponse)["content"])
+		d.compareContent(operation, label, oldContent, newContent, false)
+	}
+
+	for _, status := range sortedMapKeys(newResponses) {
+		if _, ok := oldResponses[status]; !ok {
+			d.report(operation, false, "response %s added", status)
+		}
+	}
+}
+
+// compareContent compares the media types of a request body or response
+func (d *specDiffer) compareContent(operation, label string, oldContent, newContent map[string]interface{}, request bool) {
+	for _, mediaType := range sortedMapKeys(oldContent) {
+		newMedia, ok := newContent[mediaType]
+		if !ok {
+			d.report(operation, true, "%s media type %s removed", label, mediaType)
+			continue
+		}
+
+		schemaLabel := label
+		if !request {
+			schemaLabel += " body"
+		}
+		oldSchema := d.old.resolveMap(oldContent[mediaType])["schema"]
+		newSchema := d.new.resolveMap(newMedia)["schema"]
+		d.compareSchema(operation, schemaLabel, oldSchema, newSchema, request, make(map[string]bool))
+	}
+
+	for _, mediaType := range sortedMapKeys(newContent) {
+		if _, ok := oldContent[mediaType]; !ok {
+			d.report(operation, false, "%s media type %s added", label, mediaType)
+		}
+	}
+}
+
+// compareSchema compares the schemas of a value sent by clients (request) or returned to
+// them. Requests break when they accept less than before; responses break when they may
+// return something clients did not expect, or stop returning something they relied on.
+func (d *specDiffer) compareSchema(operation, label string, oldNode, newNode interface{}, request bool, visiting map[string]bool) {
+	oldRef, _ := refOf(oldNode)
+	newRef, _ := refOf(newNode)
+	if oldRef != "" || newRef != "" {
+		key := oldRef + "|" + newRef
+		if visiting[key] {
+			return
+		}
+		visiting[key] = true
+		defer delete(visiting, key)
+	}
+
+	oldSchema := flattenSchema(oldNode, d.old)
+	newSchema := flattenSchema(newNode, d.new)
+	if oldSchema == nil || newSchema == nil {
+		return
+	}
+
+	oldType, _ := oldSchema["type"].(string)
+	newType, _ := newSchema["type"].(string)
+	if oldType != "" && newType != "" && oldType != newType && !(oldType == "integer" && newType == "number" && request) {
+		d.report(operation, true, "%s type changed from %s to %s", label, oldType, newType)
+		return
+	}
+
+	if oldSchema["nullable"] != true && newSchema["nullable"] == true && !request {
+		d.report(operation, true, "%s became nullable", label)
+	} else if oldSchema["nullable"] == true && newSchema["nullable"] != true && request {
+		d.report(operation, true, "%s is no longer nullable", label)
+	}
+
+	d.compareEnum(operation, label, oldSchema["enum"], newSchema["enum"], request)
+
+	if oldItems, ok := oldSchema["items"]; ok {
+		if newItems, ok := newSchema["items"]; ok {
+			d.compareSchema(operation, label+"[]", oldItems, newItems, request, visiting)
+		}
+	}
+
+	oldProperties := d.old.resolveMap(oldSchema["properties"])
+	newProperties := d.new.resolveMap(newSchema["properties"])
+	oldRequired := stringSet(oldSchema["required"])
+	newRequired := stringSet(newSchema["required"])
+
+	for _, name := range sortedMapKeys(oldProperties) {
+		property := propertyLabel(label, name)
+		newProperty, ok := newProperties[name]
+		if !ok {
+			// Clients stop receiving a field they may rely on; requests just ignore it
+			d.report(operation, !request, "%s removed", property)
+			continue
+		}
+
+		if request && !oldRequired[name] && newRequired[name] {
+			d.report(operation, true, "%s became required", property)
+		} else if !request && oldRequired[name] && !newRequired[name] {
+			d.report(operation, true, "%s is no longer required", property)
+		}
+		d.compareSchema(operation, property, oldProperties[name], newProperty, request, visiting)
+	}
+
+	for _, name := range sortedMapKeys(newProperties) {
+		if _, ok := oldProperties[name]; ok {
+			continue
+		}
+		property := propertyLabel(label, name)
+		if request && newRequired[name] {
+			d.report(operation, true, "required %s added", property)
+		} else {
+			d.report(operation, false, "%s added", property)
+		}
+	}
+}
+
+// compareEnum reports removed and added enum values. Removing values breaks clients
+// sending them, adding values breaks clients receiving them.
+func (d *specDiffer) compareEnum(operation, label string, oldEnum, newEnum interface{}, request bool) {
+	oldValues, _ := oldEnum.([]interface{})
+	newValues, _ := newEnum.([]interface{})
+	if len(oldValues) == 0 || len(newValues) == 0 {
+		return
+	}
+
+	if removed := enumDifference(oldValues, newValues); len(removed) > 0 {
+		d.report(operation, request, "%s enum values removed: %s", label, strings.Join(removed, ", "))
+	}
+	if added := enumDifference(newValues, oldValues); len(added) > 0 {
+		d.report(operation, !request, "%s enum values added: %s", label, strings.Join(added, ", "))
+	}
+}
+
+// enumDifference returns the values of a that are not in b
+func enumDifference(a, b []interface{}) []string {
+	present := make(map[string]bool, len(b))
+	for _, value := range b {
+		present[fmt.Sprint(value)] = true
+	}
+
+	var missing []string
+	for _, value := range a {
+		if !present[fmt.Sprint(value)] {
+			missing = append(missing, fmt.Sprint(value))
+		}
+	}
+	return missing
+}
+
+// flattenSchema resolves a schema and merges the properties and required fields of its allOf members
+func flattenSchema(node interface{}, refs *refResolver) map[string]interface{} {
+	schema := refs.resolveMap(node)
+	allOf, _ := schema["allOf"].([]interface{})
+	if len(allOf) == 0 {
+		return schema
+	}
+
+	merged := make(map[string]interface{}, len(schema))
+	properties := make(map[string]interface{})
+	var required []interface{}
+	for _, part := range append([]interface{}{schema}, allOf...) {
+		member := refs.resolveMap(part)
+		for key, value := range member {
+			if _, ok := merged[key]; !ok && key != "allOf" {
+				merged[key] = value
+			}
+		}
+		for name, property := range refs.resolveMap(member["properties"]) {
+			properties[name] = property
+		}
+		list, _ := member["required"].([]interface{})
+		required = append(required, list...)
+	}
+
+	merged["properties"] = properties
+	merged["required"] = required
+	if _, ok := merged["type"]; !ok {
+		merged["type"] = "object"
+	}
+	return merged
+}
+
+// propertyLabel names a property of the value described by label
+func propertyLabel(label, name string) string {
+	if strings.Contains(label, " property ") {
+		return label + "." + name
+	}
+	return fmt.Sprintf("%s property %s", label, name)
+}
+
+// stringSet returns the strings of a list as a set
+func stringSet(node interface{}) map[string]bool {
+	list, _ := node.([]interface{})
+	set := make(map[string]bool, len(list))
+	for _, item := range list {
+		if value, ok := item.(string); ok {
+			set[value] = true
+		}
+	}
+	return set
+}
+
+// sortedMapKeys returns the keys of a map in alphabetical order
+func sortedMapKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
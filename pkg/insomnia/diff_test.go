@@ -0,0 +1,200 @@
+package insomnia
+
+import (
+	"strings"
+	"testing"
+)
+
+const diffBaseSpec = `
+openapi: 3.0.3
+info:
+  title: Orders API
+  version: 1.0.0
+paths:
+  /orders:
+    get:
+      parameters:
+        - name: status
+          in: query
+          schema:
+            $ref: '#/components/schemas/Status'
+        - name: page
+          in: query
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: Orders
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Order'
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Order'
+      responses:
+        "201":
+          description: Created
+  /orders/{id}:
+    delete:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "204":
+          description: Deleted
+components:
+  schemas:
+    Status:
+      type: string
+      enum: [open, shipped, cancelled]
+    Order:
+      type: object
+      required: [id]
+      properties:
+        id:
+          type: string
+        total:
+          type: number
+        status:
+          $ref: '#/components/schemas/Status'
+`
+
+const diffRevisedSpec = `
+openapi: 3.0.3
+info:
+  title: Orders API
+  version: 2.0.0
+paths:
+  /orders:
+    get:
+      parameters:
+        - name: status
+          in: query
+          schema:
+            $ref: '#/components/schemas/Status'
+        - name: tenant
+          in: query
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: Orders
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Order'
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Order'
+      responses:
+        "200":
+          description: Created
+  /orders/{orderId}:
+    delete:
+      parameters:
+        - name: orderId
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "204":
+          description: Deleted
+  /orders/{orderId}/refunds:
+    post:
+      responses:
+        "201":
+          description: Refunded
+components:
+  schemas:
+    Status:
+      type: string
+      enum: [open, shipped]
+    Order:
+      type: object
+      required: [id, currency]
+      properties:
+        id:
+          type: string
+        currency:
+          type: string
+        status:
+          $ref: '#/components/schemas/Status'
+`
+
+func TestDiffOpenAPI(t *testing.T) {
+	diff, err := NewGenerator().DiffOpenAPI([]byte(diffBaseSpec), []byte(diffRevisedSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	var changes []string
+	for _, change := range diff.Changes {
+		changes = append(changes, change.String())
+	}
+	report := strings.Join(changes, "\n")
+
+	for _, expected := range []string{
+		`[BREAKING] GET /orders: query parameter "page" removed`,
+		`[BREAKING] GET /orders: required query parameter "tenant" added`,
+		`[BREAKING] GET /orders: query parameter "status" enum values removed: cancelled`,
+		`[BREAKING] GET /orders: response 200 body[] property total removed`,
+		`[non-breaking] GET /orders: response 200 body[] property status enum values removed: cancelled`,
+		`[BREAKING] POST /orders: required request body property currency added`,
+		`[non-breaking] POST /orders: request body became optional`,
+		`[BREAKING] POST /orders: response 201 removed`,
+		`[non-breaking] POST /orders: response 200 added`,
+		`[non-breaking] POST /orders/{orderId}/refunds: operation added`,
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Test failed. Expected change %q, got:\n%s", expected, report)
+		}
+	}
+
+	if strings.Contains(report, "DELETE") {
+		t.Errorf("Test failed. Expected renamed path parameters to match, got:\n%s", report)
+	}
+	if !diff.HasBreakingChanges() {
+		t.Errorf("Test failed. Expected breaking changes")
+	}
+}
+
+func TestDiffOpenAPIWithoutChanges(t *testing.T) {
+	diff, err := NewGenerator().DiffOpenAPI([]byte(testSpec), []byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if len(diff.Changes) != 0 {
+		t.Errorf("Test failed. Expected no changes, got %v", diff.Changes)
+	}
+}
+
+func TestDiffOpenAPIRemovedOperation(t *testing.T) {
+	diff, err := NewGenerator().DiffOpenAPI([]byte(diffRevisedSpec), []byte(diffBaseSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	for _, change := range diff.Breaking() {
+		if change.Operation == "POST /orders/{orderId}/refunds" && change.Message == "operation removed" {
+			return
+		}
+	}
+	t.Errorf("Test failed. Expected the removed operation to be breaking, got %v", diff.Changes)
+}
@@ -0,0 +1,333 @@
+package insomnia
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format identifies an output format of the generator
+type Format string
+
+// Supported output formats
+const (
+	FormatInsomnia Format = "insomnia"
+	FormatPostman  Format = "postman"
+	FormatBruno    Format = "bruno"
+	FormatHTTP     Format = "http"
+	FormatCurl     Format = "curl"
+	FormatHTTPie   Format = "httpie"
+	FormatHAR      Format = "har"
+)
+
+// formatSuffixes maps each format to the suffix of its default output file, or of its
+// output directory for the formats that write one file per operation
+var formatSuffixes = map[Format]string{
+	FormatInsomnia: "-insomnia.yml",
+	FormatPostman:  postmanCollectionSuffix,
+	FormatBruno:    "-bruno",
+	FormatHTTP:     "-http",
+	FormatCurl:     ".curl.sh",
+	FormatHTTPie:   ".httpie.sh",
+	FormatHAR:      ".har",
+}
+
+// generatedFilesManifest lists the files written to an output directory, so that files no
+// longer generated are removed on the next write while files added by hand are kept
+const generatedFilesManifest = ".generated-files"
+
+// unsafeFileNameChars matches characters that are not portable in file names
+var unsafeFileNameChars = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]+`)
+
+// ParseFormat returns the format with the given name
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := formatSuffixes[format]; !ok {
+		return "", fmt.Errorf("unsupported format %q (expected insomnia, postman, bruno, http, curl, httpie or har)", name)
+	}
+	return format, nil
+}
+
+// ParseFormats parses a comma-separated list of formats such as "insomnia,postman"
+func ParseFormats(list string) ([]Format, error) {
+	var formats []Format
+	for _, name := range strings.Split(list, ",") {
+		format, err := ParseFormat(name)
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+// OutputFileName returns the default output file for an OpenAPI file in the given format,
+// next to the OpenAPI file
+func OutputFileName(openAPIFile string, format Format) string {
+	dir := filepath.Dir(openAPIFile)
+	baseName := strings.TrimSuffix(filepath.Base(openAPIFile), filepath.Ext(openAPIFile))
+	return filepath.Join(dir, baseName+formatSuffixes[format])
+}
+
+// ExportToFile converts an OpenAPI file to the given format and writes it to outputFile,
+// which is a directory for the bruno and http formats
+func (g *Generator) ExportToFile(openAPIFile, outputFile string, format Format) error {
+	switch format {
+	case FormatInsomnia:
+		return g.GenerateToFile(openAPIFile, outputFile)
+	case FormatPostman:
+		return g.GeneratePostmanToFile(openAPIFile, outputFile)
+	case FormatBruno:
+		return g.GenerateBrunoToDir(openAPIFile, outputFile)
+	case FormatHTTP:
+		return g.GenerateHTTPToDir(openAPIFile, outputFile)
+	case FormatCurl, FormatHTTPie, FormatHAR:
+		api, err := g.ParseFile(openAPIFile)
+		if err != nil {
+			return fmt.Errorf("failed to generate %s export: %w", format, err)
+		}
+		return g.WriteCommands(api, format, outputFile)
+	}
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+// WriteAPI writes an API in the given format to outputFile, which is a directory for the
+// bruno and http formats. Files whose content is unchanged are not rewritten; it reports
+// whether any file was written.
+func (g *Generator) WriteAPI(api *API, format Format, outputFile string) (bool, error) {
+	switch format {
+	case FormatInsomnia:
+		yamlData, err := yaml.Marshal(g.BuildInsomnia(api))
+		if err != nil {
+			return false, fmt.Errorf("failed to marshal Insomnia spec: %w", err)
+		}
+		return writeFileIfChanged(outputFile, yamlData, 0644)
+	case FormatPostman:
+		return writePostmanFiles(outputFile, g.BuildPostman(api))
+	case FormatBruno:
+		return writeFiles(outputFile, g.BuildBruno(api))
+	case FormatHTTP:
+		return writeFiles(outputFile, g.BuildHTTPFiles(api))
+	case FormatCurl, FormatHTTPie, FormatHAR:
+		return g.writeCommands(api, format, outputFile)
+	}
+	return false, fmt.Errorf("unsupported format %q", format)
+}
+
+// WriteCommands writes the curl or HTTPie script, or the HAR file, of an API to outputFile
+func (g *Generator) WriteCommands(api *API, format Format, outputFile string) error {
+	_, err := g.writeCommands(api, format, outputFile)
+	return err
+}
+
+// writeCommands writes the curl or HTTPie script, or the HAR file, of an API to outputFile
+// unless it is unchanged, reporting whether it was written
+func (g *Generator) writeCommands(api *API, format Format, outputFile string) (bool, error) {
+	data, err := g.RenderCommands(api, format)
+	if err != nil {
+		return false, err
+	}
+
+	mode := os.FileMode(0644)
+	if format != FormatHAR {
+		mode = 0755
+	}
+	return writeFileIfChanged(outputFile, data, mode)
+}
+
+// RenderCommands renders the operations of an API as a curl or HTTPie script, or as a HAR file
+func (g *Generator) RenderCommands(api *API, format Format) ([]byte, error) {
+	switch format {
+	case FormatCurl:
+		return []byte(g.BuildCurl(api)), nil
+	case FormatHTTPie:
+		return []byte(g.BuildHTTPie(api)), nil
+	case FormatHAR:
+		return marshalJSON(g.BuildHAR(api))
+	}
+	return nil, fmt.Errorf("format %q does not render commands", format)
+}
+
+// writeFiles writes files keyed by their slash-separated path relative to dir, creating
+// directories as needed and leaving unchanged files untouched. Files it wrote last time
+// that are no longer generated are removed. It reports whether any file was written or
+// removed.
+func writeFiles(dir string, files map[string]string) (bool, error) {
+	written := false
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return written, fmt.Errorf("failed to create output directory: %w", err)
+		}
+		changed, err := writeFileIfChanged(path, []byte(content), 0644)
+		if err != nil {
+			return written, err
+		}
+		written = written || changed
+	}
+
+	removed, err := removeStaleFiles(dir, files)
+	if err != nil {
+		return written, err
+	}
+
+	manifest := strings.Join(sortedKeys(files), "\n") + "\n"
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return written, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if _, err := writeFileIfChanged(filepath.Join(dir, generatedFilesManifest), []byte(manifest), 0644); err != nil {
+		return written, err
+	}
+	return written || removed, nil
+}
+
+// removeStaleFiles removes the files listed in the manifest of dir that are not among
+// files, and the directories they leave empty. It reports whether any file was removed.
+func removeStaleFiles(dir string, files map[string]string) (bool, error) {
+	manifest, err := os.ReadFile(filepath.Join(dir, generatedFilesManifest))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read generated files: %w", err)
+	}
+
+	removed := false
+	for _, name := range strings.Split(string(manifest), "\n") {
+		if _, ok := files[name]; ok || name == "" || strings.HasPrefix(name, "/") || strings.Contains("/"+name+"/", "/../") {
+			continue
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.Remove(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return removed, fmt.Errorf("failed to remove stale output file: %w", err)
+		}
+		removed = true
+
+		// Directories still holding files, e.g. added by hand, are kept
+		for parent := filepath.Dir(path); parent != filepath.Clean(dir) && os.Remove(parent) == nil; parent = filepath.Dir(parent) {
+		}
+	}
+	return removed, nil
+}
+
+// writeFileIfChanged writes data to file unless the file already holds it, so that
+// regenerating identical output keeps its modification time. It reports whether the
+// file was written.
+func writeFileIfChanged(file string, data []byte, mode os.FileMode) (bool, error) {
+	if existing, err := os.ReadFile(file); err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+	if err := os.WriteFile(file, data, mode); err != nil {
+		return false, fmt.Errorf("failed to write output file: %w", err)
+	}
+	return true, nil
+}
+
+// fileName turns a folder, request or environment name into a portable file name
+func fileName(name string) string {
+	name = strings.Trim(unsafeFileNameChars.ReplaceAllString(name, "-"), " .-")
+	if name == "" {
+		return "unnamed"
+	}
+	return name
+}
+
+// operationFileNames names the file of each operation of a folder after the operation,
+// numbering operations that share a name
+func operationFileNames(folder Folder, ext string) []string {
+	names := make([]string, 0, len(folder.Operations))
+	used := make(map[string]int)
+	for _, operation := range folder.Operations {
+		name := fileName(operation.Name)
+		used[strings.ToLower(name)]++
+		if count := used[strings.ToLower(name)]; count > 1 {
+			name = fmt.Sprintf("%s (%d)", name, count)
+		}
+		names = append(names, name+ext)
+	}
+	return names
+}
+
+// environmentServers returns the servers to write environments for, falling back to
+// http://localhost for specs without servers
+func environmentServers(api *API) []Server {
+	if len(api.Servers) > 0 {
+		return api.Servers
+	}
+	return []Server{{Key: "", Name: "Local", Scheme: "http", Host: "localhost"}}
+}
+
+// exampleURL returns the URL of an operation with its path parameters replaced by their
+// example values, keeping {{name}} references for parameters without one
+func exampleURL(operation Operation) string {
+	if operation.Webhook {
+		return operation.URL
+	}
+
+	path := operation.Path
+	for _, param := range operation.PathParameters {
+		value := "{{" + param.Name + "}}"
+		if param.Value != "" {
+			value = url.PathEscape(param.Value)
+		}
+		path = strings.ReplaceAll(path, "{"+param.Name+"}", value)
+	}
+	return "{{base_url}}" + path
+}
+
+// pathVariableSegments splits the path of an operation into segments, turning whole-segment
+// path parameters into :name path variables and other parameter references into {{name}}
+func pathVariableSegments(operation Operation) []string {
+	pathParams := make(map[string]bool)
+	for _, param := range operation.PathParameters {
+		pathParams[param.Name] = true
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(operation.Path, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		if name := strings.Trim(segment, "{}"); segment == "{"+name+"}" && pathParams[name] {
+			segment = ":" + name
+		} else {
+			for name := range pathParams {
+				segment = strings.ReplaceAll(segment, "{"+name+"}", "{{"+name+"}}")
+			}
+		}
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+// queryString joins the enabled query parameters, escaping values that are not variable
+// references when escape is set
+func queryString(params []Parameter, escape bool) string {
+	var query []string
+	for _, param := range params {
+		if param.Disabled {
+			continue
+		}
+
+		name, value := param.Name, param.Value
+		if escape {
+			name = url.QueryEscape(name)
+			if !variablePattern.MatchString(value) {
+				value = url.QueryEscape(value)
+			}
+		}
+		query = append(query, name+"="+value)
+	}
+	return strings.Join(query, "&")
+}
@@ -0,0 +1,116 @@
+package insomnia
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteAPISkipsUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	api, err := NewDeterministicGenerator().ParseOpenAPI([]byte(overlaySpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	for format := range formatSuffixes {
+		outputFile := filepath.Join(dir, "api"+formatSuffixes[format])
+		written, err := NewDeterministicGenerator().WriteAPI(api, format, outputFile)
+		if err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+		if !written {
+			t.Errorf("Test failed. Expected the %s output to be written", format)
+		}
+
+		// Unchanged outputs keep their modification time
+		past := time.Now().Add(-time.Hour).Truncate(time.Second)
+		if err := os.Chtimes(outputFile, past, past); err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+		written, err = NewDeterministicGenerator().WriteAPI(api, format, outputFile)
+		if err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+		stat, err := os.Stat(outputFile)
+		if err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+		if written || !stat.ModTime().Equal(past) {
+			t.Errorf("Test failed. Expected the unchanged %s output not to be written", format)
+		}
+	}
+
+	if _, err := NewGenerator().WriteAPI(api, Format("pdf"), filepath.Join(dir, "api.pdf")); err == nil {
+		t.Errorf("Test failed. Expected an unsupported format to fail")
+	}
+}
+
+func TestWriteAPIRemovesStaleFiles(t *testing.T) {
+	spec := overlaySpec + `    post:
+      summary: Ping
+  /orders:
+    get:
+      tags: [orders]
+      summary: List orders
+`
+
+	for _, format := range []Format{FormatBruno, FormatHTTP} {
+		t.Run(string(format), func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "api"+formatSuffixes[format])
+			api, err := NewDeterministicGenerator().ParseOpenAPI([]byte(spec))
+			if err != nil {
+				t.Fatalf("Test shouldnt have failed: %v", err)
+			}
+			if _, err := NewDeterministicGenerator().WriteAPI(api, format, dir); err != nil {
+				t.Fatalf("Test shouldnt have failed: %v", err)
+			}
+			ext := ".bru"
+			if format == FormatHTTP {
+				ext = ".http"
+			}
+			if _, err := os.Stat(filepath.Join(dir, "orders", "List orders"+ext)); err != nil {
+				t.Fatalf("Test shouldnt have failed: %v", err)
+			}
+			handMade := writeTestFile(t, dir, "Hand made"+ext, "")
+
+			// Drop the Ping operation and the orders folder
+			api, err = NewDeterministicGenerator().ParseOpenAPI([]byte(overlaySpec))
+			if err != nil {
+				t.Fatalf("Test shouldnt have failed: %v", err)
+			}
+			written, err := NewDeterministicGenerator().WriteAPI(api, format, dir)
+			if err != nil {
+				t.Fatalf("Test shouldnt have failed: %v", err)
+			}
+			if !written {
+				t.Errorf("Test failed. Expected removing stale files to count as a change")
+			}
+
+			var files []string
+			err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+				if err == nil && path != dir {
+					rel, _ := filepath.Rel(dir, path)
+					files = append(files, filepath.ToSlash(rel))
+				}
+				return err
+			})
+			if err != nil {
+				t.Fatalf("Test shouldnt have failed: %v", err)
+			}
+			for _, file := range files {
+				if strings.Contains(file, "Ping") || strings.HasPrefix(file, "orders") {
+					t.Errorf("Test failed. Expected %s to be removed, got %v", file, files)
+				}
+			}
+			if _, err := os.Stat(handMade); err != nil {
+				t.Errorf("Test failed. Expected the hand-made file to be kept: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "default", "Health"+ext)); err != nil {
+				t.Errorf("Test failed. Expected the remaining operation to be kept: %v", err)
+			}
+		})
+	}
+}
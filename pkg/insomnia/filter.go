@@ -0,0 +1,217 @@
+package insomnia
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// OperationFilter selects the operations of a spec that are generated. An operation is
+// kept when it matches every criterion set in Include and none of those set in Exclude.
+type OperationFilter struct {
+	Include OperationMatch `yaml:"include"`
+	Exclude OperationMatch `yaml:"exclude"`
+}
+
+// OperationMatch lists criteria matching operations. Empty criteria are ignored; a list
+// matches when any of its values does.
+type OperationMatch struct {
+	// Tags match the tags of operations; untagged operations have the "default" tag
+	Tags []string `yaml:"tags"`
+
+	// Paths are globs where * matches within a path segment and ** across segments,
+	// e.g. /customers/** or /*/{id}
+	Paths []string `yaml:"paths"`
+
+	// Methods match HTTP methods, case-insensitively
+	Methods []string `yaml:"methods"`
+
+	// OperationID is a regular expression matched against operationIds
+	OperationID string `yaml:"operationId"`
+
+	// Deprecated matches operations marked deprecated
+	Deprecated bool `yaml:"deprecated"`
+
+	// Extensions match x- extensions of operations by value, or by presence when the
+	// value is empty, e.g. {"x-internal": "true"}
+	Extensions map[string]string `yaml:"extensions"`
+}
+
+// WithOperationFilter keeps only the operations selected by the filter
+func WithOperationFilter(filter OperationFilter) Option {
+	return func(g *Generator) {
+		g.filter = filter
+	}
+}
+
+// IsEmpty reports whether the filter keeps every operation
+func (f OperationFilter) IsEmpty() bool {
+	return f.Include.isEmpty() && f.Exclude.isEmpty()
+}
+
+// Validate reports whether the regular expressions of the filter are invalid
+func (f OperationFilter) Validate() error {
+	_, err := f.compile()
+	return err
+}
+
+// ParseExtensions parses x- extension criteria such as "x-internal=true,x-beta"
+func ParseExtensions(list string) (map[string]string, error) {
+	extensions := make(map[string]string)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+
+		name, value, _ := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !strings.HasPrefix(name, "x-") {
+			return nil, fmt.Errorf("extension %q does not start with x-", name)
+		}
+		extensions[name] = strings.TrimSpace(value)
+	}
+	return extensions, nil
+}
+
+// operationFilter is an OperationFilter with its regular expressions compiled
+type operationFilter struct {
+	include, exclude operationMatcher
+}
+
+// operationMatcher is an OperationMatch with its regular expression compiled
+type operationMatcher struct {
+	OperationMatch
+	operationID *regexp.Regexp
+}
+
+// compile validates the filter and compiles its regular expressions
+func (f OperationFilter) compile() (operationFilter, error) {
+	include, err := f.Include.compile()
+	if err != nil {
+		return operationFilter{}, fmt.Errorf("invalid include filter: %w", err)
+	}
+	exclude, err := f.Exclude.compile()
+	if err != nil {
+		return operationFilter{}, fmt.Errorf("invalid exclude filter: %w", err)
+	}
+	return operationFilter{include: include, exclude: exclude}, nil
+}
+
+// keep reports whether the filter selects an operation
+func (f operationFilter) keep(operation Operation) bool {
+	return f.include.matchesAll(operation) && !f.exclude.matchesAny(operation)
+}
+
+// isEmpty reports whether no criterion is set
+func (m OperationMatch) isEmpty() bool {
+	return len(m.Tags) == 0 && len(m.Paths) == 0 && len(m.Methods) == 0 &&
+		m.OperationID == "" && !m.Deprecated && len(m.Extensions) == 0
+}
+
+// compile validates the criteria and compiles the operationId expression
+func (m OperationMatch) compile() (operationMatcher, error) {
+	matcher := operationMatcher{OperationMatch: m}
+	for _, pattern := range m.Paths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return matcher, fmt.Errorf("path glob %q: %w", pattern, err)
+		}
+	}
+	if m.OperationID != "" {
+		expression, err := regexp.Compile(m.OperationID)
+		if err != nil {
+			return matcher, fmt.Errorf("operationId expression: %w", err)
+		}
+		matcher.operationID = expression
+	}
+	return matcher, nil
+}
+
+// criteria returns whether each criterion that is set matches an operation
+func (m operationMatcher) criteria(operation Operation) []bool {
+	var results []bool
+	if len(m.Tags) > 0 {
+		tags := operation.Tags
+		if len(tags) == 0 {
+			tags = []string{"default"}
+		}
+		results = append(results, containsAny(tags, m.Tags))
+	}
+	if len(m.Paths) > 0 {
+		matched := false
+		for _, pattern := range m.Paths {
+			matched = matched || matchPathGlob(pattern, operation.Path)
+		}
+		results = append(results, matched)
+	}
+	if len(m.Methods) > 0 {
+		matched := false
+		for _, method := range m.Methods {
+			matched = matched || strings.EqualFold(method, operation.Method)
+		}
+		results = append(results, matched)
+	}
+	if m.operationID != nil {
+		results = append(results, operation.OperationID != "" && m.operationID.MatchString(operation.OperationID))
+	}
+	if m.Deprecated {
+		results = append(results, operation.Deprecated)
+	}
+	if len(m.Extensions) > 0 {
+		matched := false
+		for name, value := range m.Extensions {
+			actual, ok := operation.Extensions[name]
+			matched = matched || (ok && (value == "" || scalarString(actual) == value))
+		}
+		results = append(results, matched)
+	}
+	return results
+}
+
+// matchesAll reports whether an operation matches every criterion that is set
+func (m operationMatcher) matchesAll(operation Operation) bool {
+	for _, matched := range m.criteria(operation) {
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAny reports whether an operation matches any criterion that is set
+func (m operationMatcher) matchesAny(operation Operation) bool {
+	for _, matched := range m.criteria(operation) {
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPathGlob matches a path against a glob where * matches within a segment and **
+// matches any number of segments
+func matchPathGlob(pattern, operationPath string) bool {
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(strings.Trim(operationPath, "/"), "/"))
+}
+
+// matchSegments matches path segments against glob segments
+func matchSegments(patterns, segments []string) bool {
+	if len(patterns) == 0 {
+		return len(segments) == 0
+	}
+
+	if patterns[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(patterns[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+	matched, _ := path.Match(patterns[0], segments[0])
+	return matched && matchSegments(patterns[1:], segments[1:])
+}
@@ -0,0 +1,143 @@
+package insomnia
+
+import (
+	"strings"
+	"testing"
+)
+
+const filterSpec = `
+openapi: 3.0.3
+info:
+  title: Filter API
+  version: 1.0.0
+paths:
+  /customers:
+    get:
+      operationId: listCustomers
+      summary: List customers
+      tags: [customers]
+    post:
+      operationId: createCustomer
+      summary: Create customer
+      tags: [customers]
+  /customers/{id}/address:
+    get:
+      operationId: getCustomerAddress
+      summary: Get address
+      tags: [addresses]
+    put:
+      operationId: updateCustomerAddress
+      summary: Update address
+      tags: [addresses]
+      deprecated: true
+  /admin/reindex:
+    post:
+      operationId: reindex
+      summary: Reindex
+      x-internal: true
+  /health:
+    get:
+      summary: Health
+      x-internal: false
+`
+
+// filteredOperations returns the operation names kept by a filter
+func filteredOperations(t *testing.T, filter OperationFilter) string {
+	api, err := NewDeterministicGenerator(WithOperationFilter(filter)).ParseOpenAPI([]byte(filterSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	var names []string
+	walkOperations(api.Folders, nil, func(_ []string, operation Operation) {
+		names = append(names, operation.Name)
+	})
+	return strings.Join(names, ", ")
+}
+
+func TestOperationFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   OperationFilter
+		expected string
+	}{
+		{"none", OperationFilter{}, "List customers, Create customer, Get address, Update address, Reindex, Health"},
+		{"include tag", OperationFilter{Include: OperationMatch{Tags: []string{"addresses"}}}, "Get address, Update address"},
+		{"untagged", OperationFilter{Include: OperationMatch{Tags: []string{"default"}}}, "Reindex, Health"},
+		{"exclude tag", OperationFilter{Exclude: OperationMatch{Tags: []string{"customers", "default"}}}, "Get address, Update address"},
+		{"path glob", OperationFilter{Include: OperationMatch{Paths: []string{"/customers/*/address"}}}, "Get address, Update address"},
+		{"path globstar", OperationFilter{Include: OperationMatch{Paths: []string{"/customers/**"}}}, "List customers, Create customer, Get address, Update address"},
+		{"method", OperationFilter{Include: OperationMatch{Methods: []string{"get"}}}, "List customers, Get address, Health"},
+		{"operationId", OperationFilter{Include: OperationMatch{OperationID: "^(list|get)"}}, "List customers, Get address"},
+		{"deprecated", OperationFilter{Exclude: OperationMatch{Deprecated: true}}, "List customers, Create customer, Get address, Reindex, Health"},
+		{"extension value", OperationFilter{Exclude: OperationMatch{Extensions: map[string]string{"x-internal": "true"}}}, "List customers, Create customer, Get address, Update address, Health"},
+		{"extension presence", OperationFilter{Include: OperationMatch{Extensions: map[string]string{"x-internal": ""}}}, "Reindex, Health"},
+		{"all criteria", OperationFilter{Include: OperationMatch{Paths: []string{"/customers/**"}, Methods: []string{"GET"}}}, "List customers, Get address"},
+		{"include and exclude", OperationFilter{Include: OperationMatch{Tags: []string{"addresses"}}, Exclude: OperationMatch{Methods: []string{"GET"}}}, "Update address"},
+	}
+
+	for _, test := range tests {
+		if names := filteredOperations(t, test.filter); names != test.expected {
+			t.Errorf("Test failed. Expected %s to keep %q, got %q", test.name, test.expected, names)
+		}
+	}
+}
+
+func TestOperationFilterFolders(t *testing.T) {
+	g := NewDeterministicGenerator(WithOperationFilter(OperationFilter{Include: OperationMatch{Tags: []string{"addresses"}}}))
+	spec, err := g.GenerateFromOpenAPI([]byte(filterSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if len(spec.Collection) != 1 || spec.Collection[0].Name != "addresses" {
+		t.Errorf("Test failed. Expected folders without operations to be dropped, got %v", spec.Collection)
+	}
+}
+
+func TestOperationFilterErrors(t *testing.T) {
+	g := NewGenerator(WithOperationFilter(OperationFilter{Exclude: OperationMatch{OperationID: "("}}))
+	if _, err := g.ParseOpenAPI([]byte(filterSpec)); err == nil || !strings.Contains(err.Error(), "invalid exclude filter") {
+		t.Errorf("Test failed. Expected an error for an invalid expression, got %v", err)
+	}
+
+	g = NewGenerator(WithOperationFilter(OperationFilter{Include: OperationMatch{Paths: []string{"/["}}}))
+	if _, err := g.ParseOpenAPI([]byte(filterSpec)); err == nil {
+		t.Errorf("Test failed. Expected an error for an invalid path glob")
+	}
+}
+
+func TestParseExtensions(t *testing.T) {
+	extensions, err := ParseExtensions("x-internal=true, x-beta")
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if len(extensions) != 2 || extensions["x-internal"] != "true" || extensions["x-beta"] != "" {
+		t.Errorf("Test failed. Expected x-internal=true and x-beta, got %v", extensions)
+	}
+
+	if _, err := ParseExtensions("internal=true"); err == nil {
+		t.Errorf("Test failed. Expected an error for a name without x-")
+	}
+}
+
+func TestMatchPathGlob(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		expected      bool
+	}{
+		{"/customers", "/customers", true},
+		{"/customers/*", "/customers/{id}", true},
+		{"/customers/*", "/customers/{id}/address", false},
+		{"/customers/**", "/customers", true},
+		{"/**/address", "/customers/{id}/address", true},
+		{"/*/{id}", "/customers/{id}", true},
+		{"/orders/**", "/customers", false},
+	}
+
+	for _, test := range tests {
+		if matched := matchPathGlob(test.pattern, test.path); matched != test.expected {
+			t.Errorf("Test failed. Expected %s matching %s to be %v, got %v", test.pattern, test.path, test.expected, matched)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package insomnia
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FolderLayout controls how operations are arranged in the folders of generated collections
+type FolderLayout struct {
+	// ByPath nests folders by the static segments of operation paths instead of grouping
+	// them by tag: /customers/{id}/address goes to customers › address
+	ByPath bool
+
+	// AllTags places an operation under every tag it lists instead of only its first one
+	AllTags bool
+}
+
+// walkOperations calls fn for every operation of a folder tree, depth first, with the
+// names of the folders leading to it
+func walkOperations(folders []Folder, parents []string, fn func(path []string, operation Operation)) {
+	for _, folder := range folders {
+		path := append(append([]string{}, parents...), folder.Name)
+		walkOperations(folder.Folders, path, fn)
+		for _, operation := range folder.Operations {
+			fn(path, operation)
+		}
+	}
+}
+
+// tagFolders creates one folder per tag, ordered as declared in the top-level tags and
+// then by first use.
+// Operations go under their first tag, or under every tag with AllTags; copies under
+// the other tags get their own key so that they are identified separately.
+func (g *Generator) tagFolders(openAPI OpenAPISpec, operations []Operation) []Folder {
+	tagMap := make(map[string][]Operation)
+	var used []string
+	for _, operation := range operations {
+		tags := operation.Tags
+		if len(tags) == 0 {
+			tags = []string{"default"}
+		}
+		if !g.layout.AllTags {
+			tags = tags[:1]
+		}
+
+		for i, tag := range tags {
+			placed := operation
+			if i > 0 {
+				placed.Key = operation.Key + "#" + tag
+			}
+			if _, ok := tagMap[tag]; !ok {
+				used = append(used, tag)
+			}
+			tagMap[tag] = append(tagMap[tag], placed)
+		}
+	}
+
+	tagDescriptions := make(map[string]string)
+	for _, tag := range openAPI.Tags {
+		tagDescriptions[tag.Name] = tag.Description
+	}
+
+	var folders []Folder
+	for _, tag := range orderTags(openAPI.Tags, used) {
+		description := tagDescriptions[tag]
+		if description == "" {
+			description = strings.ReplaceAll(g.folderDescription, "%s", tag)
+		}
+
+		folders = append(folders, Folder{
+			Name:        tag,
+			Description: description,
+			Operations:  tagMap[tag],
+		})
+	}
+
+	return groupTagFolders(openAPI.TagGroups, folders)
+}
+
+// groupTagFolders nests tag folders under the x-tagGroups listing them. A tag listed by
+// several groups goes under the first one; tags without a group stay at the top level,
+// after the groups.
+func groupTagFolders(groups []OpenAPITagGroup, folders []Folder) []Folder {
+	if len(groups) == 0 {
+		return folders
+	}
+
+	byTag := make(map[string]Folder, len(folders))
+	for _, folder := range folders {
+		byTag[folder.Name] = folder
+	}
+
+	placed := make(map[string]bool)
+	var grouped []Folder
+	for _, group := range groups {
+		folder := Folder{Name: group.Name}
+		for _, tag := range group.Tags {
+			if tagFolder, ok := byTag[tag]; ok && !placed[tag] {
+				placed[tag] = true
+				folder.Folders = append(folder.Folders, tagFolder)
+			}
+		}
+		if len(folder.Folders) > 0 {
+			grouped = append(grouped, folder)
+		}
+	}
+
+	for _, folder := range folders {
+		if !placed[folder.Name] {
+			grouped = append(grouped, folder)
+		}
+	}
+	return grouped
+}
+
+// pathFolders nests operations in one folder per static path segment, so that
+// /customers/{id}/address goes to customers › address. Operations without static
+// segments go to a "default" folder and webhooks to a "webhooks" folder.
+func pathFolders(operations []Operation) []Folder {
+	root := &Folder{}
+	for _, operation := range operations {
+		segments := staticSegments(operation.Path)
+		switch {
+		case operation.Webhook || strings.HasPrefix(operation.Path, webhookPathPrefix):
+			segments = []string{"webhooks"}
+		case len(segments) == 0:
+			segments = []string{"default"}
+		}
+
+		folder := root
+		for i, segment := range segments {
+			folder = subFolder(folder, segment, "/"+strings.Join(segments[:i+1], "/"))
+		}
+		folder.Operations = append(folder.Operations, operation)
+	}
+	return root.Folders
+}
+
+// subFolder returns the sub-folder with the given name, creating it when missing
+func subFolder(parent *Folder, name, path string) *Folder {
+	for i := range parent.Folders {
+		if parent.Folders[i].Name == name {
+			return &parent.Folders[i]
+		}
+	}
+
+	parent.Folders = append(parent.Folders, Folder{
+		Name:        name,
+		Description: fmt.Sprintf("Operations under %s", path),
+	})
+	return &parent.Folders[len(parent.Folders)-1]
+}
+
+// staticSegments returns the segments of a path that are not parameters
+func staticSegments(path string) []string {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" && !strings.Contains(segment, "{") {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
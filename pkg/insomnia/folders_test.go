@@ -0,0 +1,140 @@
+package insomnia
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const folderSpec = `
+openapi: 3.0.3
+info:
+  title: Shop API
+  version: 1.0.0
+tags:
+  - name: orders
+  - name: payments
+  - name: customers
+x-tagGroups:
+  - name: Sales
+    tags: [orders, payments]
+paths:
+  /customers:
+    get:
+      summary: List customers
+      tags: [customers]
+  /customers/{id}/orders:
+    get:
+      summary: List customer orders
+      tags: [orders, customers]
+  /orders/{id}/payments:
+    post:
+      summary: Pay order
+      tags: [payments]
+`
+
+func TestTagGroupFolders(t *testing.T) {
+	api, err := NewDeterministicGenerator().ParseOpenAPI([]byte(folderSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if len(api.Folders) != 2 || api.Folders[0].Name != "Sales" || api.Folders[1].Name != "customers" {
+		t.Fatalf("Test failed. Expected the Sales group followed by ungrouped tags, got %v", api.Folders)
+	}
+	sales := api.Folders[0]
+	if len(sales.Folders) != 2 || sales.Folders[0].Name != "orders" || sales.Folders[1].Name != "payments" {
+		t.Errorf("Test failed. Expected orders and payments under Sales, got %v", sales.Folders)
+	}
+	if len(api.Folders[1].Operations) != 1 {
+		t.Errorf("Test failed. Expected operations only under their first tag, got %v", api.Folders[1].Operations)
+	}
+}
+
+func TestAllTagsFolders(t *testing.T) {
+	g := NewDeterministicGenerator(WithFolderLayout(FolderLayout{AllTags: true}))
+	spec, err := g.GenerateFromOpenAPI([]byte(folderSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	var ids []string
+	for _, folder := range append(spec.Collection[0].Folders, spec.Collection[1]) {
+		for _, request := range folder.Children {
+			if request.Name == "List customer orders" {
+				ids = append(ids, request.Meta.ID)
+			}
+		}
+	}
+	if len(ids) != 2 || ids[0] == ids[1] {
+		t.Errorf("Test failed. Expected the operation under both of its tags with distinct IDs, got %v", ids)
+	}
+}
+
+func TestPathFolders(t *testing.T) {
+	g := NewDeterministicGenerator(WithFolderLayout(FolderLayout{ByPath: true}))
+	api, err := g.ParseOpenAPI([]byte(folderSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	var placed []string
+	walkOperations(api.Folders, nil, func(path []string, operation Operation) {
+		placed = append(placed, strings.Join(path, "/")+": "+operation.Name)
+	})
+
+	expected := []string{
+		"customers/orders: List customer orders",
+		"customers: List customers",
+		"orders/payments: Pay order",
+	}
+	if strings.Join(placed, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Test failed. Expected %v, got %v", expected, placed)
+	}
+}
+
+func TestNestedCollectionRoundTrip(t *testing.T) {
+	g := NewDeterministicGenerator()
+	spec, err := g.GenerateFromOpenAPI([]byte(folderSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	data, err := yaml.Marshal(spec)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	read, err := ParseInsomnia(data)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	sales := read.Collection[0]
+	if len(sales.Children) != 0 || len(sales.Folders) != 2 {
+		t.Fatalf("Test failed. Expected sub-folders to be read back as folders, got %v", sales)
+	}
+	if orders := sales.Folders[0]; len(orders.Children) != 1 || orders.Children[0].Method != "GET" {
+		t.Errorf("Test failed. Expected requests of sub-folders to be read back, got %v", orders)
+	}
+}
+
+func TestUpdateKeepsIDsOfGroupedFolders(t *testing.T) {
+	ungrouped := strings.Replace(folderSpec, "x-tagGroups:", "x-ignored:", 1)
+	existing, err := NewGenerator().GenerateFromOpenAPI([]byte(ungrouped))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	merged, err := NewGenerator().UpdateFromOpenAPI([]byte(folderSpec), existing, false)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if merged.Collection[0].Folders[0].Meta.ID != existing.Collection[0].Meta.ID {
+		t.Errorf("Test failed. Expected the orders folder to keep its ID when moved into a group")
+	}
+	if len(merged.Collection) != 2 {
+		t.Errorf("Test failed. Expected no Removed folder, got %d folders", len(merged.Collection))
+	}
+}
@@ -1,7 +1,10 @@
 package insomnia
 
 import (
+	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -31,20 +34,135 @@ type Meta struct {
 	SortKey     int64  `yaml:"sortKey,omitempty"`
 }
 
-// CollectionItem represents a folder or request in the collection
+// CollectionItem represents a folder of the collection. Insomnia lists its sub-folders
+// and requests together as children; sub-folders are written first.
 type CollectionItem struct {
-	Name     string        `yaml:"name"`
-	Meta     Meta          `yaml:"meta"`
-	Children []RequestItem `yaml:"children,omitempty"`
+	Name     string
+	Meta     Meta
+	Folders  []CollectionItem
+	Children []RequestItem
+	Extra    map[string]interface{}
+}
+
+// collectionItemYAML is the YAML form of a CollectionItem
+type collectionItemYAML struct {
+	Name     string                 `yaml:"name"`
+	Meta     Meta                   `yaml:"meta"`
+	Children []yaml.Node            `yaml:"children,omitempty"`
+	Extra    map[string]interface{} `yaml:",inline"`
+}
+
+// MarshalYAML writes the sub-folders and requests of a folder as its children
+func (c CollectionItem) MarshalYAML() (interface{}, error) {
+	item := collectionItemYAML{Name: c.Name, Meta: c.Meta, Extra: c.Extra}
+	for _, folder := range c.Folders {
+		var node yaml.Node
+		if err := node.Encode(folder); err != nil {
+			return nil, err
+		}
+		item.Children = append(item.Children, node)
+	}
+	for _, request := range c.Children {
+		var node yaml.Node
+		if err := node.Encode(request); err != nil {
+			return nil, err
+		}
+		item.Children = append(item.Children, node)
+	}
+	return item, nil
+}
+
+// UnmarshalYAML reads the children of a folder, telling requests (which have a url or
+// method) from sub-folders
+func (c *CollectionItem) UnmarshalYAML(value *yaml.Node) error {
+	var item collectionItemYAML
+	if err := value.Decode(&item); err != nil {
+		return err
+	}
+
+	*c = CollectionItem{Name: item.Name, Meta: item.Meta, Extra: item.Extra}
+	for i := range item.Children {
+		child := &item.Children[i]
+		if isRequestNode(child) {
+			var request RequestItem
+			if err := child.Decode(&request); err != nil {
+				return err
+			}
+			c.Children = append(c.Children, request)
+			continue
+		}
+
+		var folder CollectionItem
+		if err := child.Decode(&folder); err != nil {
+			return err
+		}
+		c.Folders = append(c.Folders, folder)
+	}
+	return nil
+}
+
+// isRequestNode reports whether a child of a folder is a request
+func isRequestNode(node *yaml.Node) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key := node.Content[i].Value; key == "url" || key == "method" {
+			return true
+		}
+	}
+	return false
 }
 
 // RequestItem represents an individual API request
 type RequestItem struct {
-	URL      string          `yaml:"url"`
-	Name     string          `yaml:"name"`
-	Meta     Meta            `yaml:"meta"`
-	Method   string          `yaml:"method"`
-	Settings RequestSettings `yaml:"settings"`
+	URL            string                 `yaml:"url"`
+	Name           string                 `yaml:"name"`
+	Meta           Meta                   `yaml:"meta"`
+	Method         string                 `yaml:"method"`
+	Body           *RequestBody           `yaml:"body,omitempty"`
+	Parameters     []RequestParameter     `yaml:"parameters,omitempty"`
+	Headers        []RequestHeader        `yaml:"headers,omitempty"`
+	Authentication *RequestAuthentication `yaml:"authentication,omitempty"`
+	Scripts        *RequestScripts        `yaml:"scripts,omitempty"`
+	Settings       RequestSettings        `yaml:"settings"`
+
+	// Extra holds fields edited in Insomnia that the generator does not manage
+	Extra map[string]interface{} `yaml:",inline"`
+}
+
+// RequestScripts contains the scripts run before a request is sent and after its
+// response is received
+type RequestScripts struct {
+	PreRequest    string `yaml:"preRequest,omitempty"`
+	AfterResponse string `yaml:"afterResponse,omitempty"`
+}
+
+// RequestBody contains the request payload
+type RequestBody struct {
+	MimeType string      `yaml:"mimeType"`
+	Text     string      `yaml:"text,omitempty"`
+	Params   []BodyParam `yaml:"params,omitempty"`
+}
+
+// BodyParam represents a form field of a form-encoded or multipart body
+type BodyParam struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// RequestParameter represents a query string parameter
+type RequestParameter struct {
+	Name     string `yaml:"name"`
+	Value    string `yaml:"value"`
+	Disabled bool   `yaml:"disabled,omitempty"`
+}
+
+// RequestHeader represents a request header
+type RequestHeader struct {
+	Name     string `yaml:"name"`
+	Value    string `yaml:"value"`
+	Disabled bool   `yaml:"disabled,omitempty"`
 }
 
 // RequestSettings contains request configuration
@@ -64,8 +182,9 @@ type CookieSettings struct {
 
 // CookieJar represents the cookie jar configuration
 type CookieJar struct {
-	Name string `yaml:"name"`
-	Meta Meta   `yaml:"meta"`
+	Name  string                 `yaml:"name"`
+	Meta  Meta                   `yaml:"meta"`
+	Extra map[string]interface{} `yaml:",inline"`
 }
 
 // Environment represents the environment configuration
@@ -78,7 +197,8 @@ type Environment struct {
 
 // EnvironmentData contains environment variables
 type EnvironmentData struct {
-	BaseURL string `yaml:"base_url"`
+	BaseURL string                 `yaml:"base_url"`
+	Extra   map[string]interface{} `yaml:",inline"`
 }
 
 // SubEnvironment represents a specific environment configuration
@@ -90,14 +210,15 @@ type SubEnvironment struct {
 
 // SubEnvironmentData contains sub-environment specific data
 type SubEnvironmentData struct {
-	Scheme   string `yaml:"scheme"`
-	BasePath string `yaml:"base_path"`
-	Host     string `yaml:"host"`
+	Scheme   string                 `yaml:"scheme"`
+	BasePath string                 `yaml:"base_path"`
+	Host     string                 `yaml:"host"`
+	Extra    map[string]interface{} `yaml:",inline"`
 }
 
 // SpecContainer wraps the OpenAPI spec
 type SpecContainer struct {
-	Contents interface{} `yaml:"contents"`
+	Contents interface{} `yaml:"contents,omitempty"`
 	Meta     Meta        `yaml:"meta"`
 }
 
@@ -108,6 +229,9 @@ type OpenAPISpec struct {
 	Servers []OpenAPIServer        `yaml:"servers"`
 	Paths   map[string]interface{} `yaml:"paths"`
 	Tags    []OpenAPITag           `yaml:"tags,omitempty"`
+
+	// TagGroups groups tags for navigation, as in Redoc's x-tagGroups extension
+	TagGroups []OpenAPITagGroup `yaml:"x-tagGroups,omitempty"`
 }
 
 // OpenAPIInfo contains API information
@@ -126,8 +250,16 @@ type OpenAPIContact struct {
 
 // OpenAPIServer represents a server configuration
 type OpenAPIServer struct {
-	URL         string `yaml:"url"`
-	Description string `yaml:"description,omitempty"`
+	URL         string                           `yaml:"url"`
+	Description string                           `yaml:"description,omitempty"`
+	Variables   map[string]OpenAPIServerVariable `yaml:"variables,omitempty"`
+}
+
+// OpenAPIServerVariable represents a variable of a templated server URL
+type OpenAPIServerVariable struct {
+	Default     string   `yaml:"default"`
+	Enum        []string `yaml:"enum,omitempty"`
+	Description string   `yaml:"description,omitempty"`
 }
 
 // OpenAPITag represents an API tag
@@ -136,84 +268,203 @@ type OpenAPITag struct {
 	Description string `yaml:"description,omitempty"`
 }
 
+// OpenAPITagGroup is an entry of x-tagGroups
+type OpenAPITagGroup struct {
+	Name string   `yaml:"name"`
+	Tags []string `yaml:"tags"`
+}
+
+// httpMethods lists the path item keys that describe operations, in the order they are generated
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// deterministicTimestamp is the fixed timestamp used by deterministic generators
+const deterministicTimestamp int64 = 1700000000000
+
 // Generator handles the conversion from OpenAPI to Insomnia format
 type Generator struct {
-	timestamp int64
+	timestamp      int64
+	deterministic  bool
+	layout         FolderLayout
+	sortOrder      SortOrder
+	overlays       []string
+	overlayServers map[string]string
+	filter         OperationFilter
+	requestName    string
+	deprecated     DeprecatedHandling
+
+	specType          string
+	settings          RequestSettings
+	folderDescription string
+	environmentName   string
+	omitSpec          bool
+	responseExamples  bool
+	responseTests     bool
+}
+
+// NewGenerator creates a new generator instance, customized by the given options
+func NewGenerator(options ...Option) *Generator {
+	g := &Generator{
+		timestamp:         time.Now().UnixMilli(),
+		specType:          insomniaSpecType,
+		settings:          defaultRequestSettings,
+		folderDescription: defaultFolderDescription,
+	}
+	for _, option := range options {
+		option(g)
+	}
+	return g
 }
 
-// NewGenerator creates a new generator instance
-func NewGenerator() *Generator {
-	return &Generator{
-		timestamp: time.Now().UnixMilli(),
-	}
+// NewDeterministicGenerator creates a generator whose IDs are derived from stable keys
+// (workspace title, tag, operationId or method+path, server URL) and whose timestamps
+// are fixed, so regenerating an unchanged spec produces identical output
+func NewDeterministicGenerator(options ...Option) *Generator {
+	return NewGenerator(append([]Option{WithDeterministicIDs()}, options...)...)
 }
 
-// GenerateFromOpenAPI converts an OpenAPI spec to Insomnia format
+// GenerateFromOpenAPI converts an OpenAPI spec (YAML or JSON) to Insomnia format.
+// Swagger 2.0 and OpenAPI 3.1 documents are normalized to OpenAPI 3.0 before
+// generating. References to other files are resolved relative to the working directory.
 func (g *Generator) GenerateFromOpenAPI(openAPIData []byte) (*InsomniaSpec, error) {
-	var openAPI OpenAPISpec
-	if err := yaml.Unmarshal(openAPIData, &openAPI); err != nil {
-		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	return g.generate(openAPIData, "")
+}
+
+// GenerateFromFile reads an OpenAPI file and converts it to Insomnia format,
+// resolving references to other files relative to it
+func (g *Generator) GenerateFromFile(openAPIFile string) (*InsomniaSpec, error) {
+	openAPIData, err := os.ReadFile(openAPIFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI file: %w", err)
 	}
 
-	// Parse the full spec as interface{} to preserve all data
-	var fullSpec interface{}
-	if err := yaml.Unmarshal(openAPIData, &fullSpec); err != nil {
-		return nil, fmt.Errorf("failed to parse full OpenAPI spec: %w", err)
+	return g.generate(openAPIData, openAPIFile)
+}
+
+// generate converts OpenAPI data read from source (empty when unknown) to Insomnia format
+func (g *Generator) generate(openAPIData []byte, source string) (*InsomniaSpec, error) {
+	api, err := g.parse(openAPIData, source)
+	if err != nil {
+		return nil, err
 	}
 
-	insomnia := &InsomniaSpec{
-		Type: "spec.insomnia.rest/5.0",
-		Name: fmt.Sprintf("%s %s", openAPI.Info.Title, openAPI.Info.Version),
+	return g.BuildInsomnia(api), nil
+}
+
+// BuildInsomnia converts the API model to an Insomnia workspace
+func (g *Generator) BuildInsomnia(api *API) *InsomniaSpec {
+	title := api.Title
+
+	spec := &InsomniaSpec{
+		Type: g.specType,
+		Name: fmt.Sprintf("%s %s", api.Title, api.Version),
 		Meta: Meta{
-			ID:          g.generateWorkspaceID(),
+			ID:          g.generateWorkspaceID(title),
 			Created:     g.timestamp,
 			Modified:    g.timestamp - 1,
 			Description: "",
 		},
-		Collection:   g.generateCollection(openAPI),
-		CookieJar:    g.generateCookieJar(),
-		Environments: g.generateEnvironments(openAPI),
+		Collection:   g.generateCollection(api),
+		CookieJar:    g.generateCookieJar(title),
+		Environments: g.generateEnvironments(api),
 		Spec: SpecContainer{
-			Contents: fullSpec,
+			Contents: api.Document,
 			Meta: Meta{
-				ID:       g.generateSpecID(),
+				ID:       g.generateSpecID(title),
 				Created:  g.timestamp + 3,
 				Modified: g.timestamp + 4,
 			},
 		},
 	}
+	if g.omitSpec {
+		spec.Spec.Contents = nil
+	}
+
+	return spec
+}
+
+// parseDocument parses a YAML or JSON document, sniffing JSON from its first character
+func parseDocument(data []byte) (interface{}, error) {
+	var document interface{}
+
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		if err := json.Unmarshal(trimmed, &document); err != nil {
+			return nil, err
+		}
+		return document, nil
+	}
+
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return normalizeKeys(document), nil
+}
+
+// decodeOpenAPISpec decodes the typed view of a normalized OpenAPI document
+func decodeOpenAPISpec(document map[string]interface{}) (OpenAPISpec, error) {
+	var openAPI OpenAPISpec
+
+	data, err := yaml.Marshal(document)
+	if err != nil {
+		return openAPI, err
+	}
 
-	return insomnia, nil
+	err = yaml.Unmarshal(data, &openAPI)
+	return openAPI, err
 }
 
 // generateWorkspaceID generates a workspace ID
-func (g *Generator) generateWorkspaceID() string {
-	return fmt.Sprintf("wrk_%s", g.generateRandomID())
+func (g *Generator) generateWorkspaceID(title string) string {
+	return g.generateID("wrk", title)
 }
 
 // generateSpecID generates a spec ID
-func (g *Generator) generateSpecID() string {
-	return fmt.Sprintf("spc_%s", g.generateRandomID())
+func (g *Generator) generateSpecID(title string) string {
+	return g.generateID("spc", title)
 }
 
 // generateFolderID generates a folder ID
-func (g *Generator) generateFolderID() string {
-	return fmt.Sprintf("fld_%s", g.generateRandomID())
+func (g *Generator) generateFolderID(title, tag string) string {
+	return g.generateID("fld", title, tag)
 }
 
 // generateRequestID generates a request ID
-func (g *Generator) generateRequestID() string {
-	return fmt.Sprintf("req_%s", g.generateRandomID())
+func (g *Generator) generateRequestID(title, operationKey string) string {
+	return g.generateID("req", title, operationKey)
+}
+
+// describeOperation returns the description of the request of an operation, followed by
+// its documented responses when response examples are enabled
+func (g *Generator) describeOperation(operation Operation) string {
+	if !g.responseExamples {
+		return operation.Description
+	}
+	return describeResponses(operation.Description, operation.Responses)
 }
 
 // generateEnvironmentID generates an environment ID
-func (g *Generator) generateEnvironmentID() string {
-	return fmt.Sprintf("env_%s", g.generateRandomID())
+func (g *Generator) generateEnvironmentID(title, serverURL string) string {
+	return g.generateID("env", title, serverURL)
 }
 
 // generateCookieJarID generates a cookie jar ID
-func (g *Generator) generateCookieJarID() string {
-	return fmt.Sprintf("jar_%s", g.generateRandomID())
+func (g *Generator) generateCookieJarID(title string) string {
+	return g.generateID("jar", title)
+}
+
+// generateID generates a prefixed ID, hashed from the prefix and keys when the
+// generator is deterministic and random otherwise
+func (g *Generator) generateID(prefix string, keys ...string) string {
+	if !g.deterministic {
+		return fmt.Sprintf("%s_%s", prefix, g.generateRandomID())
+	}
+	return deterministicID(prefix, keys...)
+}
+
+// deterministicID generates a prefixed ID hashed from the prefix and keys
+func deterministicID(prefix string, keys ...string) string {
+	hash := sha256.Sum256([]byte(prefix + "\x00" + strings.Join(keys, "\x00")))
+	return fmt.Sprintf("%s_%x", prefix, hash[:16])
 }
 
 // generateRandomID generates a random ID
@@ -226,181 +477,150 @@ func (g *Generator) generateRandomID() string {
 	return fmt.Sprintf("%x", bytes)
 }
 
-// generateCollection creates the collection structure from OpenAPI paths
-func (g *Generator) generateCollection(openAPI OpenAPISpec) []CollectionItem {
-	// Group requests by tags
-	tagMap := make(map[string][]RequestItem)
-	tagDescriptions := make(map[string]string)
-
-	// Build tag descriptions map
-	for _, tag := range openAPI.Tags {
-		tagDescriptions[tag.Name] = tag.Description
-	}
-
-	sortKey := -g.timestamp
-
-	// Process paths
-	for path, pathItem := range openAPI.Paths {
-		pathData, ok := pathItem.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		for method, operation := range pathData {
-			if method == "parameters" || method == "summary" || method == "description" {
-				continue
-			}
-
-			opData, ok := operation.(map[string]interface{})
-			if !ok {
-				continue
-			}
-
-			// Extract operation details
-			summary := g.getStringValue(opData, "summary")
-			description := g.getStringValue(opData, "description")
-			tags := g.getStringSlice(opData, "tags")
-
-			if summary == "" {
-				summary = fmt.Sprintf("%s %s", strings.ToUpper(method), path)
-			}
-
-			// Create request item
-			request := RequestItem{
-				URL:    g.buildURL(path, opData),
-				Name:   summary,
-				Method: strings.ToUpper(method),
-				Meta: Meta{
-					ID:          g.generateRequestID(),
-					Created:     g.timestamp + 10,
-					Modified:    g.timestamp + 10,
-					IsPrivate:   false,
-					Description: description,
-					SortKey:     sortKey,
-				},
-				Settings: RequestSettings{
-					RenderRequestBody: true,
-					EncodeURL:         true,
-					FollowRedirects:   "global",
-					Cookies: CookieSettings{
-						Send:  true,
-						Store: true,
-					},
-					RebuildPath: true,
-				},
-			}
-
-			// Assign to appropriate tag
-			tag := "default"
-			if len(tags) > 0 {
-				tag = tags[0]
-			}
-
-			tagMap[tag] = append(tagMap[tag], request)
-			sortKey++
-		}
-	}
-
-	// Create collection items
-	var collection []CollectionItem
+// generateCollection creates the collection structure from the folders of the API
+func (g *Generator) generateCollection(api *API) []CollectionItem {
 	folderSortKey := -g.timestamp
+	sortKey := -g.timestamp
+	return g.generateFolders(api.Title, api.Folders, nil, &folderSortKey, &sortKey)
+}
 
-	for tag, requests := range tagMap {
-		description := tagDescriptions[tag]
-		if description == "" {
-			description = fmt.Sprintf("Operations related to %s", tag)
-		}
-
-		folder := CollectionItem{
-			Name: tag,
+// generateFolders creates the items of a folder tree. Folder IDs derive from the names of
+// the folders leading to them; sort keys increase across the whole collection.
+func (g *Generator) generateFolders(title string, folders []Folder, parents []string, folderSortKey, sortKey *int64) []CollectionItem {
+	var items []CollectionItem
+	for _, folder := range folders {
+		path := append(append([]string{}, parents...), folder.Name)
+		item := CollectionItem{
+			Name: folder.Name,
 			Meta: Meta{
-				ID:          g.generateFolderID(),
+				ID:          g.generateFolderID(title, strings.Join(path, "/")),
 				Created:     g.timestamp + 5,
 				Modified:    g.timestamp + 5,
-				SortKey:     folderSortKey,
-				Description: description,
+				SortKey:     *folderSortKey,
+				Description: folder.Description,
 			},
-			Children: requests,
 		}
+		*folderSortKey++
 
-		collection = append(collection, folder)
-		folderSortKey++
+		item.Folders = g.generateFolders(title, folder.Folders, path, folderSortKey, sortKey)
+		item.Children = make([]RequestItem, 0, len(folder.Operations))
+		for _, operation := range folder.Operations {
+			item.Children = append(item.Children, g.generateRequest(title, operation, *sortKey))
+			*sortKey++
+		}
+
+		items = append(items, item)
 	}
 
-	return collection
+	return items
 }
 
-// buildURL constructs the URL with template variables
-func (g *Generator) buildURL(path string, operation map[string]interface{}) string {
-	baseURL := "{{ _.base_url }}"
+// generateRequest creates the Insomnia request of an operation
+func (g *Generator) generateRequest(title string, operation Operation, sortKey int64) RequestItem {
+	request := RequestItem{
+		URL:            insomniaTemplate(operation.URL),
+		Name:           operation.Name,
+		Method:         operation.Method,
+		Body:           insomniaBody(operation.Body),
+		Authentication: insomniaAuthentication(operation.Auth),
+		Meta: Meta{
+			ID:          g.generateRequestID(title, operation.Key),
+			Created:     g.timestamp + 10,
+			Modified:    g.timestamp + 10,
+			IsPrivate:   false,
+			Description: markOperationID(g.describeOperation(operation), operation.OperationID),
+			SortKey:     sortKey,
+		},
+		Settings: g.settings,
+	}
 
-	// Replace path parameters with template variables
-	url := path
-	if params, ok := operation["parameters"].([]interface{}); ok {
-		for _, param := range params {
-			if paramMap, ok := param.(map[string]interface{}); ok {
-				if paramMap["in"] == "path" {
-					paramName := paramMap["name"].(string)
-					url = strings.ReplaceAll(url, fmt.Sprintf("{%s}", paramName), fmt.Sprintf("{{ _.%s }}", paramName))
-				}
-			}
+	for _, param := range operation.Query {
+		request.Parameters = append(request.Parameters, RequestParameter(param))
+	}
+	for _, header := range operation.Headers {
+		request.Headers = append(request.Headers, RequestHeader(header))
+	}
+	if g.responseTests {
+		if script := responseTestScript(operation.Responses); script != "" {
+			request.Scripts = &RequestScripts{AfterResponse: script}
 		}
 	}
 
-	return baseURL + url
+	return request
+}
+
+// insomniaBody converts a body of the API model to an Insomnia request body
+func insomniaBody(body *Body) *RequestBody {
+	if body == nil {
+		return nil
+	}
+	return &RequestBody{MimeType: body.MimeType, Text: body.Text, Params: body.Params}
+}
+
+// insomniaTemplate rewrites {{name}} variable references into Insomnia's {{ _.name }} syntax
+func insomniaTemplate(value string) string {
+	return variablePattern.ReplaceAllString(value, "{{ _.$1 }}")
 }
 
 // generateCookieJar creates the cookie jar configuration
-func (g *Generator) generateCookieJar() CookieJar {
+func (g *Generator) generateCookieJar(title string) CookieJar {
 	return CookieJar{
 		Name: "Default Jar",
 		Meta: Meta{
-			ID:       g.generateCookieJarID(),
+			ID:       g.generateCookieJarID(title),
 			Created:  g.timestamp - 5,
 			Modified: g.timestamp - 5,
 		},
 	}
 }
 
-// generateEnvironments creates environment configurations from OpenAPI servers
-func (g *Generator) generateEnvironments(openAPI OpenAPISpec) Environment {
-	baseEnvID := g.generateEnvironmentID()
-
+// generateEnvironments creates environment configurations from the servers of the API
+func (g *Generator) generateEnvironments(api *API) Environment {
 	env := Environment{
 		Name: "Base Environment",
 		Meta: Meta{
-			ID:        baseEnvID,
+			ID:        g.generateEnvironmentID(api.Title, ""),
 			Created:   g.timestamp - 7,
 			Modified:  g.timestamp + 8,
 			IsPrivate: false,
 		},
 		Data: EnvironmentData{
 			BaseURL: "{{ _.scheme }}://{{ _.host }}{{ _.base_path }}",
+			Extra:   make(map[string]interface{}, len(api.Variables)),
 		},
 		SubEnvironments: []SubEnvironment{},
 	}
 
+	for name, value := range api.Variables {
+		env.Data.Extra[name] = value
+	}
+
 	// Create sub-environments from servers
 	sortKey := g.timestamp + 9
-	for _, server := range openAPI.Servers {
-		scheme, host, basePath := g.parseServerURL(server.URL)
-
+	for _, server := range api.Servers {
 		subEnv := SubEnvironment{
-			Name: fmt.Sprintf("OpenAPI env %s", host),
+			Name: server.Name,
 			Meta: Meta{
-				ID:        g.generateEnvironmentID(),
+				ID:        g.generateEnvironmentID(api.Title, server.Key),
 				Created:   sortKey,
 				Modified:  sortKey,
-				IsPrivate: false,
+				IsPrivate: server.Private,
 				SortKey:   sortKey,
 			},
 			Data: SubEnvironmentData{
-				Scheme:   scheme,
-				BasePath: basePath,
-				Host:     host,
+				Scheme:   server.Scheme,
+				BasePath: server.BasePath,
+				Host:     server.Host,
 			},
 		}
 
+		if len(server.Variables) > 0 {
+			subEnv.Data.Extra = make(map[string]interface{}, len(server.Variables))
+			for variable, value := range server.Variables {
+				subEnv.Data.Extra[variable] = value
+			}
+		}
+
 		env.SubEnvironments = append(env.SubEnvironments, subEnv)
 		sortKey++
 	}
@@ -408,31 +628,6 @@ func (g *Generator) generateEnvironments(openAPI OpenAPISpec) Environment {
 	return env
 }
 
-// parseServerURL extracts scheme, host, and base path from server URL
-func (g *Generator) parseServerURL(serverURL string) (scheme, host, basePath string) {
-	// Simple URL parsing - can be enhanced
-	if strings.HasPrefix(serverURL, "https://") {
-		scheme = "https"
-		serverURL = strings.TrimPrefix(serverURL, "https://")
-	} else if strings.HasPrefix(serverURL, "http://") {
-		scheme = "http"
-		serverURL = strings.TrimPrefix(serverURL, "http://")
-	} else {
-		scheme = "http"
-	}
-
-	parts := strings.SplitN(serverURL, "/", 2)
-	host = parts[0]
-
-	if len(parts) > 1 {
-		basePath = "/" + parts[1]
-	} else {
-		basePath = ""
-	}
-
-	return
-}
-
 // Helper functions
 func (g *Generator) getStringValue(data map[string]interface{}, key string) string {
 	if val, ok := data[key].(string); ok {
@@ -456,14 +651,8 @@ func (g *Generator) getStringSlice(data map[string]interface{}, key string) []st
 
 // GenerateToFile generates Insomnia YAML and writes it to a file
 func (g *Generator) GenerateToFile(openAPIFile, outputFile string) error {
-	// Read OpenAPI file
-	openAPIData, err := os.ReadFile(openAPIFile)
-	if err != nil {
-		return fmt.Errorf("failed to read OpenAPI file: %w", err)
-	}
-
 	// Generate Insomnia spec
-	insomniaSpec, err := g.GenerateFromOpenAPI(openAPIData)
+	insomniaSpec, err := g.GenerateFromFile(openAPIFile)
 	if err != nil {
 		return fmt.Errorf("failed to generate Insomnia spec: %w", err)
 	}
@@ -474,10 +663,7 @@ func (g *Generator) GenerateToFile(openAPIFile, outputFile string) error {
 		return fmt.Errorf("failed to marshal Insomnia spec: %w", err)
 	}
 
-	// Write to file
-	if err := os.WriteFile(outputFile, yamlData, 0644); err != nil {
-		return fmt.Errorf("failed to write output file: %w", err)
-	}
-
-	return nil
+	// Write to file, unless it is unchanged
+	_, err = writeFileIfChanged(outputFile, yamlData, 0644)
+	return err
 }
@@ -0,0 +1,503 @@
+package insomnia
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: Test API
+  version: 1.0.0
+servers:
+  - url: http://localhost:8082/api/v1
+paths:
+  /customers/{customerId}/address:
+    post:
+      summary: Create customer address
+      operationId: createCustomerAddress
+      tags:
+        - addresses
+      parameters:
+        - name: customerId
+          in: path
+          required: true
+          schema:
+            type: integer
+      requestBody:
+        $ref: '#/components/requestBodies/AddressBody'
+  /customers:
+    post:
+      summary: Create customer
+      operationId: createCustomer
+      tags:
+        - customers
+      requestBody:
+        content:
+          application/json:
+            example:
+              name: John
+  /users:
+    parameters:
+      - name: X-Tenant
+        in: header
+        required: true
+        schema:
+          type: string
+          default: acme
+    get:
+      summary: Get all users
+      operationId: getAllUsers
+      tags:
+        - users
+      parameters:
+        - name: limit
+          in: query
+          required: false
+          schema:
+            type: integer
+            default: 10
+        - name: status
+          in: query
+          required: true
+          example: [active, pending]
+          schema:
+            type: array
+            items:
+              type: string
+        - $ref: '#/components/parameters/RequestID'
+  /nodes:
+    put:
+      summary: Replace node
+      operationId: replaceNode
+      tags:
+        - nodes
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Node'
+components:
+  parameters:
+    RequestID:
+      name: X-Request-ID
+      in: header
+      example: abc-123
+  requestBodies:
+    AddressBody:
+      required: true
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/AddressRequest'
+  schemas:
+    AddressRequest:
+      type: object
+      properties:
+        id:
+          type: integer
+          readOnly: true
+        street:
+          type: string
+          example: 456 Oak Avenue
+        type:
+          type: string
+          enum: [SHIPPING, BILLING]
+        isDefault:
+          type: boolean
+    Node:
+      type: object
+      properties:
+        name:
+          type: string
+        children:
+          type: array
+          items:
+            $ref: '#/components/schemas/Node'
+`
+
+func findRequest(spec *InsomniaSpec, name string) *RequestItem {
+	for i := range spec.Collection {
+		for j := range spec.Collection[i].Children {
+			if spec.Collection[i].Children[j].Name == name {
+				return &spec.Collection[i].Children[j]
+			}
+		}
+	}
+	return nil
+}
+
+func decodeBody(t *testing.T, request *RequestItem) map[string]interface{} {
+	if request == nil || request.Body == nil {
+		t.Fatalf("Test failed. Expected request with body")
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(request.Body.Text), &body); err != nil {
+		t.Fatalf("Test failed. Body is not valid JSON: %v", err)
+	}
+	return body
+}
+
+func TestGenerateBodyFromExample(t *testing.T) {
+	spec, err := NewGenerator().GenerateFromOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	request := findRequest(spec, "Create customer")
+	body := decodeBody(t, request)
+
+	if request.Body.MimeType != "application/json" {
+		t.Errorf("Test failed. Expected mimeType application/json, got %s", request.Body.MimeType)
+	}
+	if body["name"] != "John" {
+		t.Errorf("Test failed. Expected name John, got %v", body["name"])
+	}
+}
+
+func TestGenerateBodyFromReferencedSchema(t *testing.T) {
+	spec, err := NewGenerator().GenerateFromOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	body := decodeBody(t, findRequest(spec, "Create customer address"))
+
+	if body["street"] != "456 Oak Avenue" {
+		t.Errorf("Test failed. Expected street from schema example, got %v", body["street"])
+	}
+	if body["type"] != "SHIPPING" {
+		t.Errorf("Test failed. Expected first enum value, got %v", body["type"])
+	}
+	if body["isDefault"] != false {
+		t.Errorf("Test failed. Expected boolean placeholder, got %v", body["isDefault"])
+	}
+	if _, ok := body["id"]; ok {
+		t.Errorf("Test failed. Expected readOnly property to be skipped")
+	}
+}
+
+func TestGenerateBodyFromCircularSchema(t *testing.T) {
+	spec, err := NewGenerator().GenerateFromOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	body := decodeBody(t, findRequest(spec, "Replace node"))
+
+	if body["name"] != "string" {
+		t.Errorf("Test failed. Expected string placeholder, got %v", body["name"])
+	}
+	if children, ok := body["children"].([]interface{}); !ok || len(children) != 0 {
+		t.Errorf("Test failed. Expected circular reference to stop at an empty array, got %v", body["children"])
+	}
+}
+
+func TestRefResolverLookup(t *testing.T) {
+	refs := newRefResolver(map[string]interface{}{
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"a/b":   map[string]interface{}{"type": "string"},
+				"Alias": map[string]interface{}{"$ref": "#/components/schemas/a~1b"},
+				"Loop":  map[string]interface{}{"$ref": "#/components/schemas/Loop"},
+			},
+		},
+	})
+
+	resolved := refs.resolveMap(map[string]interface{}{"$ref": "#/components/schemas/Alias"})
+	if resolved["type"] != "string" {
+		t.Errorf("Test failed. Expected escaped pointer to resolve, got %v", resolved)
+	}
+
+	if refs.resolve(map[string]interface{}{"$ref": "#/components/schemas/Loop"}) != nil {
+		t.Errorf("Test failed. Expected circular reference to resolve to nil")
+	}
+}
+
+func TestGenerateQueryParametersAndHeaders(t *testing.T) {
+	spec, err := NewGenerator().GenerateFromOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	request := findRequest(spec, "Get all users")
+	if request == nil {
+		t.Fatalf("Test failed. Expected request Get all users")
+	}
+
+	expectedParams := []RequestParameter{
+		{Name: "limit", Value: "10", Disabled: true},
+		{Name: "status", Value: "active"},
+		{Name: "status", Value: "pending"},
+	}
+	if len(request.Parameters) != len(expectedParams) {
+		t.Fatalf("Test failed. Expected %d parameters, got %v", len(expectedParams), request.Parameters)
+	}
+	for i, expected := range expectedParams {
+		if request.Parameters[i] != expected {
+			t.Errorf("Test failed. Expected parameter %v, got %v", expected, request.Parameters[i])
+		}
+	}
+
+	expectedHeaders := []RequestHeader{
+		{Name: "X-Tenant", Value: "acme"},
+		{Name: "X-Request-ID", Value: "abc-123", Disabled: true},
+	}
+	if len(request.Headers) != len(expectedHeaders) {
+		t.Fatalf("Test failed. Expected %d headers, got %v", len(expectedHeaders), request.Headers)
+	}
+	for i, expected := range expectedHeaders {
+		if request.Headers[i] != expected {
+			t.Errorf("Test failed. Expected header %v, got %v", expected, request.Headers[i])
+		}
+	}
+}
+
+func TestGenerateContentTypeHeader(t *testing.T) {
+	spec, err := NewGenerator().GenerateFromOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	request := findRequest(spec, "Create customer address")
+	if len(request.Headers) != 1 || request.Headers[0].Name != "Content-Type" || request.Headers[0].Value != "application/json" {
+		t.Errorf("Test failed. Expected Content-Type header, got %v", request.Headers)
+	}
+
+	expectedURL := "{{ _.base_url }}/customers/{{ _.customerId }}/address"
+	if request.URL != expectedURL {
+		t.Errorf("Test failed. Expected URL %s, got %s", expectedURL, request.URL)
+	}
+}
+
+func TestDeterministicGeneratorIsStable(t *testing.T) {
+	generate := func(generator *Generator) []byte {
+		spec, err := generator.GenerateFromOpenAPI([]byte(testSpec))
+		if err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+		data, err := yaml.Marshal(spec)
+		if err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+		return data
+	}
+
+	first := generate(NewDeterministicGenerator())
+	second := generate(NewDeterministicGenerator())
+	if !bytes.Equal(first, second) {
+		t.Errorf("Test failed. Expected deterministic generators to produce identical output")
+	}
+
+	if bytes.Equal(first, generate(NewGenerator())) {
+		t.Errorf("Test failed. Expected default generator to produce random IDs")
+	}
+}
+
+func TestDeterministicIDsDependOnKeys(t *testing.T) {
+	generator := NewDeterministicGenerator()
+
+	if generator.generateRequestID("API", "getUser") != generator.generateRequestID("API", "getUser") {
+		t.Errorf("Test failed. Expected the same key to produce the same ID")
+	}
+	if generator.generateRequestID("API", "getUser") == generator.generateRequestID("API", "deleteUser") {
+		t.Errorf("Test failed. Expected different keys to produce different IDs")
+	}
+	if generator.generateWorkspaceID("API")[4:] == generator.generateCookieJarID("API")[4:] {
+		t.Errorf("Test failed. Expected IDs of different kinds to differ")
+	}
+}
+
+func TestGenerateFromJSON(t *testing.T) {
+	spec, err := NewGenerator().GenerateFromOpenAPI([]byte(`{
+  "openapi": "3.0.3",
+  "info": {"title": "JSON API", "version": "1.0.0"},
+  "paths": {
+    "/users": {
+      "post": {
+        "summary": "Create user",
+        "requestBody": {"content": {"application/json": {"example": {"name": "John"}}}}
+      }
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if spec.Name != "JSON API 1.0.0" {
+		t.Errorf("Test failed. Expected name JSON API 1.0.0, got %s", spec.Name)
+	}
+	if body := decodeBody(t, findRequest(spec, "Create user")); body["name"] != "John" {
+		t.Errorf("Test failed. Expected body from JSON example, got %v", body)
+	}
+}
+
+func TestGenerateFromFileWithExternalRefs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"api.yaml": `
+openapi: 3.0.3
+info:
+  title: Split API
+  version: 1.0.0
+paths:
+  /addresses:
+    post:
+      summary: Create address
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: './schemas/address.yaml#/Address'
+`,
+		"schemas/address.yaml": `
+Address:
+  type: object
+  properties:
+    street:
+      type: string
+      example: 123 Main Street
+    country:
+      $ref: '#/Country'
+    geo:
+      $ref: 'geo.json'
+Country:
+  type: string
+  example: USA
+`,
+		"schemas/geo.json": `{"type": "object", "properties": {"lat": {"type": "number", "example": 1.5}}}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+	}
+
+	spec, err := NewGenerator().GenerateFromFile(filepath.Join(dir, "api.yaml"))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	body := decodeBody(t, findRequest(spec, "Create address"))
+	if body["street"] != "123 Main Street" || body["country"] != "USA" {
+		t.Errorf("Test failed. Expected body from referenced files, got %v", body)
+	}
+	if geo, ok := body["geo"].(map[string]interface{}); !ok || geo["lat"] != 1.5 {
+		t.Errorf("Test failed. Expected nested JSON reference to resolve, got %v", body["geo"])
+	}
+
+	if err := os.Remove(filepath.Join(dir, "schemas/geo.json")); err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if _, err := NewGenerator().GenerateFromFile(filepath.Join(dir, "api.yaml")); err == nil {
+		t.Errorf("Test failed. Expected missing referenced file to fail")
+	}
+}
+
+func TestParseFileRecordsSourceFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "api.yaml", `
+openapi: 3.0.3
+info:
+  title: Split API
+  version: 1.0.0
+paths:
+  /addresses:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: 'address.yaml#/Address'
+`)
+	writeTestFile(t, dir, "address.yaml", "Address:\n  type: object\n")
+	writeTestFile(t, dir, "stages.env", "host=localhost:8080\nAPI_KEY=secret\n")
+
+	api, err := NewGenerator(WithEnvironmentOverlays(filepath.Join(dir, "stages.env"))).ParseFile(filepath.Join(dir, "api.yaml"))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	expected := []string{
+		filepath.Join(root, "api.yaml"),
+		filepath.Join(root, "address.yaml"),
+		filepath.Join(root, "stages.env"),
+	}
+	if !reflect.DeepEqual(api.Files, expected) {
+		t.Errorf("Test failed. Expected source files %v, got %v", expected, api.Files)
+	}
+
+	api, err = NewGenerator().ParseOpenAPI([]byte(overlaySpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if len(api.Files) != 0 {
+		t.Errorf("Test failed. Expected no source files for parsed data, got %v", api.Files)
+	}
+}
+
+func TestGenerateEnvironmentsFromServerVariables(t *testing.T) {
+	spec, err := NewGenerator().GenerateFromOpenAPI([]byte(`
+openapi: 3.0.3
+info:
+  title: Regional API
+  version: 1.0.0
+servers:
+  - url: https://{region}.api.example.com:{port}/v1/
+    description: Regional server
+    variables:
+      region:
+        default: us
+        enum: [us, eu]
+      port:
+        default: "8443"
+  - url: /api/v1
+paths: {}
+`))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	subEnvs := spec.Environments.SubEnvironments
+	if len(subEnvs) != 3 {
+		t.Fatalf("Test failed. Expected 3 sub-environments, got %d", len(subEnvs))
+	}
+
+	expected := []struct {
+		name, scheme, host, basePath string
+	}{
+		{"Regional server (region=us)", "https", "us.api.example.com:8443", "/v1"},
+		{"Regional server (region=eu)", "https", "eu.api.example.com:8443", "/v1"},
+		{"OpenAPI env localhost", "http", "localhost", "/api/v1"},
+	}
+	for i, e := range expected {
+		data := subEnvs[i].Data
+		if subEnvs[i].Name != e.name || data.Scheme != e.scheme || data.Host != e.host || data.BasePath != e.basePath {
+			t.Errorf("Test failed. Expected %v, got %s %s://%s%s", e, subEnvs[i].Name, data.Scheme, data.Host, data.BasePath)
+		}
+	}
+
+	if subEnvs[1].Data.Extra["region"] != "eu" || subEnvs[1].Data.Extra["port"] != "8443" {
+		t.Errorf("Test failed. Expected server variables as environment variables, got %v", subEnvs[1].Data.Extra)
+	}
+	if subEnvs[0].Meta.ID == subEnvs[1].Meta.ID {
+		t.Errorf("Test failed. Expected distinct IDs per combination")
+	}
+}
@@ -0,0 +1,191 @@
+package insomnia
+
+import (
+	"strings"
+	"time"
+)
+
+// HAR represents an HTTP Archive 1.2 file
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog contains the entries of an HTTP Archive
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator identifies the application that created the archive
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a single request of the archive. Generated entries have an empty response.
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            int         `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+// HARRequest describes a request of the archive
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+	Comment     string         `json:"comment,omitempty"`
+}
+
+// HARResponse describes the response to a request of the archive
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue is a cookie, header, query parameter or form field
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData contains the body of a request
+type HARPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Params   []HARNameValue `json:"params,omitempty"`
+}
+
+// HARContent contains the body of a response
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+// HARTimings contains the timings of a request
+type HARTimings struct {
+	Send    int `json:"send"`
+	Wait    int `json:"wait"`
+	Receive int `json:"receive"`
+}
+
+// BuildHAR renders every operation of the API as an entry of an HTTP Archive. URLs are
+// resolved against the first server; credentials are left as {{name}} placeholders.
+func (g *Generator) BuildHAR(api *API) *HAR {
+	har := &HAR{
+		Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{Name: "insomnia-generator", Version: "1.0"},
+			Entries: []HAREntry{},
+		},
+	}
+
+	started := time.UnixMilli(g.timestamp).UTC().Format("2006-01-02T15:04:05.000Z07:00")
+	baseURL := environmentServers(api)[0].URL()
+
+	walkOperations(api.Folders, nil, func(path []string, operation Operation) {
+		har.Log.Entries = append(har.Log.Entries, HAREntry{
+			StartedDateTime: started,
+			Time:            0,
+			Request:         harRequest(operation, baseURL),
+			Response: HARResponse{
+				HTTPVersion: "HTTP/1.1",
+				Cookies:     []HARNameValue{},
+				Headers:     []HARNameValue{},
+				HeadersSize: -1,
+				BodySize:    -1,
+			},
+			Timings: HARTimings{},
+			Comment: strings.Join(path, "/") + ": " + operation.Name,
+		})
+	})
+
+	return har
+}
+
+// harRequest converts an operation to an archived request
+func harRequest(operation Operation, baseURL string) HARRequest {
+	request := HARRequest{
+		Method:      operation.Method,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []HARNameValue{},
+		Headers:     []HARNameValue{},
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    -1,
+		Comment:     operation.Description,
+	}
+
+	query := operation.Query
+	for _, header := range operation.Headers {
+		if !header.Disabled {
+			request.Headers = append(request.Headers, HARNameValue{Name: header.Name, Value: header.Value})
+		}
+	}
+
+	if auth := operation.Auth; auth != nil {
+		switch auth.Type {
+		case "bearer":
+			request.Headers = append(request.Headers, HARNameValue{Name: "Authorization", Value: "Bearer " + auth.Token})
+		case "basic", "digest":
+			scheme := "Basic "
+			if auth.Type == "digest" {
+				scheme = "Digest "
+			}
+			request.Headers = append(request.Headers, HARNameValue{Name: "Authorization", Value: scheme + auth.Username + ":" + auth.Password})
+		case "apikey":
+			switch auth.In {
+			case "query":
+				query = append(append([]Parameter{}, query...), Parameter{Name: auth.Key, Value: auth.Value})
+			case "cookie":
+				request.Cookies = append(request.Cookies, HARNameValue{Name: auth.Key, Value: auth.Value})
+			default:
+				request.Headers = append(request.Headers, HARNameValue{Name: auth.Key, Value: auth.Value})
+			}
+		case "oauth2":
+			request.Headers = append(request.Headers, HARNameValue{Name: "Authorization", Value: "Bearer {{access_token}}"})
+		}
+	}
+
+	for _, param := range query {
+		if !param.Disabled {
+			request.QueryString = append(request.QueryString, HARNameValue{Name: param.Name, Value: param.Value})
+		}
+	}
+
+	request.URL = strings.Replace(exampleURL(operation), "{{base_url}}", baseURL, 1)
+	if q := queryString(query, true); q != "" {
+		request.URL += "?" + q
+	}
+
+	if body := operation.Body; body != nil {
+		request.PostData = &HARPostData{MimeType: body.MimeType, Text: body.Text}
+		for _, param := range body.Params {
+			request.PostData.Params = append(request.PostData.Params, HARNameValue{Name: param.Name, Value: param.Value})
+		}
+		if body.MimeType == "application/x-www-form-urlencoded" {
+			request.PostData.Text = httpBody(body)
+		}
+		request.BodySize = len(request.PostData.Text)
+	}
+
+	return request
+}
@@ -0,0 +1,54 @@
+package insomnia
+
+import (
+	"testing"
+)
+
+func TestBuildHAR(t *testing.T) {
+	g := NewDeterministicGenerator()
+	api, err := g.ParseOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	har := g.BuildHAR(api)
+
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 4 {
+		t.Fatalf("Test failed. Expected a HAR 1.2 log with 4 entries, got %v", har.Log)
+	}
+
+	var users *HARRequest
+	for i, entry := range har.Log.Entries {
+		if entry.Comment == "users: Get all users" {
+			users = &har.Log.Entries[i].Request
+		}
+	}
+	if users == nil {
+		t.Fatalf("Test failed. Expected an entry for Get all users")
+	}
+	if users.URL != "http://localhost:8082/api/v1/users?status=active&status=pending" {
+		t.Errorf("Test failed. Expected absolute URL with query string, got %s", users.URL)
+	}
+	if len(users.QueryString) != 2 || users.QueryString[0].Value != "active" {
+		t.Errorf("Test failed. Expected enabled query parameters, got %v", users.QueryString)
+	}
+}
+
+func TestBuildHARAuthentication(t *testing.T) {
+	g := NewGenerator()
+	api, err := g.ParseOpenAPI([]byte(securitySpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	for _, entry := range g.BuildHAR(api).Log.Entries {
+		if entry.Comment != "default: List orders" {
+			continue
+		}
+		headers := entry.Request.Headers
+		if len(headers) != 1 || headers[0].Name != "Authorization" || headers[0].Value != "Bearer {{bearer_token}}" {
+			t.Errorf("Test failed. Expected bearer token placeholder, got %v", headers)
+		}
+		return
+	}
+	t.Errorf("Test failed. Expected an entry for List orders")
+}
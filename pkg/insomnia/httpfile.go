@@ -0,0 +1,194 @@
+package insomnia
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// httpEnvironmentFile is the environment file shared by the JetBrains HTTP client and
+// the VS Code REST Client
+const httpEnvironmentFile = "http-client.env.json"
+
+// httpPrivateEnvironmentFile holds the variables of private environments, and is meant
+// to stay out of version control
+const httpPrivateEnvironmentFile = "http-client.private.env.json"
+
+// multipartBoundary separates the fields of generated multipart bodies
+const multipartBoundary = "boundary"
+
+// GenerateHTTPToDir writes .http request files for an OpenAPI file to dir: one directory
+// per tag holding one .http file per operation, and an http-client.env.json (plus an
+// http-client.private.env.json for private environments)
+func (g *Generator) GenerateHTTPToDir(openAPIFile, dir string) error {
+	api, err := g.ParseFile(openAPIFile)
+	if err != nil {
+		return fmt.Errorf("failed to generate HTTP files: %w", err)
+	}
+
+	_, err = writeFiles(dir, g.BuildHTTPFiles(api))
+	return err
+}
+
+// BuildHTTPFiles converts the API model to .http request files, keyed by their path
+// relative to the output directory
+func (g *Generator) BuildHTTPFiles(api *API) map[string]string {
+	files := make(map[string]string)
+	httpFolders(files, "", api.Folders)
+
+	oauth := false
+	walkOperations(api.Folders, nil, func(_ []string, operation Operation) {
+		if operation.Auth != nil && operation.Auth.Type == "oauth2" {
+			oauth = true
+		}
+	})
+
+	environments := make(map[string]map[string]string)
+	private := make(map[string]map[string]string)
+	for _, server := range environmentServers(api) {
+		variables := map[string]string{"base_url": server.URL()}
+		if server.Private && len(server.Variables) > 0 {
+			private[server.Name] = server.Variables
+		} else {
+			for name, value := range server.Variables {
+				variables[name] = value
+			}
+		}
+		for name, value := range api.Variables {
+			variables[name] = value
+		}
+		if oauth {
+			variables["access_token"] = ""
+		}
+		environments[server.Name] = variables
+	}
+	files[httpEnvironmentFile] = renderJSON(environments) + "\n"
+	if len(private) > 0 {
+		files[httpPrivateEnvironmentFile] = renderJSON(private) + "\n"
+	}
+
+	return files
+}
+
+// httpFolders adds the .http files of a folder tree to files, one directory per folder
+func httpFolders(files map[string]string, parent string, folders []Folder) {
+	for _, folder := range folders {
+		dir := parent + fileName(folder.Name)
+		httpFolders(files, dir+"/", folder.Folders)
+		for i, name := range operationFileNames(folder, ".http") {
+			files[dir+"/"+name] = httpRequest(folder.Operations[i])
+		}
+	}
+}
+
+// httpRequest renders the .http file of an operation
+func httpRequest(operation Operation) string {
+	var builder strings.Builder
+
+	// Path parameters are declared as variables of the file so they can be edited in place
+	for _, param := range operation.PathParameters {
+		fmt.Fprintf(&builder, "@%s = %s\n", param.Name, param.Value)
+	}
+	if len(operation.PathParameters) > 0 {
+		builder.WriteString("\n")
+	}
+
+	fmt.Fprintf(&builder, "### %s\n", operation.Name)
+	for _, line := range strings.Split(strings.TrimSpace(operation.Description), "\n") {
+		if line != "" {
+			fmt.Fprintf(&builder, "# %s\n", line)
+		}
+	}
+	for _, param := range operation.Query {
+		if param.Disabled {
+			fmt.Fprintf(&builder, "# Optional query parameter: %s=%s\n", param.Name, param.Value)
+		}
+	}
+	for _, header := range operation.Headers {
+		if header.Disabled {
+			fmt.Fprintf(&builder, "# Optional header: %s: %s\n", header.Name, header.Value)
+		}
+	}
+
+	query := operation.Query
+	headers := httpHeaders(operation.Headers, operation.Body)
+	if auth := operation.Auth; auth != nil {
+		switch auth.Type {
+		case "bearer":
+			headers = append(headers, "Authorization: Bearer "+auth.Token)
+		case "basic":
+			headers = append(headers, fmt.Sprintf("Authorization: Basic %s %s", auth.Username, auth.Password))
+		case "digest":
+			headers = append(headers, fmt.Sprintf("Authorization: Digest %s %s", auth.Username, auth.Password))
+		case "apikey":
+			switch auth.In {
+			case "query":
+				query = append(append([]Parameter{}, query...), Parameter{Name: auth.Key, Value: auth.Value})
+			case "cookie":
+				headers = append(headers, fmt.Sprintf("Cookie: %s=%s", auth.Key, auth.Value))
+			default:
+				headers = append(headers, fmt.Sprintf("%s: %s", auth.Key, auth.Value))
+			}
+		case "oauth2":
+			fmt.Fprintf(&builder, "# OAuth 2.0 (%s): set access_token to a token from %s\n", auth.GrantType, auth.AccessTokenURL)
+			headers = append(headers, "Authorization: Bearer {{access_token}}")
+		}
+	}
+
+	requestURL := operation.URL
+	if q := queryString(query, true); q != "" {
+		requestURL += "?" + q
+	}
+	fmt.Fprintf(&builder, "%s %s\n", operation.Method, requestURL)
+	for _, header := range headers {
+		builder.WriteString(header + "\n")
+	}
+
+	if body := httpBody(operation.Body); body != "" {
+		builder.WriteString("\n" + body + "\n")
+	}
+
+	return builder.String()
+}
+
+// httpHeaders renders the enabled headers of a request, adding the boundary of multipart bodies
+func httpHeaders(params []Parameter, body *Body) []string {
+	var headers []string
+	for _, header := range params {
+		if header.Disabled {
+			continue
+		}
+
+		value := header.Value
+		if body != nil && body.MimeType == "multipart/form-data" && strings.EqualFold(header.Name, "Content-Type") {
+			value += "; boundary=" + multipartBoundary
+		}
+		headers = append(headers, fmt.Sprintf("%s: %s", header.Name, value))
+	}
+	return headers
+}
+
+// httpBody renders the payload of a request
+func httpBody(body *Body) string {
+	if body == nil {
+		return ""
+	}
+
+	switch body.MimeType {
+	case "application/x-www-form-urlencoded":
+		fields := make([]string, 0, len(body.Params))
+		for _, param := range body.Params {
+			fields = append(fields, url.QueryEscape(param.Name)+"="+url.QueryEscape(param.Value))
+		}
+		return strings.Join(fields, "&")
+	case "multipart/form-data":
+		var builder strings.Builder
+		for _, param := range body.Params {
+			fmt.Fprintf(&builder, "--%s\nContent-Disposition: form-data; name=%q\n\n%s\n", multipartBoundary, param.Name, param.Value)
+		}
+		fmt.Fprintf(&builder, "--%s--", multipartBoundary)
+		return builder.String()
+	}
+
+	return body.Text
+}
@@ -0,0 +1,61 @@
+package insomnia
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBuildHTTPFiles(t *testing.T) {
+	g := NewDeterministicGenerator()
+	api, err := g.ParseOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	files := g.BuildHTTPFiles(api)
+
+	users, ok := files["users/Get all users.http"]
+	if !ok {
+		t.Fatalf("Test failed. Expected one file per operation in its tag directory, got %v", files)
+	}
+	expected := "### Get all users\n" +
+		"# Optional query parameter: limit=10\n" +
+		"# Optional header: X-Request-ID: abc-123\n" +
+		"GET {{base_url}}/users?status=active&status=pending\n" +
+		"X-Tenant: acme\n"
+	if users != expected {
+		t.Errorf("Test failed. Expected:\n%s\ngot:\n%s", expected, users)
+	}
+
+	address := files["addresses/Create customer address.http"]
+	if !strings.HasPrefix(address, "@customerId = \n\n### Create customer address\n") {
+		t.Errorf("Test failed. Expected path parameter file variable, got:\n%s", address)
+	}
+	if !strings.Contains(address, "POST {{base_url}}/customers/{{customerId}}/address\nContent-Type: application/json\n\n{") {
+		t.Errorf("Test failed. Expected request line, headers and body, got:\n%s", address)
+	}
+
+	var environments map[string]map[string]string
+	if err := json.Unmarshal([]byte(files[httpEnvironmentFile]), &environments); err != nil {
+		t.Fatalf("Test failed. Environment file is not valid JSON: %v", err)
+	}
+	if environments["OpenAPI env localhost:8082"]["base_url"] != "http://localhost:8082/api/v1" {
+		t.Errorf("Test failed. Expected base_url per server, got %v", environments)
+	}
+}
+
+func TestBuildHTTPFilesAuthentication(t *testing.T) {
+	g := NewGenerator()
+	api, err := g.ParseOpenAPI([]byte(securitySpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	files := g.BuildHTTPFiles(api)
+
+	if order := files["default/Create order.http"]; !strings.Contains(order, "POST {{base_url}}/orders?key={{api_key}}\n") {
+		t.Errorf("Test failed. Expected API key in query string, got:\n%s", order)
+	}
+	if admin := files["default/Admin.http"]; !strings.Contains(admin, "Authorization: Basic {{basic_username}} {{basic_password}}\n") {
+		t.Errorf("Test failed. Expected basic authentication header, got:\n%s", admin)
+	}
+}
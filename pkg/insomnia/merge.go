@@ -0,0 +1,712 @@
+package insomnia
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RemovedFolderName is the folder that requests whose operation vanished from the spec are moved to
+const RemovedFolderName = "Removed"
+
+// baseURLPrefix is the template variable every generated request URL starts with
+const baseURLPrefix = "{{ _.base_url }}"
+
+// templateVariablePattern matches Insomnia template variables such as {{ _.customerId }}
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*_\.([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// UpdateFromOpenAPI generates a workspace from an OpenAPI spec and merges it into an
+// existing one. Folders and requests are matched by tag and by ID, operationId or
+// method + path; matches keep their IDs and user-edited fields. Generated requests whose
+// operation vanished are moved to the "Removed" folder, or dropped when prune is set;
+// folders and requests created by hand stay where they are.
+func (g *Generator) UpdateFromOpenAPI(openAPIData []byte, existing *InsomniaSpec, prune bool) (*InsomniaSpec, error) {
+	api, err := g.ParseOpenAPI(openAPIData)
+	if err != nil {
+		return nil, err
+	}
+
+	last := g.lastGenerated(existing, "")
+	return g.mergeWorkspace(existing, g.BuildInsomnia(api), last, api.Title, prune), nil
+}
+
+// UpdateToFile regenerates an Insomnia file from an OpenAPI file, merging into the
+// existing Insomnia file when there is one
+func (g *Generator) UpdateToFile(openAPIFile, insomniaFile string, prune bool) error {
+	if _, err := os.Stat(insomniaFile); os.IsNotExist(err) {
+		return g.GenerateToFile(openAPIFile, insomniaFile)
+	}
+
+	data, err := os.ReadFile(insomniaFile)
+	if err != nil {
+		return fmt.Errorf("failed to read Insomnia file: %w", err)
+	}
+	existing, err := ParseInsomnia(data)
+	if err != nil {
+		return err
+	}
+
+	api, err := g.ParseFile(openAPIFile)
+	if err != nil {
+		return fmt.Errorf("failed to update Insomnia spec: %w", err)
+	}
+	last := g.lastGenerated(existing, openAPIFile)
+	insomniaSpec := g.mergeWorkspace(existing, g.BuildInsomnia(api), last, api.Title, prune)
+
+	yamlData, err := yaml.Marshal(insomniaSpec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Insomnia spec: %w", err)
+	}
+
+	_, err = writeFileIfChanged(insomniaFile, yamlData, 0644)
+	return err
+}
+
+// lastGenerated regenerates the collection of an existing workspace from the spec it
+// embeds, read from source (empty when unknown), to tell the values edited in Insomnia
+// from the generated ones. It is nil when the workspace embeds no spec that parses.
+func (g *Generator) lastGenerated(existing *InsomniaSpec, source string) []CollectionItem {
+	if existing.Spec.Contents == nil {
+		return nil
+	}
+	openAPIData, err := yaml.Marshal(existing.Spec.Contents)
+	if err != nil {
+		return nil
+	}
+	api, err := g.parse(openAPIData, source)
+	if err != nil {
+		return nil
+	}
+	return g.generateCollection(api)
+}
+
+// mergeWorkspace merges a freshly generated workspace into an existing one, generated
+// from the API of the given title. last is the collection the existing one was generated
+// as, when known.
+func (g *Generator) mergeWorkspace(existing, generated *InsomniaSpec, last []CollectionItem, title string, prune bool) *InsomniaSpec {
+	merged := *generated
+	merged.Meta = keepIdentity(generated.Meta, existing.Meta)
+
+	merged.CookieJar = existing.CookieJar
+	if merged.CookieJar.Meta.ID == "" {
+		merged.CookieJar = generated.CookieJar
+	}
+
+	merged.Spec.Meta = keepIdentity(generated.Spec.Meta, existing.Spec.Meta)
+	merged.Environments = mergeEnvironments(existing.Environments, generated.Environments)
+	merged.Collection = g.mergeCollection(generated.Name, title, existing.Collection, last, generated.Collection, prune)
+
+	return &merged
+}
+
+// mergeCollection matches existing folders and requests against the generated ones, of
+// the workspace named name generated from the API of the given title. last is the
+// collection the existing one was generated as, when known.
+func (g *Generator) mergeCollection(name, title string, existing, last, generated []CollectionItem, prune bool) []CollectionItem {
+	index := &collectionIndex{
+		title:                 title,
+		folders:               make(map[string]CollectionItem),
+		foldersByName:         make(map[string]string),
+		generated:             make(map[string]bool),
+		requestsByID:          make(map[string]RequestItem),
+		requestsByOperationID: make(map[string]RequestItem),
+		requestsByKey:         make(map[string]RequestItem),
+		matched:               make(map[string]bool),
+		quarantined:           make(map[string]bool),
+		usedFolders:           make(map[string]bool),
+		lastGenerated:         make(map[string]RequestItem),
+	}
+	index.add(existing, nil)
+	index.addLastGenerated(last)
+	index.addGenerated(generated, nil)
+
+	// Folders created by hand follow the generated ones
+	collection := index.merge(generated, nil)
+	collection = append(collection, index.keepFolders(existing, nil)...)
+	if prune {
+		return collection
+	}
+
+	var removed []RequestItem
+	for _, request := range index.ordered {
+		if !index.matched[request.Meta.ID] && index.isGenerated(request) {
+			removed = append(removed, request)
+		}
+	}
+
+	if len(removed) > 0 {
+		folder, ok := index.folders[RemovedFolderName]
+		if !ok {
+			folder = CollectionItem{
+				Name: RemovedFolderName,
+				Meta: Meta{
+					ID:          g.generateFolderID(name, RemovedFolderName),
+					Created:     g.timestamp + 5,
+					Modified:    g.timestamp + 5,
+					Description: "Requests whose operations are no longer in the OpenAPI spec",
+				},
+			}
+		}
+		folder.Folders = nil
+		folder.Children = removed
+		collection = append(collection, folder)
+	}
+
+	return collection
+}
+
+// collectionIndex indexes the folders and requests of an existing collection
+type collectionIndex struct {
+	title                 string                    // title of the API the collection is generated from
+	folders               map[string]CollectionItem // by the names of the folders leading to them
+	foldersByName         map[string]string         // paths of folders with a unique name
+	generated             map[string]bool           // paths of the generated folders
+	requestsByID          map[string]RequestItem
+	requestsByOperationID map[string]RequestItem
+	requestsByKey         map[string]RequestItem
+	ordered               []RequestItem
+	matched               map[string]bool
+	quarantined           map[string]bool        // requests of the Removed folder
+	usedFolders           map[string]bool        // paths of the folders matched by generated ones
+	lastGenerated         map[string]RequestItem // requests as last generated, by existing ID
+}
+
+// add indexes a folder tree
+func (x *collectionIndex) add(folders []CollectionItem, parents []string) {
+	for _, folder := range folders {
+		path := append(append([]string{}, parents...), folder.Name)
+		key := strings.Join(path, "/")
+		x.folders[key] = folder
+		if _, ok := x.foldersByName[folder.Name]; ok {
+			x.foldersByName[folder.Name] = ""
+		} else {
+			x.foldersByName[folder.Name] = key
+		}
+
+		x.add(folder.Folders, path)
+		for _, request := range folder.Children {
+			if key == RemovedFolderName {
+				x.quarantined[request.Meta.ID] = true
+			}
+			x.ordered = append(x.ordered, request)
+			x.requestsByID[request.Meta.ID] = request
+			if operationID, _ := markedOperationID(request.Meta.Description); operationID != "" {
+				x.requestsByOperationID[operationID] = request
+			}
+			x.requestsByKey[requestKey(request)] = request
+		}
+	}
+}
+
+// addLastGenerated records the requests of the collection the indexed one was generated
+// as, by the ID of the existing request each one matches
+func (x *collectionIndex) addLastGenerated(folders []CollectionItem) {
+	for _, folder := range folders {
+		x.addLastGenerated(folder.Folders)
+		for _, request := range folder.Children {
+			if previous, ok := x.find(request); ok {
+				x.lastGenerated[previous.Meta.ID] = request
+			}
+		}
+	}
+}
+
+// find returns the indexed request matching a generated one, by ID, then by the
+// operationId recorded in its description, then by method and path
+func (x *collectionIndex) find(request RequestItem) (RequestItem, bool) {
+	previous, ok := x.requestsByID[request.Meta.ID]
+	if operationID, _ := markedOperationID(request.Meta.Description); !ok && operationID != "" {
+		previous, ok = x.requestsByOperationID[operationID]
+	}
+	if !ok {
+		previous, ok = x.requestsByKey[requestKey(request)]
+	}
+	return previous, ok
+}
+
+// addGenerated records the paths of the folders of a generated tree
+func (x *collectionIndex) addGenerated(folders []CollectionItem, parents []string) {
+	for _, folder := range folders {
+		path := append(append([]string{}, parents...), folder.Name)
+		x.generated[strings.Join(path, "/")] = true
+		x.addGenerated(folder.Folders, path)
+	}
+}
+
+// merge merges a generated folder tree into the indexed one. Folders are matched by
+// their path, or by their unique name when they moved, e.g. into an x-tagGroups group;
+// requests by ID, then by the operationId recorded in their description, then by method
+// and path.
+func (x *collectionIndex) merge(generated []CollectionItem, parents []string) []CollectionItem {
+	collection := make([]CollectionItem, 0, len(generated)+1)
+
+	for _, folder := range generated {
+		path := append(append([]string{}, parents...), folder.Name)
+		previousKey := strings.Join(path, "/")
+		previous, ok := x.folders[previousKey]
+		if moved := x.foldersByName[folder.Name]; !ok && moved != "" && !x.generated[moved] {
+			previousKey = moved
+			previous, ok = x.folders[moved]
+		}
+		if ok {
+			x.usedFolders[previousKey] = true
+			folder.Meta = keepIdentity(folder.Meta, previous.Meta)
+			folder.Extra = previous.Extra
+		}
+
+		folder.Folders = x.merge(folder.Folders, path)
+
+		children := make([]RequestItem, 0, len(folder.Children))
+		for _, request := range folder.Children {
+			if previous, ok := x.find(request); ok && !x.matched[previous.Meta.ID] {
+				x.matched[previous.Meta.ID] = true
+				var last *RequestItem
+				if request, ok := x.lastGenerated[previous.Meta.ID]; ok {
+					last = &request
+				}
+				request = mergeRequest(previous, last, request)
+			}
+			children = append(children, request)
+		}
+		folder.Children = children
+
+		// Requests and sub-folders created by hand in the folder stay in it
+		if ok {
+			folder.Folders = append(folder.Folders, x.keepFolders(previous.Folders, strings.Split(previousKey, "/"))...)
+			folder.Children = append(folder.Children, x.handMade(previous.Children)...)
+		}
+
+		collection = append(collection, folder)
+	}
+
+	return collection
+}
+
+// keepFolders returns the existing folders no generated folder matched, with the requests
+// created by hand in them. Generated folders left empty are dropped.
+func (x *collectionIndex) keepFolders(folders []CollectionItem, parents []string) []CollectionItem {
+	var kept []CollectionItem
+	for _, folder := range folders {
+		path := append(append([]string{}, parents...), folder.Name)
+		key := strings.Join(path, "/")
+		if x.usedFolders[key] || key == RemovedFolderName {
+			continue
+		}
+
+		folder.Folders = x.keepFolders(folder.Folders, path)
+		folder.Children = x.handMade(folder.Children)
+		if len(folder.Folders) == 0 && len(folder.Children) == 0 && folder.Meta.ID == deterministicID("fld", x.title, key) {
+			continue
+		}
+		kept = append(kept, folder)
+	}
+	return kept
+}
+
+// handMade returns the requests created by hand among existing requests
+func (x *collectionIndex) handMade(requests []RequestItem) []RequestItem {
+	var kept []RequestItem
+	for _, request := range requests {
+		if !x.matched[request.Meta.ID] && !x.isGenerated(request) {
+			kept = append(kept, request)
+		}
+	}
+	return kept
+}
+
+// isGenerated reports whether an existing request was generated: it records the
+// operationId of its operation, carries the ID derived from its method and path, or was
+// moved to the Removed folder. Other requests were created by hand.
+func (x *collectionIndex) isGenerated(request RequestItem) bool {
+	if operationID, _ := markedOperationID(request.Meta.Description); operationID != "" {
+		return true
+	}
+	return x.quarantined[request.Meta.ID] || request.Meta.ID == deterministicID("req", x.title, requestKey(request))
+}
+
+// mergeRequest applies the spec-owned fields of a generated request to an existing
+// request, keeping its identity and the fields a developer may have edited. last is the
+// request as last generated, when known.
+func mergeRequest(existing RequestItem, last *RequestItem, generated RequestItem) RequestItem {
+	merged := generated
+	merged.Meta = keepIdentity(generated.Meta, existing.Meta)
+	merged.Settings = existing.Settings
+	merged.Extra = existing.Extra
+
+	merged.Body = mergeBody(existing.Body, last, generated.Body)
+	merged.Authentication = mergeAuthentication(existing.Authentication, last, generated.Authentication)
+	if existing.Scripts != nil && existing.Scripts.PreRequest != "" {
+		scripts := RequestScripts{PreRequest: existing.Scripts.PreRequest}
+		if merged.Scripts != nil {
+			scripts.AfterResponse = merged.Scripts.AfterResponse
+		}
+		merged.Scripts = &scripts
+	}
+
+	var lastParameters, lastHeaders []RequestParameter
+	if last != nil {
+		lastParameters, lastHeaders = last.Parameters, headerParameters(last.Headers)
+	}
+	merged.Parameters = mergeParameters(existing.Parameters, lastParameters, generated.Parameters, last != nil, false)
+	headers := mergeParameters(headerParameters(existing.Headers), lastHeaders, headerParameters(generated.Headers), last != nil, true)
+	merged.Headers = nil
+	for _, header := range headers {
+		merged.Headers = append(merged.Headers, RequestHeader(header))
+	}
+
+	return merged
+}
+
+// mergeParameters merges the query parameters, or headers when foldCase is set, of a
+// request as mergeBody does with bodies, matching them by name: parameters edited in
+// Insomnia are kept, the others follow the spec, which adds, changes and removes them,
+// and parameters added by hand are kept. Without the parameters as last generated
+// (known unset), existing parameters are kept and the spec's new ones added.
+func mergeParameters(existing, last, generated []RequestParameter, known, foldCase bool) []RequestParameter {
+	var merged []RequestParameter
+	if !known {
+		merged = append(merged, existing...)
+		for _, param := range generated {
+			if indexParameter(existing, param.Name, foldCase) < 0 {
+				merged = append(merged, param)
+			}
+		}
+		return merged
+	}
+
+	for _, param := range generated {
+		i, j := indexParameter(existing, param.Name, foldCase), indexParameter(last, param.Name, foldCase)
+		switch {
+		case i >= 0 && (j < 0 || existing[i] != last[j]):
+			merged = append(merged, existing[i])
+		case i < 0 && j >= 0 && last[j] == param:
+			// Deleted in Insomnia and unchanged in the spec
+		default:
+			merged = append(merged, param)
+		}
+	}
+
+	for _, param := range existing {
+		if indexParameter(generated, param.Name, foldCase) >= 0 {
+			continue
+		}
+		// Parameters removed from the spec go unless edited in Insomnia
+		if j := indexParameter(last, param.Name, foldCase); j >= 0 && last[j] == param {
+			continue
+		}
+		merged = append(merged, param)
+	}
+	return merged
+}
+
+// indexParameter returns the index of the parameter with the given name, ignoring case
+// when foldCase is set, or -1 when there is none
+func indexParameter(params []RequestParameter, name string, foldCase bool) int {
+	for i, param := range params {
+		if param.Name == name || (foldCase && strings.EqualFold(param.Name, name)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// headerParameters converts headers to parameters, which have the same fields
+func headerParameters(headers []RequestHeader) []RequestParameter {
+	var params []RequestParameter
+	for _, header := range headers {
+		params = append(params, RequestParameter(header))
+	}
+	return params
+}
+
+// mergeBody keeps a body edited in Insomnia, one that differs from the body last
+// generated, while the spec's body is unchanged. When it changed, the spec's media type
+// and structure win and the edited values are merged into them. Without the request as
+// last generated, a body of the generated media type is kept.
+func mergeBody(existing *RequestBody, last *RequestItem, generated *RequestBody) *RequestBody {
+	switch {
+	case isEmptyBody(existing):
+		return generated
+	case last == nil:
+		if generated == nil || generated.MimeType == existing.MimeType {
+			return existing
+		}
+	case sameBody(existing, last.Body):
+		return generated
+	case sameBody(last.Body, generated):
+		return existing
+	}
+
+	if generated == nil {
+		return nil
+	}
+	edited, ok := bodyValue(existing)
+	if !ok {
+		return generated
+	}
+
+	merged := *generated
+	switch {
+	case len(generated.Params) > 0:
+		fields, _ := edited.(map[string]interface{})
+		merged.Params = make([]BodyParam, len(generated.Params))
+		for i, param := range generated.Params {
+			if value, ok := fields[param.Name]; ok {
+				param.Value = scalarString(value)
+			}
+			merged.Params[i] = param
+		}
+	case isJSONMediaType(generated.MimeType):
+		if value, ok := bodyValue(generated); ok {
+			merged.Text = renderJSON(mergeValues(edited, value))
+		}
+	}
+	return &merged
+}
+
+// isEmptyBody reports whether a request sends no body
+func isEmptyBody(body *RequestBody) bool {
+	return body == nil || (body.Text == "" && len(body.Params) == 0)
+}
+
+// sameBody reports whether two request bodies are alike
+func sameBody(a, b *RequestBody) bool {
+	if isEmptyBody(a) || isEmptyBody(b) {
+		return isEmptyBody(a) && isEmptyBody(b)
+	}
+	if a.MimeType != b.MimeType || a.Text != b.Text || len(a.Params) != len(b.Params) {
+		return false
+	}
+	for i := range a.Params {
+		if a.Params[i] != b.Params[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// bodyValue decodes a JSON body, or the fields of a form body
+func bodyValue(body *RequestBody) (interface{}, bool) {
+	if len(body.Params) > 0 {
+		fields := make(map[string]interface{}, len(body.Params))
+		for _, param := range body.Params {
+			fields[param.Name] = param.Value
+		}
+		return fields, true
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(body.Text), &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// mergeValues merges edited values into the structure of a generated value: properties
+// take the edited value of the same name and arrays the edited items, while scalars keep
+// the edited value when it is of the same type
+func mergeValues(edited, generated interface{}) interface{} {
+	switch value := generated.(type) {
+	case map[string]interface{}:
+		fields, ok := edited.(map[string]interface{})
+		if !ok {
+			return generated
+		}
+		merged := make(map[string]interface{}, len(value))
+		for name, field := range value {
+			merged[name] = field
+			if editedField, ok := fields[name]; ok {
+				merged[name] = mergeValues(editedField, field)
+			}
+		}
+		return merged
+	case []interface{}:
+		items, ok := edited.([]interface{})
+		if !ok || len(value) == 0 {
+			return generated
+		}
+		merged := make([]interface{}, len(items))
+		for i, item := range items {
+			merged[i] = mergeValues(item, value[0])
+		}
+		return merged
+	case nil:
+		return edited
+	}
+
+	if fmt.Sprintf("%T", edited) == fmt.Sprintf("%T", generated) {
+		return edited
+	}
+	return generated
+}
+
+// mergeAuthentication keeps authentication edited in Insomnia while the spec's is
+// unchanged, as mergeBody does with bodies. When it changed, the edited credentials are
+// kept if the spec's authentication is of the same type.
+func mergeAuthentication(existing *RequestAuthentication, last *RequestItem, generated *RequestAuthentication) *RequestAuthentication {
+	switch {
+	case existing == nil:
+		return generated
+	case last == nil:
+		if generated == nil || generated.Type == existing.Type {
+			return existing
+		}
+	case sameAuthentication(existing, last.Authentication):
+		return generated
+	case sameAuthentication(last.Authentication, generated):
+		return existing
+	}
+
+	if generated == nil || generated.Type != existing.Type {
+		return generated
+	}
+	merged := *generated
+	merged.Disabled = existing.Disabled
+	merged.Token = existing.Token
+	merged.Username = existing.Username
+	merged.Password = existing.Password
+	merged.Value = existing.Value
+	merged.ClientID = existing.ClientID
+	merged.ClientSecret = existing.ClientSecret
+	return &merged
+}
+
+// sameAuthentication reports whether two request authentications are alike
+func sameAuthentication(a, b *RequestAuthentication) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// mergeEnvironments keeps environment IDs and user-edited values, adding new
+// variables and sub-environments from the generated environment
+func mergeEnvironments(existing, generated Environment) Environment {
+	if existing.Meta.ID == "" {
+		return generated
+	}
+
+	merged := generated
+	merged.Meta = keepIdentity(generated.Meta, existing.Meta)
+	if existing.Data.BaseURL != "" {
+		merged.Data.BaseURL = existing.Data.BaseURL
+	}
+	merged.Data.Extra = mergeVariables(existing.Data.Extra, generated.Data.Extra)
+
+	subEnvironments := append([]SubEnvironment{}, generated.SubEnvironments...)
+	done := make(map[int]bool)    // generated sub-environments merged
+	matched := make(map[int]bool) // existing sub-environments merged
+	for _, matcher := range subEnvironmentMatchers {
+		for i, subEnv := range generated.SubEnvironments {
+			if done[i] {
+				continue
+			}
+			for j, previous := range existing.SubEnvironments {
+				if matched[j] || !matcher.match(previous, subEnv) {
+					continue
+				}
+				done[i], matched[j] = true, true
+				subEnvironments[i] = mergeSubEnvironment(previous, subEnv, matcher.keepName)
+				break
+			}
+		}
+	}
+
+	// Sub-environments created by hand are kept as they are
+	for j, previous := range existing.SubEnvironments {
+		if !matched[j] {
+			subEnvironments = append(subEnvironments, previous)
+		}
+	}
+	merged.SubEnvironments = subEnvironments
+
+	return merged
+}
+
+// subEnvironmentMatcher matches an existing sub-environment to a generated one
+type subEnvironmentMatcher struct {
+	match func(existing, generated SubEnvironment) bool
+	// keepName keeps the name of the existing sub-environment, which a developer may have
+	// renamed; otherwise the generator renamed it
+	keepName bool
+}
+
+// subEnvironmentMatchers match sub-environments by ID, then by name, then by server URL,
+// which finds them again when the generator names environments differently, such as after
+// a server gained a description
+var subEnvironmentMatchers = []subEnvironmentMatcher{
+	{match: func(existing, generated SubEnvironment) bool { return existing.Meta.ID == generated.Meta.ID }, keepName: true},
+	{match: func(existing, generated SubEnvironment) bool { return existing.Name == generated.Name }, keepName: true},
+	{match: sameServer},
+}
+
+// sameServer reports whether two sub-environments point to the same server URL
+func sameServer(existing, generated SubEnvironment) bool {
+	return existing.Data.Host != "" &&
+		strings.EqualFold(existing.Data.Scheme, generated.Data.Scheme) &&
+		strings.EqualFold(existing.Data.Host, generated.Data.Host) &&
+		strings.TrimSuffix(existing.Data.BasePath, "/") == strings.TrimSuffix(generated.Data.BasePath, "/")
+}
+
+// mergeSubEnvironment keeps the identity and edited values of an existing sub-environment,
+// and its name when keepName is set
+func mergeSubEnvironment(existing, generated SubEnvironment, keepName bool) SubEnvironment {
+	merged := generated
+	if keepName {
+		merged.Name = existing.Name
+	}
+	merged.Meta = keepIdentity(generated.Meta, existing.Meta)
+	merged.Meta.IsPrivate = existing.Meta.IsPrivate || generated.Meta.IsPrivate
+
+	if existing.Data.Scheme != "" {
+		merged.Data.Scheme = existing.Data.Scheme
+	}
+	if existing.Data.Host != "" {
+		merged.Data.Host = existing.Data.Host
+	}
+	if existing.Data.BasePath != "" {
+		merged.Data.BasePath = existing.Data.BasePath
+	}
+	merged.Data.Extra = mergeVariables(existing.Data.Extra, generated.Data.Extra)
+
+	return merged
+}
+
+// mergeVariables overlays existing environment variables onto generated ones
+func mergeVariables(existing, generated map[string]interface{}) map[string]interface{} {
+	if len(existing) == 0 {
+		return generated
+	}
+
+	merged := make(map[string]interface{}, len(existing)+len(generated))
+	for k, v := range generated {
+		merged[k] = v
+	}
+	for k, v := range existing {
+		merged[k] = v
+	}
+	return merged
+}
+
+// keepIdentity returns generated metadata carrying the ID and creation time of existing metadata
+func keepIdentity(generated, existing Meta) Meta {
+	if existing.ID == "" {
+		return generated
+	}
+
+	generated.ID = existing.ID
+	generated.Created = existing.Created
+	return generated
+}
+
+// requestKey identifies a request by method and OpenAPI path, recovering
+// {param} placeholders from the template variables of its URL
+func requestKey(request RequestItem) string {
+	path := strings.TrimPrefix(request.URL, baseURLPrefix)
+	path = templateVariablePattern.ReplaceAllString(path, "{$1}")
+	return fmt.Sprintf("%s %s", strings.ToUpper(request.Method), path)
+}
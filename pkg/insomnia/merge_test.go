@@ -0,0 +1,307 @@
+package insomnia
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const mergeSpec = `
+openapi: 3.0.3
+info:
+  title: Merge API
+  version: 1.0.0
+servers:
+  - url: http://localhost:8082/api/v1
+paths:
+  /users:
+    get:
+      summary: Get all users
+      tags:
+        - users
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            default: 10
+    post:
+      summary: Create user
+      tags:
+        - users
+      requestBody:
+        content:
+          application/json:
+            example:
+              name: John
+`
+
+func TestUpdatePreservesIDsAndEdits(t *testing.T) {
+	existing, err := NewGenerator().GenerateFromOpenAPI([]byte(mergeSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	folderID := existing.Collection[0].Meta.ID
+	getUsers := findRequest(existing, "Get all users")
+	getUsers.Parameters[0].Value = "50"
+	getUsers.Headers = append(getUsers.Headers, RequestHeader{Name: "X-Debug", Value: "1"})
+	getUsersID := getUsers.Meta.ID
+	createUser := findRequest(existing, "Create user")
+	createUser.Body.Text = `{"name": "Edited"}`
+	existing.Environments.Data.Extra = map[string]interface{}{"token": "secret"}
+
+	updatedSpec := strings.Replace(mergeSpec, "summary: Get all users", "summary: List users", 1)
+	merged, err := NewGenerator().UpdateFromOpenAPI([]byte(updatedSpec), existing, false)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if merged.Meta.ID != existing.Meta.ID {
+		t.Errorf("Test failed. Expected workspace ID %s, got %s", existing.Meta.ID, merged.Meta.ID)
+	}
+	if merged.Collection[0].Meta.ID != folderID {
+		t.Errorf("Test failed. Expected folder ID %s, got %s", folderID, merged.Collection[0].Meta.ID)
+	}
+
+	listUsers := findRequest(merged, "List users")
+	if listUsers == nil {
+		t.Fatalf("Test failed. Expected renamed request to be matched by method and path")
+	}
+	if listUsers.Meta.ID != getUsersID {
+		t.Errorf("Test failed. Expected request ID %s, got %s", getUsersID, listUsers.Meta.ID)
+	}
+	if listUsers.Parameters[0].Value != "50" {
+		t.Errorf("Test failed. Expected edited parameter value 50, got %s", listUsers.Parameters[0].Value)
+	}
+	if indexParameter(headerParameters(listUsers.Headers), "x-debug", true) < 0 {
+		t.Errorf("Test failed. Expected user-added header to be kept")
+	}
+	if body := findRequest(merged, "Create user").Body.Text; body != `{"name": "Edited"}` {
+		t.Errorf("Test failed. Expected edited body to be kept, got %s", body)
+	}
+	if merged.Environments.Data.Extra["token"] != "secret" {
+		t.Errorf("Test failed. Expected edited environment variable to be kept")
+	}
+}
+
+func TestUpdateQuarantinesRemovedOperations(t *testing.T) {
+	// Requests without an operationId are told apart from hand-made ones by their ID
+	existing, err := NewDeterministicGenerator().GenerateFromOpenAPI([]byte(mergeSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	createUserID := findRequest(existing, "Create user").Meta.ID
+
+	withoutPost := mergeSpec[:strings.Index(mergeSpec, "    post:")]
+
+	merged, err := NewGenerator().UpdateFromOpenAPI([]byte(withoutPost), existing, false)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	last := merged.Collection[len(merged.Collection)-1]
+	if last.Name != RemovedFolderName || len(last.Children) != 1 || last.Children[0].Meta.ID != createUserID {
+		t.Errorf("Test failed. Expected removed request in %s folder, got %v", RemovedFolderName, last)
+	}
+
+	pruned, err := NewGenerator().UpdateFromOpenAPI([]byte(withoutPost), existing, true)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if findRequest(pruned, "Create user") != nil {
+		t.Errorf("Test failed. Expected removed request to be pruned")
+	}
+}
+
+func TestUpdateAppliesChangedRequestBodies(t *testing.T) {
+	existing, err := NewDeterministicGenerator().GenerateFromOpenAPI([]byte(mergeSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	unedited, err := NewDeterministicGenerator().GenerateFromOpenAPI([]byte(mergeSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	findRequest(existing, "Create user").Body.Text = `{"name": "Edited"}`
+
+	withEmail := strings.Replace(mergeSpec, "              name: John", "              name: John\n              email: john@example.com", 1)
+
+	merged, err := NewDeterministicGenerator().UpdateFromOpenAPI([]byte(withEmail), unedited, false)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	body := decodeBody(t, findRequest(merged, "Create user"))
+	if body["name"] != "John" || body["email"] != "john@example.com" {
+		t.Errorf("Test failed. Expected unedited body to follow the spec, got %v", body)
+	}
+
+	merged, err = NewDeterministicGenerator().UpdateFromOpenAPI([]byte(withEmail), existing, false)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	body = decodeBody(t, findRequest(merged, "Create user"))
+	if body["name"] != "Edited" || body["email"] != "john@example.com" {
+		t.Errorf("Test failed. Expected edited value merged into the new body, got %v", body)
+	}
+
+	asForm := strings.Replace(withEmail, "application/json", "application/x-www-form-urlencoded", 1)
+	merged, err = NewDeterministicGenerator().UpdateFromOpenAPI([]byte(asForm), existing, false)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	form := findRequest(merged, "Create user").Body
+	if form.MimeType != "application/x-www-form-urlencoded" {
+		t.Errorf("Test failed. Expected the spec's content type, got %s", form.MimeType)
+	}
+	expected := []BodyParam{{Name: "email", Value: "john@example.com"}, {Name: "name", Value: "Edited"}}
+	if !reflect.DeepEqual(form.Params, expected) {
+		t.Errorf("Test failed. Expected %v, got %v", expected, form.Params)
+	}
+}
+
+func TestUpdateAppliesChangedParameters(t *testing.T) {
+	spec := strings.Replace(mergeSpec, "            default: 10\n", `            default: 10
+        - name: sort
+          in: query
+          example: name
+        - name: fields
+          in: query
+          example: id
+`, 1)
+	existing, err := NewDeterministicGenerator().GenerateFromOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	getUsers := findRequest(existing, "Get all users")
+	getUsers.Parameters[2].Value = "id,name"
+	getUsers.Parameters = append(getUsers.Parameters, RequestParameter{Name: "debug", Value: "1"})
+
+	// limit changes its default, sort and fields are removed and page is added
+	updatedSpec := strings.Replace(mergeSpec, "            default: 10\n", `            default: 20
+        - name: page
+          in: query
+          example: 1
+`, 1)
+	merged, err := NewDeterministicGenerator().UpdateFromOpenAPI([]byte(updatedSpec), existing, false)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	expected := []RequestParameter{
+		{Name: "limit", Value: "20", Disabled: true},
+		{Name: "page", Value: "1", Disabled: true},
+		{Name: "fields", Value: "id,name", Disabled: true},
+		{Name: "debug", Value: "1"},
+	}
+	if params := findRequest(merged, "Get all users").Parameters; !reflect.DeepEqual(params, expected) {
+		t.Errorf("Test failed. Expected %v, got %v", expected, params)
+	}
+}
+
+func TestUpdateKeepsHandMadeRequestsAndFolders(t *testing.T) {
+	existing, err := NewDeterministicGenerator().GenerateFromOpenAPI([]byte(mergeSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	existing.Collection[0].Children = append(existing.Collection[0].Children, RequestItem{
+		Name:   "Users smoke test",
+		Meta:   Meta{ID: "req_handmade1"},
+		Method: "GET",
+		URL:    "{{ _.base_url }}/users?smoke=true",
+	})
+	existing.Collection = append(existing.Collection, CollectionItem{
+		Name: "Scratch",
+		Meta: Meta{ID: "fld_handmade"},
+		Children: []RequestItem{{
+			Name:   "Health",
+			Meta:   Meta{ID: "req_handmade2"},
+			Method: "GET",
+			URL:    "{{ _.base_url }}/health",
+		}},
+	})
+
+	withoutPost := mergeSpec[:strings.Index(mergeSpec, "    post:")]
+
+	for _, prune := range []bool{false, true} {
+		merged, err := NewDeterministicGenerator().UpdateFromOpenAPI([]byte(withoutPost), existing, prune)
+		if err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+
+		if request := findRequest(merged, "Users smoke test"); request == nil || request.Meta.ID != "req_handmade1" {
+			t.Errorf("Test failed. Expected hand-made request to stay in its folder (prune %v)", prune)
+		}
+		if request := findRequest(merged, "Health"); request == nil || request.Meta.ID != "req_handmade2" {
+			t.Errorf("Test failed. Expected hand-made folder to be kept (prune %v)", prune)
+		}
+		for _, folder := range merged.Collection {
+			if folder.Name != RemovedFolderName {
+				continue
+			}
+			if len(folder.Children) != 1 || folder.Children[0].Name != "Create user" {
+				t.Errorf("Test failed. Expected only the generated request in %s folder, got %v", RemovedFolderName, folder.Children)
+			}
+		}
+	}
+}
+
+func TestUpdateMatchesRenamedEnvironmentsByServer(t *testing.T) {
+	// A workspace generated when environments were named after their host
+	var existing InsomniaSpec
+	err := yaml.Unmarshal([]byte(`
+type: spec.insomnia.rest/5.0
+name: Merge API 1.0.0
+meta:
+    id: wrk_40d19e55fc174fb5a8585ae34f8f564f
+environments:
+    name: Base Environment
+    meta:
+        id: env_b80e364e9488b026ad6f2f58d668708e208ac00e
+    data:
+        base_url: '{{ _.scheme }}://{{ _.host }}{{ _.base_path }}'
+    subEnvironments:
+        - name: OpenAPI env localhost:8082
+          meta:
+            id: env_2e7aba19436fe239ab0e1c1aa15b83f8
+          data:
+            scheme: http
+            base_path: /api/v1
+            host: localhost:8082
+            token: secret
+        - name: My sandbox
+          meta:
+            id: env_hand_made
+          data:
+            scheme: https
+            base_path: /
+            host: sandbox.example.com
+`), &existing)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	described := strings.Replace(mergeSpec, "  - url: http://localhost:8082/api/v1",
+		"  - url: http://localhost:8082/api/v1\n    description: Local development server", 1)
+	merged, err := NewGenerator().UpdateFromOpenAPI([]byte(described), &existing, false)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	subEnvironments := merged.Environments.SubEnvironments
+	if len(subEnvironments) != 2 {
+		t.Fatalf("Test failed. Expected the renamed and the hand-made environments, got %v", subEnvironments)
+	}
+	renamed := subEnvironments[0]
+	if renamed.Name != "Local development server" || renamed.Meta.ID != "env_2e7aba19436fe239ab0e1c1aa15b83f8" {
+		t.Errorf("Test failed. Expected the environment to be renamed keeping its ID, got %s %s", renamed.Name, renamed.Meta.ID)
+	}
+	if renamed.Data.Extra["token"] != "secret" {
+		t.Errorf("Test failed. Expected the values of the environment to be kept, got %v", renamed.Data.Extra)
+	}
+	if subEnvironments[1].Name != "My sandbox" {
+		t.Errorf("Test failed. Expected the hand-made environment to be kept, got %s", subEnvironments[1].Name)
+	}
+}
@@ -0,0 +1,568 @@
+package insomnia
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// API is the format-neutral description of an OpenAPI document that the Insomnia and
+// Postman exporters are built from. Variable references use the {{name}} syntax and
+// are translated by each exporter.
+type API struct {
+	Title       string
+	Version     string
+	Description string
+	Folders     []Folder
+	Servers     []Server
+
+	// Variables holds the variables referenced by requests besides the server ones,
+	// such as credentials and the webhook receiver URL
+	Variables map[string]string
+
+	// Document is the original OpenAPI document, before normalization
+	Document interface{}
+
+	// Files are the absolute paths of the files the API was read from: the spec, unless
+	// parsed from data, the files its references load and the environment overlays
+	Files []string
+}
+
+// Folder groups operations, by tag or by path segment, and may hold sub-folders
+type Folder struct {
+	Name        string
+	Description string
+	Folders     []Folder
+	Operations  []Operation
+}
+
+// Operation describes a single API request
+type Operation struct {
+	// Key identifies the operation across versions of the spec: its operationId,
+	// or its method and path
+	Key         string
+	OperationID string
+	Name        string
+	Description string
+	Method      string
+	Path        string
+	Tags        []string
+
+	// URL is the request URL relative to {{base_url}}, or {{webhook_url}} for webhooks
+	URL     string
+	Webhook bool
+
+	PathParameters []Parameter
+	Query          []Parameter
+	Headers        []Parameter
+	Body           *Body
+	Auth           *Auth
+	Responses      []Response
+
+	// Deprecated marks operations declared deprecated; Extensions holds their x- extensions
+	Deprecated bool
+	Extensions map[string]interface{}
+}
+
+// Parameter is a name/value pair sent in the path, query string or headers.
+// Optional parameters are disabled.
+type Parameter struct {
+	Name     string
+	Value    string
+	Disabled bool
+}
+
+// Body is the payload of a request: Text for raw bodies, Params for form bodies
+type Body struct {
+	MimeType string
+	Text     string
+	Params   []BodyParam
+}
+
+// Response is a documented response of an operation. Status is a status code, a range
+// such as 4XX, or default; Schema is the JSON Schema of JSON bodies.
+type Response struct {
+	Status      string
+	Description string
+	MimeType    string
+	Example     string
+	Schema      map[string]interface{}
+}
+
+// Auth describes how a request authenticates. Type is one of bearer, basic, digest,
+// apikey or oauth2; secrets reference variables of the API.
+type Auth struct {
+	Type string
+
+	Token    string
+	Username string
+	Password string
+
+	// Key is sent in the header, query parameter or cookie named by In
+	Key   string
+	Value string
+	In    string
+
+	GrantType        string
+	AuthorizationURL string
+	AccessTokenURL   string
+	ClientID         string
+	ClientSecret     string
+	Scopes           []string
+}
+
+// Server is a server of the API with its variables substituted
+type Server struct {
+	// Key identifies the server across versions of the spec
+	Key       string
+	Name      string
+	Scheme    string
+	Host      string
+	BasePath  string
+	Variables map[string]string
+
+	// Private servers hold secrets: their environments are not synced or shared
+	Private bool
+}
+
+// URL returns the base URL of the server
+func (s Server) URL() string {
+	return fmt.Sprintf("%s://%s%s", s.Scheme, s.Host, s.BasePath)
+}
+
+// Filter returns a copy of the API keeping the operations tagged with one of tags and
+// whose operationId is one of operationIDs. An empty list does not filter, and folders
+// left without operations are dropped.
+func (api *API) Filter(tags, operationIDs []string) *API {
+	filtered := *api
+	filtered.Folders = filterFolders(api.Folders, tags, operationIDs)
+	return &filtered
+}
+
+// filterFolders filters the operations of a folder tree, dropping the folders left empty
+func filterFolders(folders []Folder, tags, operationIDs []string) []Folder {
+	var filtered []Folder
+	for _, folder := range folders {
+		var operations []Operation
+		for _, operation := range folder.Operations {
+			// Untagged operations are matched by the name of their folder
+			operationTags := operation.Tags
+			if len(operationTags) == 0 {
+				operationTags = []string{folder.Name}
+			}
+
+			if (len(tags) == 0 || containsAny(operationTags, tags)) &&
+				(len(operationIDs) == 0 || containsAny([]string{operation.OperationID}, operationIDs)) {
+				operations = append(operations, operation)
+			}
+		}
+
+		folder.Operations = operations
+		folder.Folders = filterFolders(folder.Folders, tags, operationIDs)
+		if len(folder.Operations) > 0 || len(folder.Folders) > 0 {
+			filtered = append(filtered, folder)
+		}
+	}
+
+	return filtered
+}
+
+// containsAny reports whether values contains any of candidates
+func containsAny(values, candidates []string) bool {
+	for _, value := range values {
+		for _, candidate := range candidates {
+			if value == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// variablePattern matches {{name}} variable references of the model
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// ParseOpenAPI parses an OpenAPI spec (YAML or JSON) into the format-neutral API model.
+// Swagger 2.0 and OpenAPI 3.1 documents are normalized to OpenAPI 3.0 first.
+func (g *Generator) ParseOpenAPI(openAPIData []byte) (*API, error) {
+	return g.parse(openAPIData, "")
+}
+
+// ParseFile reads an OpenAPI file and parses it into the format-neutral API model,
+// resolving references to other files relative to it
+func (g *Generator) ParseFile(openAPIFile string) (*API, error) {
+	openAPIData, err := os.ReadFile(openAPIFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI file: %w", err)
+	}
+
+	return g.parse(openAPIData, openAPIFile)
+}
+
+// parse converts OpenAPI data read from source (empty when unknown) to the API model
+func (g *Generator) parse(openAPIData []byte, source string) (*API, error) {
+	fullSpec, document, refs, err := loadDocument(openAPIData, source)
+	if err != nil {
+		return nil, err
+	}
+
+	openAPI, err := decodeOpenAPISpec(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	security := newSecuritySchemes(document, refs)
+
+	filter, err := g.filter.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	api := &API{
+		Title:       openAPI.Info.Title,
+		Version:     openAPI.Info.Version,
+		Description: openAPI.Info.Description,
+		Folders:     g.buildFolders(openAPI, readDocumentOrder(openAPIData), filter, refs, security),
+		Servers:     g.buildServers(openAPI.Servers),
+		Variables:   security.variables(),
+		Document:    fullSpec,
+	}
+
+	if err := g.applyOverlays(api); err != nil {
+		return nil, err
+	}
+	if api.Files, err = g.sourceFiles(refs); err != nil {
+		return nil, err
+	}
+
+	// Webhook requests are sent to the receiver configured in webhook_url
+	for path := range openAPI.Paths {
+		if strings.HasPrefix(path, webhookPathPrefix) {
+			api.Variables["webhook_url"] = ""
+			break
+		}
+	}
+
+	return api, nil
+}
+
+// sourceFiles returns the absolute paths of the files an API is read from, in name order
+// after the spec
+func (g *Generator) sourceFiles(refs *refResolver) ([]string, error) {
+	var files []string
+	if refs.rootPath != "" {
+		files = append(files, refs.rootPath)
+	}
+
+	var dependencies []string
+	for _, file := range refs.files() {
+		if file != refs.rootPath {
+			dependencies = append(dependencies, file)
+		}
+	}
+	for _, overlay := range g.overlays {
+		file, err := filepath.Abs(overlay)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve path %s: %w", overlay, err)
+		}
+		dependencies = append(dependencies, file)
+	}
+	sort.Strings(dependencies)
+
+	return append(files, dependencies...), nil
+}
+
+// loadDocument parses OpenAPI data read from source (empty when unknown), returning the
+// document as read, a copy normalized to OpenAPI 3.0 and a resolver for its references
+func loadDocument(openAPIData []byte, source string) (interface{}, map[string]interface{}, *refResolver, error) {
+	// Parse the full spec as interface{} to preserve all data
+	fullSpec, err := parseDocument(openAPIData)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse full OpenAPI spec: %w", err)
+	}
+
+	document, ok := deepCopy(fullSpec).(map[string]interface{})
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("failed to parse OpenAPI spec: document is not a mapping")
+	}
+	document = normalizeDocument(document)
+
+	refs := newRefResolver(document)
+	if err := refs.loadExternalRefs(source); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to resolve OpenAPI references: %w", err)
+	}
+
+	return fullSpec, document, refs, nil
+}
+
+// buildFolders arranges the operations of the OpenAPI paths in folders, by tag or by
+// path segment depending on the folder layout of the generator, in its sort order.
+// Operations the filter does not keep are left out, and deprecated operations are
+// handled as the generator is set to.
+func (g *Generator) buildFolders(openAPI OpenAPISpec, order documentOrder, filter operationFilter, refs *refResolver, security *securitySchemes) []Folder {
+	var operations []Operation
+
+	// Process paths in document order so that output is stable and follows the spec
+	for _, path := range order.sortedPaths(openAPI.Paths) {
+		pathData := refs.resolveMap(openAPI.Paths[path])
+		if pathData == nil {
+			continue
+		}
+
+		for _, method := range order.sortedMethods(path) {
+			opData, ok := pathData[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			params := collectParameters(pathData, opData, refs)
+			body := g.buildRequestBody(opData, refs)
+
+			// Extract operation details
+			summary := g.getStringValue(opData, "summary")
+			operationID := g.getStringValue(opData, "operationId")
+			tags := g.getStringSlice(opData, "tags")
+
+			deprecated := opData["deprecated"] == true
+
+			operation := Operation{
+				Key:            operationKey(operationID, method, path),
+				OperationID:    operationID,
+				Name:           g.nameRequest(summary, operationID, method, path, deprecated),
+				Description:    g.getStringValue(opData, "description"),
+				Method:         strings.ToUpper(method),
+				Path:           path,
+				Tags:           tags,
+				URL:            g.buildURL(path, params),
+				PathParameters: buildPathParameters(params, refs),
+				Query:          buildQueryParameters(params, refs),
+				Headers:        buildHeaders(params, body, refs),
+				Body:           body,
+				Auth:           security.authentication(opData),
+				Responses:      g.buildResponses(opData, refs),
+				Deprecated:     deprecated,
+				Extensions:     operationExtensions(opData),
+			}
+			if opData[webhookExtension] == true {
+				operation.URL = "{{webhook_url}}"
+				operation.Webhook = true
+			}
+			if !filter.keep(operation) {
+				continue
+			}
+
+			operations = append(operations, operation)
+		}
+	}
+
+	g.sortOperations(operations)
+	operations, deprecated := g.deprecatedFolder(operations)
+
+	var folders []Folder
+	if g.layout.ByPath {
+		folders = pathFolders(operations)
+	} else {
+		folders = g.tagFolders(openAPI, operations)
+	}
+	g.sortFolders(folders)
+
+	// Deprecated operations come last, whatever the sort order
+	if deprecated != nil {
+		folders = append(folders, *deprecated)
+	}
+	return folders
+}
+
+// operationExtensions returns the x- extensions of an operation
+func operationExtensions(opData map[string]interface{}) map[string]interface{} {
+	var extensions map[string]interface{}
+	for key, value := range opData {
+		if !strings.HasPrefix(key, "x-") {
+			continue
+		}
+		if extensions == nil {
+			extensions = make(map[string]interface{})
+		}
+		extensions[key] = value
+	}
+	return extensions
+}
+
+// operationKey returns the stable key identifying an operation: its operationId,
+// or its method and path when no operationId is declared
+func operationKey(operationID, method, path string) string {
+	if operationID != "" {
+		return operationID
+	}
+	return fmt.Sprintf("%s %s", strings.ToUpper(method), path)
+}
+
+// orderTags returns the used tags, given in order of first use, with declared tags first
+// followed by the rest
+func orderTags(declared []OpenAPITag, used []string) []string {
+	ordered := make([]string, 0, len(used))
+	seen := make(map[string]bool)
+
+	isUsed := make(map[string]bool, len(used))
+	for _, tag := range used {
+		isUsed[tag] = true
+	}
+
+	for _, tag := range declared {
+		if isUsed[tag.Name] && !seen[tag.Name] {
+			ordered = append(ordered, tag.Name)
+			seen[tag.Name] = true
+		}
+	}
+
+	for _, tag := range used {
+		if !seen[tag] {
+			ordered = append(ordered, tag)
+			seen[tag] = true
+		}
+	}
+
+	return ordered
+}
+
+// buildURL constructs the URL with template variables
+func (g *Generator) buildURL(path string, params []map[string]interface{}) string {
+	baseURL := "{{base_url}}"
+
+	// Replace path parameters with template variables
+	url := path
+	for _, param := range params {
+		if param["in"] == "path" {
+			paramName := param["name"].(string)
+			url = strings.ReplaceAll(url, fmt.Sprintf("{%s}", paramName), fmt.Sprintf("{{%s}}", paramName))
+		}
+	}
+
+	return baseURL + url
+}
+
+// buildServers creates the servers of the API, one per combination of server variable values
+func (g *Generator) buildServers(openAPIServers []OpenAPIServer) []Server {
+	var servers []Server
+	for _, server := range openAPIServers {
+		combinations := expandServerVariables(server.Variables)
+
+		for _, values := range combinations {
+			scheme, host, basePath := g.parseServerURL(substituteServerVariables(server.URL, values))
+
+			name := g.nameEnvironment(server.Description, scheme, host, basePath)
+
+			// Only variables with several values distinguish the combinations of a server
+			key := server.URL
+			if len(combinations) > 1 {
+				label := describeServerVariables(server.Variables, values)
+				name = fmt.Sprintf("%s (%s)", name, label)
+				key = fmt.Sprintf("%s (%s)", key, label)
+			}
+
+			servers = append(servers, Server{
+				Key:       key,
+				Name:      name,
+				Scheme:    scheme,
+				Host:      host,
+				BasePath:  basePath,
+				Variables: values,
+			})
+		}
+	}
+	return servers
+}
+
+// parseServerURL extracts scheme, host (including port), and base path from a server URL.
+// Relative server URLs are resolved against http://localhost.
+func (g *Generator) parseServerURL(serverURL string) (scheme, host, basePath string) {
+	parsed, err := url.Parse(serverURL)
+	if err != nil {
+		return "http", serverURL, ""
+	}
+
+	scheme = parsed.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	host = parsed.Host
+	if host == "" {
+		host = "localhost"
+		if !strings.HasPrefix(parsed.Path, "/") && parsed.Path != "" {
+			parsed.Path = "/" + parsed.Path
+		}
+	}
+
+	basePath = strings.TrimSuffix(parsed.Path, "/")
+	return
+}
+
+// maxServerCombinations limits how many sub-environments a single server expands into.
+// Servers with more combinations only get a sub-environment for their default values.
+const maxServerCombinations = 16
+
+// expandServerVariables returns every combination of server variable values
+func expandServerVariables(variables map[string]OpenAPIServerVariable) []map[string]string {
+	names := make([]string, 0, len(variables))
+	total := 1
+	for name, variable := range variables {
+		names = append(names, name)
+		if len(variable.Enum) > 0 {
+			total *= len(variable.Enum)
+		}
+	}
+	sort.Strings(names)
+
+	combinations := []map[string]string{{}}
+	for _, name := range names {
+		variable := variables[name]
+		values := variable.Enum
+		if len(values) == 0 || total > maxServerCombinations {
+			values = []string{variable.Default}
+		}
+
+		var next []map[string]string
+		for _, combination := range combinations {
+			for _, value := range values {
+				expanded := make(map[string]string, len(combination)+1)
+				for k, v := range combination {
+					expanded[k] = v
+				}
+				expanded[name] = value
+				next = append(next, expanded)
+			}
+		}
+		combinations = next
+	}
+
+	return combinations
+}
+
+// substituteServerVariables replaces {variable} placeholders in a server URL
+func substituteServerVariables(serverURL string, values map[string]string) string {
+	for name, value := range values {
+		serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", value)
+	}
+	return serverURL
+}
+
+// describeServerVariables labels a combination by the values of its multi-valued variables
+func describeServerVariables(variables map[string]OpenAPIServerVariable, values map[string]string) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if len(variables[name].Enum) > 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%s", name, values[name]))
+	}
+	return strings.Join(parts, ", ")
+}
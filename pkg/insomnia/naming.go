@@ -0,0 +1,127 @@
+package insomnia
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DeprecatedHandling sets how operations marked deprecated are generated
+type DeprecatedHandling string
+
+const (
+	// DeprecatedKeep generates deprecated operations like any other
+	DeprecatedKeep DeprecatedHandling = "keep"
+	// DeprecatedPrefix prefixes the names of deprecated requests with "[Deprecated] "
+	DeprecatedPrefix DeprecatedHandling = "prefix"
+	// DeprecatedFolder moves deprecated requests to a "Deprecated" folder, after the others
+	DeprecatedFolder DeprecatedHandling = "folder"
+)
+
+// deprecatedPrefix marks the names of deprecated requests
+const deprecatedPrefix = "[Deprecated] "
+
+// deprecatedFolderName names the folder of deprecated requests
+const deprecatedFolderName = "Deprecated"
+
+// operationIDMarker records the operationId of a request in its description, as an
+// HTML comment that Markdown renderers hide
+const operationIDMarker = "<!-- operationId: %s -->"
+
+// operationIDMarkerPattern matches the operationId marker of a description
+var operationIDMarkerPattern = regexp.MustCompile(`(?:\n\n)?<!-- operationId: (\S+) -->$`)
+
+// ParseDeprecatedHandling parses the name of a deprecated handling, case-insensitively
+func ParseDeprecatedHandling(name string) (DeprecatedHandling, error) {
+	switch handling := DeprecatedHandling(strings.ToLower(strings.TrimSpace(name))); handling {
+	case DeprecatedKeep, DeprecatedPrefix, DeprecatedFolder:
+		return handling, nil
+	}
+	return "", fmt.Errorf("unknown deprecated handling %q (expected keep, prefix or folder)", name)
+}
+
+// WithRequestName sets the name of generated requests. The {{operationId}}, {{summary}},
+// {{method}} and {{path}} placeholders are replaced by those of the operation; names that
+// come out empty fall back to the default, the summary or else "{{method}} {{path}}".
+func WithRequestName(template string) Option {
+	return func(g *Generator) {
+		g.requestName = template
+	}
+}
+
+// WithDeprecatedHandling sets how operations marked deprecated are generated
+func WithDeprecatedHandling(handling DeprecatedHandling) Option {
+	return func(g *Generator) {
+		g.deprecated = handling
+	}
+}
+
+// nameRequest names the request of an operation
+func (g *Generator) nameRequest(summary, operationID, method string, path string, deprecated bool) string {
+	name := strings.TrimSpace(strings.NewReplacer(
+		"{{operationId}}", operationID,
+		"{{summary}}", summary,
+		"{{method}}", strings.ToUpper(method),
+		"{{path}}", path,
+	).Replace(g.requestName))
+
+	if name == "" {
+		name = summary
+	}
+	if name == "" {
+		name = fmt.Sprintf("%s %s", strings.ToUpper(method), path)
+	}
+	if deprecated && g.deprecated == DeprecatedPrefix {
+		name = deprecatedPrefix + name
+	}
+	return name
+}
+
+// deprecatedFolder moves the deprecated operations out of the others into a folder of
+// their own, when the generator is set to
+func (g *Generator) deprecatedFolder(operations []Operation) ([]Operation, *Folder) {
+	if g.deprecated != DeprecatedFolder {
+		return operations, nil
+	}
+
+	var current, deprecated []Operation
+	for _, operation := range operations {
+		if operation.Deprecated {
+			deprecated = append(deprecated, operation)
+		} else {
+			current = append(current, operation)
+		}
+	}
+	if len(deprecated) == 0 {
+		return operations, nil
+	}
+
+	return current, &Folder{
+		Name:        deprecatedFolderName,
+		Description: "Operations marked deprecated in the spec",
+		Operations:  deprecated,
+	}
+}
+
+// markOperationID appends the operationId marker to the description of a request
+func markOperationID(description, operationID string) string {
+	if operationID == "" {
+		return description
+	}
+
+	marker := fmt.Sprintf(operationIDMarker, operationID)
+	if description == "" {
+		return marker
+	}
+	return description + "\n\n" + marker
+}
+
+// markedOperationID returns the operationId recorded in the description of a request and
+// the description without it
+func markedOperationID(description string) (string, string) {
+	match := operationIDMarkerPattern.FindStringSubmatchIndex(description)
+	if match == nil {
+		return "", description
+	}
+	return description[match[2]:match[3]], description[:match[0]]
+}
@@ -0,0 +1,114 @@
+package insomnia
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRequestNameTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"", "List customers, Create customer, Get address, Update address, Reindex, Health"},
+		{"{{operationId}}", "listCustomers, createCustomer, getCustomerAddress, updateCustomerAddress, reindex, Health"},
+		{"{{method}} {{path}}", "GET /customers, POST /customers, GET /customers/{id}/address, PUT /customers/{id}/address, POST /admin/reindex, GET /health"},
+	}
+
+	for _, test := range tests {
+		api, err := NewDeterministicGenerator(WithRequestName(test.template)).ParseOpenAPI([]byte(filterSpec))
+		if err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+
+		var names []string
+		walkOperations(api.Folders, nil, func(_ []string, operation Operation) {
+			names = append(names, operation.Name)
+		})
+		if joined := strings.Join(names, ", "); joined != test.expected {
+			t.Errorf("Test failed. Expected %q to name %q, got %q", test.template, test.expected, joined)
+		}
+	}
+}
+
+func TestDeprecatedHandling(t *testing.T) {
+	spec, err := NewDeterministicGenerator(WithDeprecatedHandling(DeprecatedPrefix)).GenerateFromOpenAPI([]byte(filterSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if findRequest(spec, "[Deprecated] Update address") == nil || findRequest(spec, "Get address") == nil {
+		t.Errorf("Test failed. Expected only the deprecated request to be prefixed")
+	}
+
+	spec, err = NewDeterministicGenerator(WithDeprecatedHandling(DeprecatedFolder), WithSortOrder(SortAlphabetically)).GenerateFromOpenAPI([]byte(filterSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	last := spec.Collection[len(spec.Collection)-1]
+	if last.Name != deprecatedFolderName || len(last.Children) != 1 || last.Children[0].Name != "Update address" {
+		t.Errorf("Test failed. Expected a last Deprecated folder with the deprecated request, got %+v", last)
+	}
+
+	if _, err := ParseDeprecatedHandling("hide"); err == nil {
+		t.Errorf("Test failed. Expected an error for an unknown handling")
+	}
+}
+
+func TestOperationIDMarker(t *testing.T) {
+	spec, err := NewDeterministicGenerator().GenerateFromOpenAPI([]byte(filterSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	request := findRequest(spec, "List customers")
+	if operationID, description := markedOperationID(request.Meta.Description); operationID != "listCustomers" || description != "" {
+		t.Errorf("Test failed. Expected the listCustomers marker, got %q", request.Meta.Description)
+	}
+	if health := findRequest(spec, "Health"); health.Meta.Description != "" {
+		t.Errorf("Test failed. Expected no marker without operationId, got %q", health.Meta.Description)
+	}
+
+	operationID, description := markedOperationID(markOperationID("Lists customers", "listCustomers"))
+	if operationID != "listCustomers" || description != "Lists customers" {
+		t.Errorf("Test failed. Expected the description and operationId back, got %q and %q", description, operationID)
+	}
+}
+
+func TestUpdateMatchesOperationID(t *testing.T) {
+	existing, err := NewGenerator().GenerateFromOpenAPI([]byte(filterSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	listID := findRequest(existing, "List customers").Meta.ID
+
+	// The path changes, and random IDs differ, so only the operationId matches
+	updatedSpec := strings.Replace(filterSpec, "  /customers:\n", "  /clients:\n", 1)
+	merged, err := NewGenerator().UpdateFromOpenAPI([]byte(updatedSpec), existing, false)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if list := findRequest(merged, "List customers"); list == nil || list.Meta.ID != listID || list.URL != "{{ _.base_url }}/clients" {
+		t.Errorf("Test failed. Expected the moved request to keep ID %s, got %+v", listID, list)
+	}
+}
+
+func TestReverseOperationIDAndDeprecated(t *testing.T) {
+	g := NewDeterministicGenerator(WithDeprecatedHandling(DeprecatedPrefix))
+	spec, err := g.GenerateFromOpenAPI([]byte(filterSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	spec.Spec.Contents = nil
+
+	result, err := g.ReverseInsomnia(spec)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	paths := result.Document["paths"].(map[string]interface{})
+	update := paths["/customers/{id}/address"].(map[string]interface{})["put"].(map[string]interface{})
+	if update["operationId"] != "updateCustomerAddress" || update["deprecated"] != true || update["summary"] != "Update address" {
+		t.Errorf("Test failed. Expected the operationId and deprecated flag back, got %v", update)
+	}
+}
@@ -0,0 +1,747 @@
+package insomnia
+
+import (
+	"sort"
+	"strings"
+)
+
+// defaultSwaggerMediaType is the media type assumed when a Swagger 2.0 document declares no consumes/produces
+const defaultSwaggerMediaType = "application/json"
+
+// swaggerRefPrefixes maps Swagger 2.0 reference prefixes to their OpenAPI 3.0 locations
+var swaggerRefPrefixes = map[string]string{
+	"#/definitions/": "#/components/schemas/",
+	"#/parameters/":  "#/components/parameters/",
+	"#/responses/":   "#/components/responses/",
+}
+
+// swaggerSchemaKeys are the Swagger 2.0 parameter fields that move into the parameter schema
+var swaggerSchemaKeys = []string{
+	"type", "format", "items", "enum", "default", "minimum", "maximum",
+	"exclusiveMinimum", "exclusiveMaximum", "minLength", "maxLength", "pattern",
+	"minItems", "maxItems", "uniqueItems", "multipleOf",
+}
+
+// exclusiveBounds maps JSON Schema 2020-12 numeric exclusive bounds to the limit they replace
+var exclusiveBounds = map[string]string{
+	"exclusiveMinimum": "minimum",
+	"exclusiveMaximum": "maximum",
+}
+
+// Webhooks of OpenAPI 3.1 documents are exposed as operations under a dedicated tag,
+// keyed by name and marked with an extension so their URL points at the receiver
+const (
+	webhookExtension  = "x-webhook"
+	webhookTag        = "webhooks"
+	webhookPathPrefix = "webhook:"
+)
+
+// swaggerOAuthFlows maps Swagger 2.0 OAuth2 flow names to their OpenAPI 3.0 names
+var swaggerOAuthFlows = map[string]string{
+	"implicit":    "implicit",
+	"password":    "password",
+	"application": "clientCredentials",
+	"accessCode":  "authorizationCode",
+}
+
+// normalizeDocument converts Swagger 2.0 and OpenAPI 3.1 documents into the OpenAPI 3.0
+// shape the generator works with. OpenAPI 3.0 documents are returned unchanged. The
+// document is modified in place, so callers pass a copy when they need the original.
+func normalizeDocument(doc map[string]interface{}) map[string]interface{} {
+	if version, ok := doc["swagger"]; ok && strings.HasPrefix(scalarString(version), "2") {
+		return convertSwagger2(doc)
+	}
+
+	if version, _ := doc["openapi"].(string); strings.HasPrefix(version, "3.1") {
+		return convertOpenAPI31(doc)
+	}
+
+	return doc
+}
+
+// convertSwagger2 converts a Swagger 2.0 document into OpenAPI 3.0
+func convertSwagger2(doc map[string]interface{}) map[string]interface{} {
+	rewriteSwaggerRefs(doc)
+
+	result := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    doc["info"],
+		"servers": swaggerServers(doc),
+	}
+	if tags, ok := doc["tags"]; ok {
+		result["tags"] = tags
+	}
+	if security, ok := doc["security"]; ok {
+		result["security"] = security
+	}
+
+	consumes := mediaTypes(doc["consumes"])
+	produces := mediaTypes(doc["produces"])
+
+	// References were already rewritten, so resolve them against a components view of the document
+	refs := newRefResolver(map[string]interface{}{
+		"components": map[string]interface{}{
+			"schemas":    doc["definitions"],
+			"parameters": doc["parameters"],
+			"responses":  doc["responses"],
+		},
+	})
+
+	components := make(map[string]interface{})
+	if definitions, ok := doc["definitions"].(map[string]interface{}); ok {
+		components["schemas"] = definitions
+	}
+
+	if parameters, ok := doc["parameters"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{})
+		for name, param := range parameters {
+			if paramMap, ok := param.(map[string]interface{}); ok && !isSwaggerBodyParameter(paramMap) {
+				converted[name] = convertSwaggerParameter(paramMap)
+			}
+		}
+		components["parameters"] = converted
+	}
+
+	if responses, ok := doc["responses"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{})
+		for name, response := range responses {
+			converted[name] = convertSwaggerResponse(response, produces)
+		}
+		components["responses"] = converted
+	}
+
+	if definitions, ok := doc["securityDefinitions"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{})
+		for name, definition := range definitions {
+			if definitionMap, ok := definition.(map[string]interface{}); ok {
+				converted[name] = convertSwaggerSecurityScheme(definitionMap)
+			}
+		}
+		components["securitySchemes"] = converted
+	}
+
+	result["components"] = components
+
+	paths := make(map[string]interface{})
+	sourcePaths, _ := doc["paths"].(map[string]interface{})
+	for path, item := range sourcePaths {
+		pathItem, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// Non-body path-level parameters stay on the path item
+		pathParams, _ := pathItem["parameters"].([]interface{})
+		converted := make(map[string]interface{})
+		for key, value := range pathItem {
+			if key != "parameters" {
+				converted[key] = value
+			}
+		}
+
+		var shared []interface{}
+		for _, param := range pathParams {
+			paramMap := refs.resolveMap(param)
+			if paramMap != nil && !isSwaggerBodyParameter(paramMap) {
+				shared = append(shared, convertSwaggerParameterRef(param))
+			}
+		}
+		if len(shared) > 0 {
+			converted["parameters"] = shared
+		}
+
+		for _, method := range httpMethods {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			converted[method] = convertSwaggerOperation(operation, pathParams, consumes, produces, refs)
+		}
+
+		paths[path] = converted
+	}
+	result["paths"] = paths
+
+	for key, value := range doc {
+		if strings.HasPrefix(key, "x-") {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// swaggerServers builds OpenAPI servers from host, basePath and schemes
+func swaggerServers(doc map[string]interface{}) []interface{} {
+	host, _ := doc["host"].(string)
+	basePath, _ := doc["basePath"].(string)
+
+	if host == "" {
+		if basePath == "" {
+			return nil
+		}
+		return []interface{}{map[string]interface{}{"url": basePath}}
+	}
+
+	schemes := mediaTypes(doc["schemes"])
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+
+	servers := make([]interface{}, 0, len(schemes))
+	for _, scheme := range schemes {
+		servers = append(servers, map[string]interface{}{
+			"url": scheme + "://" + host + basePath,
+		})
+	}
+	return servers
+}
+
+// convertSwaggerOperation converts a Swagger 2.0 operation, turning body and formData
+// parameters into a requestBody and responses into OpenAPI 3.0 content maps
+func convertSwaggerOperation(operation map[string]interface{}, pathParams []interface{}, consumes, produces []string, refs *refResolver) map[string]interface{} {
+	if opConsumes := mediaTypes(operation["consumes"]); len(opConsumes) > 0 {
+		consumes = opConsumes
+	}
+	if opProduces := mediaTypes(operation["produces"]); len(opProduces) > 0 {
+		produces = opProduces
+	}
+
+	result := make(map[string]interface{})
+	for key, value := range operation {
+		switch key {
+		case "consumes", "produces", "parameters", "responses", "schemes":
+		default:
+			result[key] = value
+		}
+	}
+
+	opParams, _ := operation["parameters"].([]interface{})
+	var params []interface{}
+	var formFields []map[string]interface{}
+
+	// Body and form parameters declared on the path item apply to every operation
+	for _, param := range append(append([]interface{}{}, pathParams...), opParams...) {
+		paramMap := refs.resolveMap(param)
+		if paramMap == nil {
+			continue
+		}
+
+		switch paramMap["in"] {
+		case "body":
+			result["requestBody"] = swaggerRequestBody(paramMap, consumes)
+		case "formData":
+			formFields = append(formFields, paramMap)
+		}
+	}
+
+	for _, param := range opParams {
+		if paramMap := refs.resolveMap(param); paramMap != nil && !isSwaggerBodyParameter(paramMap) {
+			params = append(params, convertSwaggerParameterRef(param))
+		}
+	}
+
+	if len(params) > 0 {
+		result["parameters"] = params
+	}
+	if len(formFields) > 0 {
+		result["requestBody"] = swaggerFormBody(formFields, consumes)
+	}
+
+	if responses, ok := operation["responses"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{})
+		for status, response := range responses {
+			converted[status] = convertSwaggerResponse(response, produces)
+		}
+		result["responses"] = converted
+	}
+
+	return result
+}
+
+// swaggerRequestBody converts a Swagger 2.0 body parameter into a requestBody
+func swaggerRequestBody(param map[string]interface{}, consumes []string) map[string]interface{} {
+	if len(consumes) == 0 {
+		consumes = []string{defaultSwaggerMediaType}
+	}
+
+	media := map[string]interface{}{"schema": param["schema"]}
+	if example, ok := param["x-example"]; ok {
+		media["example"] = example
+	}
+
+	content := make(map[string]interface{})
+	for _, mimeType := range consumes {
+		content[mimeType] = media
+	}
+
+	body := map[string]interface{}{"content": content}
+	if description, ok := param["description"]; ok {
+		body["description"] = description
+	}
+	if required, ok := param["required"]; ok {
+		body["required"] = required
+	}
+	return body
+}
+
+// swaggerFormBody converts Swagger 2.0 formData parameters into a form requestBody
+func swaggerFormBody(fields []map[string]interface{}, consumes []string) map[string]interface{} {
+	mimeType := "application/x-www-form-urlencoded"
+	for _, consumed := range consumes {
+		if consumed == "multipart/form-data" {
+			mimeType = consumed
+		}
+	}
+
+	properties := make(map[string]interface{})
+	var required []interface{}
+	for _, field := range fields {
+		name, _ := field["name"].(string)
+		schema := swaggerParameterSchema(field)
+		if schema["type"] == "file" {
+			mimeType = "multipart/form-data"
+			schema = map[string]interface{}{"type": "string", "format": "binary"}
+		}
+		properties[name] = schema
+		if field["required"] == true {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return map[string]interface{}{
+		"content": map[string]interface{}{
+			mimeType: map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+// convertSwaggerParameterRef converts an inline parameter, leaving references untouched
+func convertSwaggerParameterRef(param interface{}) interface{} {
+	if _, ok := refOf(param); ok {
+		return param
+	}
+	if paramMap, ok := param.(map[string]interface{}); ok {
+		return convertSwaggerParameter(paramMap)
+	}
+	return param
+}
+
+// convertSwaggerParameter moves the type information of a Swagger 2.0 parameter into a schema
+func convertSwaggerParameter(param map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for key, value := range param {
+		if !isSwaggerSchemaKey(key) && key != "collectionFormat" && key != "x-example" {
+			result[key] = value
+		}
+	}
+
+	result["schema"] = swaggerParameterSchema(param)
+	if example, ok := param["x-example"]; ok {
+		result["example"] = example
+	}
+	return result
+}
+
+// swaggerParameterSchema builds a schema from the type fields of a Swagger 2.0 parameter
+func swaggerParameterSchema(param map[string]interface{}) map[string]interface{} {
+	schema := make(map[string]interface{})
+	for _, key := range swaggerSchemaKeys {
+		if value, ok := param[key]; ok {
+			schema[key] = value
+		}
+	}
+	return schema
+}
+
+// isSwaggerSchemaKey reports whether a parameter field belongs in the schema
+func isSwaggerSchemaKey(key string) bool {
+	for _, schemaKey := range swaggerSchemaKeys {
+		if key == schemaKey {
+			return true
+		}
+	}
+	return false
+}
+
+// isSwaggerBodyParameter reports whether a parameter describes the request body
+func isSwaggerBodyParameter(param map[string]interface{}) bool {
+	return param["in"] == "body" || param["in"] == "formData"
+}
+
+// convertSwaggerResponse moves the schema and examples of a Swagger 2.0 response into content
+func convertSwaggerResponse(response interface{}, produces []string) interface{} {
+	responseMap, ok := response.(map[string]interface{})
+	if !ok {
+		return response
+	}
+	if _, ok := refOf(response); ok {
+		return response
+	}
+
+	result := make(map[string]interface{})
+	for key, value := range responseMap {
+		if key != "schema" && key != "examples" {
+			result[key] = value
+		}
+	}
+
+	examples, _ := responseMap["examples"].(map[string]interface{})
+	schema, hasSchema := responseMap["schema"]
+	if !hasSchema && len(examples) == 0 {
+		return result
+	}
+
+	mimeTypes := produces
+	if len(mimeTypes) == 0 {
+		mimeTypes = []string{defaultSwaggerMediaType}
+	}
+
+	content := make(map[string]interface{})
+	for _, mimeType := range mimeTypes {
+		media := make(map[string]interface{})
+		if hasSchema {
+			media["schema"] = schema
+		}
+		if example, ok := examples[mimeType]; ok {
+			media["example"] = example
+		}
+		content[mimeType] = media
+	}
+	result["content"] = content
+
+	return result
+}
+
+// convertSwaggerSecurityScheme converts a Swagger 2.0 security definition into a security scheme
+func convertSwaggerSecurityScheme(definition map[string]interface{}) map[string]interface{} {
+	switch definition["type"] {
+	case "basic":
+		return map[string]interface{}{"type": "http", "scheme": "basic", "description": definition["description"]}
+	case "oauth2":
+		flowName, _ := definition["flow"].(string)
+		flow := make(map[string]interface{})
+		for _, key := range []string{"authorizationUrl", "tokenUrl", "scopes"} {
+			if value, ok := definition[key]; ok {
+				flow[key] = value
+			}
+		}
+		if _, ok := flow["scopes"]; !ok {
+			flow["scopes"] = map[string]interface{}{}
+		}
+		return map[string]interface{}{
+			"type":        "oauth2",
+			"description": definition["description"],
+			"flows":       map[string]interface{}{swaggerOAuthFlows[flowName]: flow},
+		}
+	}
+	return definition
+}
+
+// rewriteSwaggerRefs rewrites Swagger 2.0 $refs to their OpenAPI 3.0 locations
+func rewriteSwaggerRefs(node interface{}) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				for prefix, replacement := range swaggerRefPrefixes {
+					if strings.HasPrefix(ref, prefix) {
+						value[key] = replacement + strings.TrimPrefix(ref, prefix)
+					}
+				}
+				continue
+			}
+			rewriteSwaggerRefs(child)
+		}
+	case []interface{}:
+		for _, child := range value {
+			rewriteSwaggerRefs(child)
+		}
+	}
+}
+
+// convertOpenAPI31 downgrades the JSON Schema 2020-12 constructs of an OpenAPI 3.1
+// document to their OpenAPI 3.0 equivalents and exposes webhooks as operations
+func convertOpenAPI31(doc map[string]interface{}) map[string]interface{} {
+	downgradeSchemas(doc)
+	doc["openapi"] = "3.0.3"
+
+	webhooks, _ := doc["webhooks"].(map[string]interface{})
+	if len(webhooks) == 0 {
+		return doc
+	}
+
+	paths, _ := doc["paths"].(map[string]interface{})
+	if paths == nil {
+		paths = make(map[string]interface{})
+		doc["paths"] = paths
+	}
+
+	names := make([]string, 0, len(webhooks))
+	for name := range webhooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pathItem, ok := webhooks[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		converted := make(map[string]interface{})
+		for key, value := range pathItem {
+			operation, ok := value.(map[string]interface{})
+			if !ok {
+				converted[key] = value
+				continue
+			}
+
+			webhook := make(map[string]interface{}, len(operation)+2)
+			for k, v := range operation {
+				webhook[k] = v
+			}
+			webhook[webhookExtension] = true
+			if _, ok := webhook["tags"]; !ok {
+				webhook["tags"] = []interface{}{webhookTag}
+			}
+			converted[key] = webhook
+		}
+
+		paths[webhookPathPrefix+name] = converted
+	}
+	delete(doc, "webhooks")
+
+	return doc
+}
+
+// Keywords of a schema holding a subschema, a list of subschemas and a map of subschemas
+var (
+	subschemaKeywords     = []string{"items", "additionalProperties", "not", "contains", "if", "then", "else", "propertyNames", "unevaluatedItems", "unevaluatedProperties"}
+	subschemaListKeywords = []string{"allOf", "oneOf", "anyOf", "prefixItems"}
+	subschemaMapKeywords  = []string{"properties", "patternProperties", "dependentSchemas", "$defs"}
+)
+
+// downgradeSchemas downgrades the schemas of a document: those of components and of the
+// parameters, request bodies, responses and headers of its paths and webhooks
+func downgradeSchemas(doc map[string]interface{}) {
+	if components, ok := doc["components"].(map[string]interface{}); ok {
+		for _, schema := range mapValues(components["schemas"]) {
+			downgradeSchema(schema)
+		}
+		for _, param := range mapValues(components["parameters"]) {
+			downgradeParameterSchemas(param)
+		}
+		for _, header := range mapValues(components["headers"]) {
+			downgradeParameterSchemas(header)
+		}
+		for _, body := range mapValues(components["requestBodies"]) {
+			downgradeContentSchemas(body)
+		}
+		for _, response := range mapValues(components["responses"]) {
+			downgradeResponseSchemas(response)
+		}
+		for _, pathItem := range mapValues(components["pathItems"]) {
+			downgradePathItemSchemas(pathItem)
+		}
+	}
+
+	for _, pathItem := range mapValues(doc["paths"]) {
+		downgradePathItemSchemas(pathItem)
+	}
+	for _, pathItem := range mapValues(doc["webhooks"]) {
+		downgradePathItemSchemas(pathItem)
+	}
+}
+
+// downgradePathItemSchemas downgrades the schemas of a path item and its operations,
+// including their callbacks
+func downgradePathItemSchemas(node interface{}) {
+	pathItem, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for _, param := range listValues(pathItem["parameters"]) {
+		downgradeParameterSchemas(param)
+	}
+	for _, method := range httpMethods {
+		operation, ok := pathItem[method].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, param := range listValues(operation["parameters"]) {
+			downgradeParameterSchemas(param)
+		}
+		downgradeContentSchemas(operation["requestBody"])
+		for _, response := range mapValues(operation["responses"]) {
+			downgradeResponseSchemas(response)
+		}
+		for _, callback := range mapValues(operation["callbacks"]) {
+			for _, callbackPathItem := range mapValues(callback) {
+				downgradePathItemSchemas(callbackPathItem)
+			}
+		}
+	}
+}
+
+// downgradeParameterSchemas downgrades the schemas of a parameter or header
+func downgradeParameterSchemas(node interface{}) {
+	if param, ok := node.(map[string]interface{}); ok {
+		downgradeSchema(param["schema"])
+		downgradeContentSchemas(param)
+	}
+}
+
+// downgradeResponseSchemas downgrades the schemas of a response and its headers
+func downgradeResponseSchemas(node interface{}) {
+	if response, ok := node.(map[string]interface{}); ok {
+		downgradeContentSchemas(response)
+		for _, header := range mapValues(response["headers"]) {
+			downgradeParameterSchemas(header)
+		}
+	}
+}
+
+// downgradeContentSchemas downgrades the schemas of the media types of a request body,
+// response or parameter content, and of the headers of their multipart encodings
+func downgradeContentSchemas(node interface{}) {
+	holder, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, media := range mapValues(holder["content"]) {
+		mediaType, ok := media.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		downgradeSchema(mediaType["schema"])
+		for _, encoding := range mapValues(mediaType["encoding"]) {
+			if encoding, ok := encoding.(map[string]interface{}); ok {
+				for _, header := range mapValues(encoding["headers"]) {
+					downgradeParameterSchemas(header)
+				}
+			}
+		}
+	}
+}
+
+// downgradeSchema rewrites the JSON Schema 2020-12 keywords of a schema and its
+// subschemas: type arrays become a single type plus nullable, examples arrays become
+// example, const becomes a single-value enum and numeric exclusive bounds become booleans.
+// Property names are left alone, even when named like a keyword.
+func downgradeSchema(node interface{}) {
+	value, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if types, ok := value["type"].([]interface{}); ok {
+		var remaining []interface{}
+		for _, t := range types {
+			if t == "null" {
+				value["nullable"] = true
+			} else {
+				remaining = append(remaining, t)
+			}
+		}
+		if len(remaining) > 0 {
+			value["type"] = remaining[0]
+		} else {
+			delete(value, "type")
+		}
+	}
+
+	if examples, ok := value["examples"].([]interface{}); ok {
+		if _, hasExample := value["example"]; !hasExample && len(examples) > 0 {
+			value["example"] = examples[0]
+		}
+		delete(value, "examples")
+	}
+
+	if constant, ok := value["const"]; ok {
+		if _, hasEnum := value["enum"]; !hasEnum {
+			value["enum"] = []interface{}{constant}
+		}
+	}
+
+	for bound, limitKey := range exclusiveBounds {
+		if limit, ok := value[bound]; ok {
+			if _, isBool := limit.(bool); !isBool {
+				value[limitKey] = limit
+				value[bound] = true
+			}
+		}
+	}
+
+	for _, keyword := range subschemaKeywords {
+		downgradeSchema(value[keyword])
+	}
+	// items may still be a list of schemas in older drafts
+	for _, item := range listValues(value["items"]) {
+		downgradeSchema(item)
+	}
+	for _, keyword := range subschemaListKeywords {
+		for _, schema := range listValues(value[keyword]) {
+			downgradeSchema(schema)
+		}
+	}
+	for _, keyword := range subschemaMapKeywords {
+		for _, schema := range mapValues(value[keyword]) {
+			downgradeSchema(schema)
+		}
+	}
+}
+
+// mapValues returns the values of a mapping, or nothing when the node is not one
+func mapValues(node interface{}) []interface{} {
+	values, _ := node.(map[string]interface{})
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		result = append(result, value)
+	}
+	return result
+}
+
+// listValues returns the items of a list, or nothing when the node is not one
+func listValues(node interface{}) []interface{} {
+	values, _ := node.([]interface{})
+	return values
+}
+
+// mediaTypes converts a list of strings such as consumes, produces or schemes
+func mediaTypes(node interface{}) []string {
+	list, _ := node.([]interface{})
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		if str, ok := item.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
+// deepCopy copies a decoded YAML/JSON document so it can be modified safely
+func deepCopy(node interface{}) interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[k] = deepCopy(v)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			result[i] = deepCopy(v)
+		}
+		return result
+	default:
+		return node
+	}
+}
@@ -0,0 +1,230 @@
+package insomnia
+
+import (
+	"testing"
+)
+
+const swaggerSpec = `
+swagger: "2.0"
+info:
+  title: Legacy API
+  version: 1.0.0
+host: legacy.example.com
+basePath: /v1
+schemes: [https, http]
+consumes: [application/json]
+paths:
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        type: integer
+        x-example: 7
+    put:
+      summary: Update pet
+      tags: [pets]
+      parameters:
+        - name: dryRun
+          in: query
+          type: boolean
+          default: false
+        - name: pet
+          in: body
+          required: true
+          schema:
+            $ref: '#/definitions/Pet'
+      responses:
+        200:
+          description: Updated
+          schema:
+            $ref: '#/definitions/Pet'
+  /pets/{petId}/photo:
+    post:
+      summary: Upload photo
+      tags: [pets]
+      parameters:
+        - name: caption
+          in: formData
+          type: string
+        - name: file
+          in: formData
+          type: file
+      responses:
+        204:
+          description: Uploaded
+definitions:
+  Pet:
+    type: object
+    properties:
+      name:
+        type: string
+        example: Rex
+`
+
+const openAPI31Spec = `
+openapi: 3.1.0
+info:
+  title: Modern API
+  version: 2.0.0
+servers:
+  - url: https://api.example.com
+paths:
+  /pets:
+    post:
+      summary: Create pet
+      tags: [pets]
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: [string, "null"]
+                  examples: [Rex]
+                kind:
+                  const: dog
+webhooks:
+  newPet:
+    post:
+      summary: New pet notification
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                id:
+                  type: integer
+`
+
+func TestGenerateFromSwagger2(t *testing.T) {
+	spec, err := NewGenerator().GenerateFromOpenAPI([]byte(swaggerSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if len(spec.Environments.SubEnvironments) != 2 {
+		t.Fatalf("Test failed. Expected 2 environments from schemes, got %d", len(spec.Environments.SubEnvironments))
+	}
+	env := spec.Environments.SubEnvironments[0].Data
+	if env.Scheme != "https" || env.Host != "legacy.example.com" || env.BasePath != "/v1" {
+		t.Errorf("Test failed. Expected https://legacy.example.com/v1, got %s://%s%s", env.Scheme, env.Host, env.BasePath)
+	}
+
+	update := findRequest(spec, "Update pet")
+	if update == nil {
+		t.Fatalf("Test failed. Expected request Update pet")
+	}
+	if update.URL != "{{ _.base_url }}/pets/{{ _.petId }}" {
+		t.Errorf("Test failed. Expected path-level parameter in URL, got %s", update.URL)
+	}
+	if body := decodeBody(t, update); body["name"] != "Rex" {
+		t.Errorf("Test failed. Expected body from definitions, got %v", body)
+	}
+	if len(update.Parameters) != 1 || update.Parameters[0].Name != "dryRun" || update.Parameters[0].Value != "false" {
+		t.Errorf("Test failed. Expected dryRun query parameter, got %v", update.Parameters)
+	}
+
+	upload := findRequest(spec, "Upload photo")
+	if upload == nil || upload.Body == nil || upload.Body.MimeType != "multipart/form-data" {
+		t.Fatalf("Test failed. Expected multipart body for file upload, got %v", upload)
+	}
+	if len(upload.Body.Params) != 2 {
+		t.Errorf("Test failed. Expected 2 form fields, got %v", upload.Body.Params)
+	}
+}
+
+func TestGenerateFromOpenAPI31(t *testing.T) {
+	spec, err := NewGenerator().GenerateFromOpenAPI([]byte(openAPI31Spec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	body := decodeBody(t, findRequest(spec, "Create pet"))
+	if body["name"] != "Rex" {
+		t.Errorf("Test failed. Expected example from examples array, got %v", body["name"])
+	}
+	if body["kind"] != "dog" {
+		t.Errorf("Test failed. Expected const value, got %v", body["kind"])
+	}
+
+	webhook := findRequest(spec, "New pet notification")
+	if webhook == nil || webhook.URL != "{{ _.webhook_url }}" || webhook.Method != "POST" {
+		t.Fatalf("Test failed. Expected webhook request, got %v", webhook)
+	}
+	if _, ok := spec.Environments.Data.Extra["webhook_url"]; !ok {
+		t.Errorf("Test failed. Expected webhook_url environment variable")
+	}
+
+	contents := spec.Spec.Contents.(map[string]interface{})
+	if contents["openapi"] != "3.1.0" {
+		t.Errorf("Test failed. Expected original spec to be embedded, got version %v", contents["openapi"])
+	}
+}
+
+func TestDowngradeSchemas(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":             []interface{}{"integer", "null"},
+		"exclusiveMinimum": 5,
+	}
+	downgradeSchema(schema)
+
+	if schema["type"] != "integer" || schema["nullable"] != true {
+		t.Errorf("Test failed. Expected nullable integer, got %v", schema)
+	}
+	if schema["minimum"] != 5 || schema["exclusiveMinimum"] != true {
+		t.Errorf("Test failed. Expected boolean exclusive minimum, got %v", schema)
+	}
+}
+
+func TestDowngradeSchemasLeavesPropertyNames(t *testing.T) {
+	doc, err := parseDocument([]byte(`
+openapi: 3.1.0
+components:
+  schemas:
+    Range:
+      type: object
+      properties:
+        exclusiveMinimum:
+          type: [number, "null"]
+          exclusiveMinimum: 0
+        const:
+          type: string
+paths:
+  /ranges:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Range'
+            examples:
+              const:
+                value:
+                  exclusiveMinimum: 1
+`))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	downgradeSchemas(doc.(map[string]interface{}))
+
+	properties := doc.(map[string]interface{})["components"].(map[string]interface{})["schemas"].(map[string]interface{})["Range"].(map[string]interface{})["properties"].(map[string]interface{})
+	property, ok := properties["exclusiveMinimum"].(map[string]interface{})
+	if !ok || len(properties) != 2 || properties["minimum"] != nil {
+		t.Fatalf("Test failed. Expected the properties to keep their names, got %v", properties)
+	}
+	if property["type"] != "number" || property["nullable"] != true || property["minimum"] != 0 || property["exclusiveMinimum"] != true {
+		t.Errorf("Test failed. Expected the property schema to be downgraded, got %v", property)
+	}
+	if _, ok := properties["const"].(map[string]interface{})["enum"]; ok {
+		t.Errorf("Test failed. Expected a property named const not to become an enum, got %v", properties["const"])
+	}
+
+	media := doc.(map[string]interface{})["paths"].(map[string]interface{})["/ranges"].(map[string]interface{})["post"].(map[string]interface{})["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})
+	example := media["examples"].(map[string]interface{})["const"].(map[string]interface{})["value"].(map[string]interface{})
+	if example["exclusiveMinimum"] != 1 || example["minimum"] != nil {
+		t.Errorf("Test failed. Expected named examples to be left alone, got %v", example)
+	}
+}
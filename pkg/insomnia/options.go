@@ -0,0 +1,165 @@
+package insomnia
+
+import (
+	"strings"
+)
+
+// Option customizes the output of a Generator
+type Option func(*Generator)
+
+// defaultRequestSettings are the Insomnia settings of generated requests
+var defaultRequestSettings = RequestSettings{
+	RenderRequestBody: true,
+	EncodeURL:         true,
+	FollowRedirects:   "global",
+	Cookies: CookieSettings{
+		Send:  true,
+		Store: true,
+	},
+	RebuildPath: true,
+}
+
+// defaultFolderDescription describes tag folders whose tag has no description
+const defaultFolderDescription = "Operations related to %s"
+
+// defaultEnvironmentName names the sub-environments of servers without a description
+const defaultEnvironmentName = "OpenAPI env {host}"
+
+// WithDeterministicIDs derives IDs from stable keys and fixes timestamps, so regenerating
+// an unchanged spec produces identical output
+func WithDeterministicIDs() Option {
+	return func(g *Generator) {
+		g.timestamp = deterministicTimestamp
+		g.deterministic = true
+	}
+}
+
+// WithSpecType sets the type declared by generated Insomnia files, for Insomnia versions
+// expecting another export format than spec.insomnia.rest/5.0
+func WithSpecType(specType string) Option {
+	return func(g *Generator) {
+		g.specType = specType
+	}
+}
+
+// WithRequestSettings sets the Insomnia settings of every generated request
+func WithRequestSettings(settings RequestSettings) Option {
+	return func(g *Generator) {
+		g.settings = settings
+	}
+}
+
+// WithFolderDescription sets the description of tag folders whose tag declares none.
+// %s is replaced by the tag; the default is "Operations related to %s".
+func WithFolderDescription(format string) Option {
+	return func(g *Generator) {
+		g.folderDescription = format
+	}
+}
+
+// WithEnvironmentName sets the name of the sub-environments of servers. The {description},
+// {url}, {scheme}, {host} and {base_path} placeholders are replaced by those of the
+// server; names that come out empty fall back to the default, "OpenAPI env {host}",
+// which also names servers without a description when no name is set.
+func WithEnvironmentName(template string) Option {
+	return func(g *Generator) {
+		g.environmentName = template
+	}
+}
+
+// WithoutEmbeddedSpec leaves the OpenAPI document out of generated Insomnia files, which
+// then only hold requests and environments
+func WithoutEmbeddedSpec() Option {
+	return func(g *Generator) {
+		g.omitSpec = true
+	}
+}
+
+// WithResponseExamples appends the documented responses of requests to their descriptions,
+// with an example of every response body
+func WithResponseExamples() Option {
+	return func(g *Generator) {
+		g.responseExamples = true
+	}
+}
+
+// WithResponseTests adds an after-response script to requests, testing that the status
+// code is documented and that JSON bodies match the schema of their response
+func WithResponseTests() Option {
+	return func(g *Generator) {
+		g.responseTests = true
+	}
+}
+
+// WithFolderLayout sets how operations are arranged in folders. Tag folders are always
+// grouped under the x-tagGroups of the spec when it declares them.
+func WithFolderLayout(layout FolderLayout) Option {
+	return func(g *Generator) {
+		g.layout = layout
+	}
+}
+
+// WithSortOrder sets the order of folders and requests. The default keeps the order of the spec.
+func WithSortOrder(order SortOrder) Option {
+	return func(g *Generator) {
+		g.sortOrder = order
+	}
+}
+
+// WithEnvironmentOverlays sets the overlay files applied, in order, to the environments of
+// every generated file. YAML and JSON overlays have the form
+//
+//	variables:
+//	  tenant: acme
+//	environments:
+//	  dev:
+//	    private: true
+//	    variables:
+//	      host: dev.example.com
+//	      api_token: secret
+//	  stage:
+//	    server: Staging server
+//
+// and dotenv files named .env.<environment> (or <environment>.env) set the variables of
+// one sub-environment, or of the base environment when named .env. Dotenv files ending
+// in .private create private sub-environments.
+//
+// An environment overlays the server it names with server, or WithOverlayServers maps it
+// to, then the server of its name, then the server of the host it sets. Other
+// environments become new servers, which must set their host.
+func WithEnvironmentOverlays(files ...string) Option {
+	return func(g *Generator) {
+		g.overlays = files
+	}
+}
+
+// WithOverlayServers maps overlay environments to the name or URL of the server they
+// overlay, e.g. the stage environment of a .env.stage file to "Staging server"
+func WithOverlayServers(servers map[string]string) Option {
+	return func(g *Generator) {
+		g.overlayServers = servers
+	}
+}
+
+// nameEnvironment names the sub-environment of a server
+func (g *Generator) nameEnvironment(description, scheme, host, basePath string) string {
+	template := g.environmentName
+	if template == "" {
+		if description != "" {
+			return description
+		}
+		template = defaultEnvironmentName
+	}
+
+	replacer := strings.NewReplacer(
+		"{description}", description,
+		"{url}", scheme+"://"+host+basePath,
+		"{scheme}", scheme,
+		"{host}", host,
+		"{base_path}", basePath,
+	)
+	if name := strings.TrimSpace(replacer.Replace(template)); name != "" {
+		return name
+	}
+	return strings.NewReplacer("{host}", host).Replace(defaultEnvironmentName)
+}
@@ -0,0 +1,75 @@
+package insomnia
+
+import (
+	"testing"
+)
+
+func TestGeneratorOptions(t *testing.T) {
+	settings := defaultRequestSettings
+	settings.FollowRedirects = "off"
+
+	g := NewGenerator(
+		WithDeterministicIDs(),
+		WithSpecType("spec.insomnia.rest/4.0"),
+		WithRequestSettings(settings),
+		WithFolderDescription("Endpoints of %s"),
+		WithEnvironmentName("{host}{base_path}"),
+		WithoutEmbeddedSpec(),
+	)
+	spec, err := g.GenerateFromOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if spec.Type != "spec.insomnia.rest/4.0" {
+		t.Errorf("Test failed. Expected the spec type option, got %s", spec.Type)
+	}
+	if spec.Meta.Created != deterministicTimestamp {
+		t.Errorf("Test failed. Expected a deterministic timestamp, got %d", spec.Meta.Created)
+	}
+	if spec.Spec.Contents != nil {
+		t.Errorf("Test failed. Expected no embedded spec, got %v", spec.Spec.Contents)
+	}
+
+	for _, folder := range spec.Collection {
+		for _, request := range folder.Children {
+			if request.Settings != settings {
+				t.Errorf("Test failed. Expected the request settings option, got %v", request.Settings)
+			}
+		}
+	}
+
+	api, err := g.ParseOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if len(api.Servers) != 1 || api.Servers[0].Name != "localhost:8082/api/v1" {
+		t.Errorf("Test failed. Expected the environment name template, got %v", api.Servers)
+	}
+}
+
+func TestFolderDescriptionOption(t *testing.T) {
+	api, err := NewGenerator(WithFolderDescription("Endpoints of %s")).ParseOpenAPI([]byte(orderSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if api.Folders[1].Name != "zones" || api.Folders[1].Description != "Endpoints of zones" {
+		t.Errorf("Test failed. Expected the folder description option, got %v", api.Folders[1])
+	}
+}
+
+func TestDefaultGeneratorMatchesDeterministicOption(t *testing.T) {
+	first, err := NewDeterministicGenerator().GenerateFromOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	second, err := NewGenerator(WithDeterministicIDs()).GenerateFromOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if first.Meta.ID != second.Meta.ID || first.Collection[0].Children[0].Settings != defaultRequestSettings {
+		t.Errorf("Test failed. Expected NewDeterministicGenerator to match WithDeterministicIDs, got %s and %s", first.Meta.ID, second.Meta.ID)
+	}
+}
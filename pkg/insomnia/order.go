@@ -0,0 +1,179 @@
+package insomnia
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SortOrder controls the order of folders and requests in generated collections
+type SortOrder string
+
+const (
+	// SortByDocument keeps operations in the order of the paths and methods in the spec
+	// and folders in the order of the top-level tags, then of their first operation
+	SortByDocument SortOrder = "document"
+
+	// SortAlphabetically orders folders and requests by name at every level
+	SortAlphabetically SortOrder = "alphabetical"
+
+	// SortByPath orders requests by path, then by method, and folders as SortByDocument does
+	SortByPath SortOrder = "path"
+)
+
+// ParseSortOrder parses the name of a sort order such as "alphabetical"
+func ParseSortOrder(name string) (SortOrder, error) {
+	order := SortOrder(strings.ToLower(strings.TrimSpace(name)))
+	switch order {
+	case SortByDocument, SortAlphabetically, SortByPath:
+		return order, nil
+	}
+	return "", fmt.Errorf("unsupported sort order %q (expected document, alphabetical or path)", name)
+}
+
+// documentOrder records the order in which paths and their methods appear in a spec,
+// which is lost when the spec is decoded into Go maps
+type documentOrder struct {
+	paths   []string
+	methods map[string][]string
+}
+
+// readDocumentOrder reads the order of the paths and webhooks of YAML or JSON spec data.
+// Webhooks follow the paths, named as they are once normalized to OpenAPI 3.0.
+func readDocumentOrder(data []byte) documentOrder {
+	order := documentOrder{methods: make(map[string][]string)}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return order
+	}
+
+	order.addPaths(mappingValue(root.Content[0], "paths"), "")
+	order.addPaths(mappingValue(root.Content[0], "webhooks"), webhookPathPrefix)
+	return order
+}
+
+// addPaths records the keys of a paths mapping and the methods of each path item
+func (o *documentOrder) addPaths(paths *yaml.Node, prefix string) {
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		path := prefix + paths.Content[i].Value
+		o.paths = append(o.paths, path)
+
+		item := paths.Content[i+1]
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(item.Content); j += 2 {
+			if method := item.Content[j].Value; methodIndex(method) >= 0 {
+				o.methods[path] = append(o.methods[path], method)
+			}
+		}
+	}
+}
+
+// sortedPaths returns the paths of the spec in document order, followed alphabetically
+// by those the order does not know, such as paths of Swagger documents read from elsewhere
+func (o documentOrder) sortedPaths(paths map[string]interface{}) []string {
+	ordered := make([]string, 0, len(paths))
+	seen := make(map[string]bool)
+	for _, path := range o.paths {
+		if _, ok := paths[path]; ok && !seen[path] {
+			ordered = append(ordered, path)
+			seen[path] = true
+		}
+	}
+
+	var rest []string
+	for path := range paths {
+		if !seen[path] {
+			rest = append(rest, path)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(ordered, rest...)
+}
+
+// sortedMethods returns the methods of a path in document order, followed by the
+// remaining HTTP methods in their usual order
+func (o documentOrder) sortedMethods(path string) []string {
+	methods := append([]string{}, o.methods[path]...)
+	seen := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		seen[method] = true
+	}
+	for _, method := range httpMethods {
+		if !seen[method] {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// sortOperations reorders operations read in document order according to the sort order
+func (g *Generator) sortOperations(operations []Operation) {
+	switch g.sortOrder {
+	case SortByPath:
+		sort.SliceStable(operations, func(i, j int) bool {
+			if operations[i].Path != operations[j].Path {
+				return operations[i].Path < operations[j].Path
+			}
+			return methodIndex(strings.ToLower(operations[i].Method)) < methodIndex(strings.ToLower(operations[j].Method))
+		})
+	case SortAlphabetically:
+		sort.SliceStable(operations, func(i, j int) bool {
+			return lessName(operations[i].Name, operations[j].Name)
+		})
+	}
+}
+
+// sortFolders orders folders by name at every level when sorting alphabetically
+func (g *Generator) sortFolders(folders []Folder) {
+	if g.sortOrder != SortAlphabetically {
+		return
+	}
+
+	sort.SliceStable(folders, func(i, j int) bool {
+		return lessName(folders[i].Name, folders[j].Name)
+	})
+	for i := range folders {
+		g.sortFolders(folders[i].Folders)
+	}
+}
+
+// lessName compares names case-insensitively, falling back to a case-sensitive comparison
+func lessName(a, b string) bool {
+	if lowerA, lowerB := strings.ToLower(a), strings.ToLower(b); lowerA != lowerB {
+		return lowerA < lowerB
+	}
+	return a < b
+}
+
+// methodIndex returns the position of a lowercase method in httpMethods, or -1
+func methodIndex(method string) int {
+	for i, candidate := range httpMethods {
+		if candidate == method {
+			return i
+		}
+	}
+	return -1
+}
+
+// mappingValue returns the value of a key of a YAML mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package insomnia
+
+import (
+	"reflect"
+	"testing"
+)
+
+const orderSpec = `
+openapi: 3.0.3
+info:
+  title: Order API
+  version: 1.0.0
+tags:
+  - name: users
+paths:
+  /zones:
+    post:
+      summary: Create zone
+      tags: [zones]
+    get:
+      summary: List zones
+      tags: [zones]
+  /users:
+    get:
+      summary: list users
+      tags: [users]
+  /accounts:
+    get:
+      summary: List accounts
+      tags: [accounts]
+`
+
+const orderJSONSpec = `{
+	"openapi": "3.0.3",
+	"info": {"title": "Order API", "version": "1.0.0"},
+	"paths": {
+		"/b": {"delete": {"summary": "Delete b"}, "get": {"summary": "Get b"}},
+		"/a": {"get": {"summary": "Get a"}}
+	}
+}`
+
+// operationOrder returns the names of the folders and of their operations, in order
+func operationOrder(api *API) (folders, operations []string) {
+	for _, folder := range api.Folders {
+		folders = append(folders, folder.Name)
+	}
+	walkOperations(api.Folders, nil, func(path []string, operation Operation) {
+		operations = append(operations, operation.Name)
+	})
+	return folders, operations
+}
+
+func TestDocumentSortOrder(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		api, err := NewDeterministicGenerator().ParseOpenAPI([]byte(orderSpec))
+		if err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+
+		folders, operations := operationOrder(api)
+		if !reflect.DeepEqual(folders, []string{"users", "zones", "accounts"}) {
+			t.Fatalf("Test failed. Expected declared tags first, then tags by first use, got %v", folders)
+		}
+		if !reflect.DeepEqual(operations, []string{"list users", "Create zone", "List zones", "List accounts"}) {
+			t.Fatalf("Test failed. Expected operations in document order, got %v", operations)
+		}
+	}
+
+	api, err := NewDeterministicGenerator().ParseOpenAPI([]byte(orderJSONSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if _, operations := operationOrder(api); !reflect.DeepEqual(operations, []string{"Delete b", "Get b", "Get a"}) {
+		t.Errorf("Test failed. Expected JSON operations in document order, got %v", operations)
+	}
+}
+
+func TestAlphabeticalSortOrder(t *testing.T) {
+	g := NewDeterministicGenerator(WithSortOrder(SortAlphabetically))
+	api, err := g.ParseOpenAPI([]byte(orderSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	folders, operations := operationOrder(api)
+	if !reflect.DeepEqual(folders, []string{"accounts", "users", "zones"}) {
+		t.Errorf("Test failed. Expected folders by name, got %v", folders)
+	}
+	if !reflect.DeepEqual(operations, []string{"List accounts", "list users", "Create zone", "List zones"}) {
+		t.Errorf("Test failed. Expected operations by name, got %v", operations)
+	}
+}
+
+func TestPathSortOrder(t *testing.T) {
+	g := NewDeterministicGenerator(WithSortOrder(SortByPath), WithFolderLayout(FolderLayout{ByPath: true}))
+	api, err := g.ParseOpenAPI([]byte(orderSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	folders, operations := operationOrder(api)
+	if !reflect.DeepEqual(folders, []string{"accounts", "users", "zones"}) {
+		t.Errorf("Test failed. Expected folders by path, got %v", folders)
+	}
+	if !reflect.DeepEqual(operations, []string{"List accounts", "list users", "List zones", "Create zone"}) {
+		t.Errorf("Test failed. Expected operations by path then method, got %v", operations)
+	}
+}
+
+func TestParseSortOrder(t *testing.T) {
+	if order, err := ParseSortOrder(" Alphabetical "); err != nil || order != SortAlphabetically {
+		t.Errorf("Test failed. Expected alphabetical, got %q (%v)", order, err)
+	}
+	if _, err := ParseSortOrder("random"); err == nil {
+		t.Errorf("Test failed. Expected an error for an unknown sort order")
+	}
+}
@@ -0,0 +1,309 @@
+package insomnia
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// privateOverlaySuffix marks dotenv overlays whose environment must not be synced
+const privateOverlaySuffix = ".private"
+
+// EnvironmentOverlay adds variables to the base environment and adds or overrides the
+// variables of sub-environments, e.g. the per-stage values of a deployment
+type EnvironmentOverlay struct {
+	Variables    map[string]string
+	Environments map[string]OverlayEnvironment
+}
+
+// OverlayEnvironment holds the variables of a sub-environment. The scheme, host and
+// base_path variables set the server URL; private environments are not synced or shared.
+type OverlayEnvironment struct {
+	// Server is the name or URL of the server the environment overlays, when it is not
+	// named after it
+	Server    string
+	Private   bool
+	Variables map[string]string
+}
+
+// serverVariables are the variables of a sub-environment that make up its base URL
+var serverVariables = map[string]bool{"scheme": true, "host": true, "base_path": true}
+
+// ReadEnvironmentOverlay reads an overlay file in YAML, JSON or dotenv format
+func ReadEnvironmentOverlay(file string) (*EnvironmentOverlay, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read environment overlay: %w", err)
+	}
+
+	var overlay *EnvironmentOverlay
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yml", ".yaml", ".json":
+		overlay, err = parseOverlayDocument(data)
+	default:
+		overlay, err = parseDotenvOverlay(data, filepath.Base(file))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse environment overlay %s: %w", file, err)
+	}
+	return overlay, nil
+}
+
+// parseOverlayDocument parses a YAML or JSON overlay
+func parseOverlayDocument(data []byte) (*EnvironmentOverlay, error) {
+	document, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	root, ok := document.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("overlay is not a mapping")
+	}
+
+	overlay := &EnvironmentOverlay{Environments: make(map[string]OverlayEnvironment)}
+	if overlay.Variables, err = overlayVariables(root["variables"]); err != nil {
+		return nil, err
+	}
+
+	environments, ok := root["environments"].(map[string]interface{})
+	if !ok && root["environments"] != nil {
+		return nil, fmt.Errorf("environments is not a mapping")
+	}
+	for name, value := range environments {
+		envData, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("environment %s is not a mapping", name)
+		}
+
+		env := OverlayEnvironment{Server: scalarString(envData["server"]), Private: envData["private"] == true}
+		if env.Variables, err = overlayVariables(envData["variables"]); err != nil {
+			return nil, fmt.Errorf("environment %s: %w", name, err)
+		}
+		overlay.Environments[name] = env
+	}
+
+	return overlay, nil
+}
+
+// overlayVariables converts a mapping of variables to strings
+func overlayVariables(node interface{}) (map[string]string, error) {
+	variables := make(map[string]string)
+	if node == nil {
+		return variables, nil
+	}
+
+	values, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("variables is not a mapping")
+	}
+	for name, value := range values {
+		variables[name] = scalarString(value)
+	}
+	return variables, nil
+}
+
+// parseDotenvOverlay parses a dotenv overlay, naming its environment after the file
+func parseDotenvOverlay(data []byte, fileName string) (*EnvironmentOverlay, error) {
+	variables := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(strings.TrimPrefix(text, "export "), "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected NAME=value", line)
+		}
+		variables[strings.TrimSpace(name)] = unquoteDotenv(strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	private := strings.HasSuffix(fileName, privateOverlaySuffix)
+	name := strings.TrimSuffix(fileName, privateOverlaySuffix)
+	switch {
+	case strings.HasPrefix(name, ".env."):
+		name = strings.TrimPrefix(name, ".env.")
+	case strings.HasSuffix(name, ".env"):
+		name = strings.TrimSuffix(name, ".env")
+	default:
+		name = ""
+	}
+
+	overlay := &EnvironmentOverlay{Environments: make(map[string]OverlayEnvironment)}
+	if name == "" && !private {
+		overlay.Variables = variables
+	} else {
+		if name == "" {
+			name = "Private"
+		}
+		overlay.Environments[name] = OverlayEnvironment{Private: private, Variables: variables}
+	}
+	return overlay, nil
+}
+
+// unquoteDotenv removes the quotes of a dotenv value, or a trailing comment of an unquoted one
+func unquoteDotenv(value string) string {
+	if len(value) >= 2 {
+		if quote := value[0]; (quote == '"' || quote == '\'') && value[len(value)-1] == quote {
+			return value[1 : len(value)-1]
+		}
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+// ParseOverlayServers parses environment=server pairs such as "dev=Local development
+// server,stage=Staging server", mapping overlay environments to the server they overlay
+func ParseOverlayServers(list string) (map[string]string, error) {
+	servers := make(map[string]string)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+
+		name, server, ok := strings.Cut(item, "=")
+		name, server = strings.TrimSpace(name), strings.TrimSpace(server)
+		if !ok || name == "" || server == "" {
+			return nil, fmt.Errorf("overlay server %q is not environment=server", item)
+		}
+		servers[name] = server
+	}
+	return servers, nil
+}
+
+// applyOverlays reads the overlay files of the generator and applies them to the API
+func (g *Generator) applyOverlays(api *API) error {
+	for _, file := range g.overlays {
+		overlay, err := ReadEnvironmentOverlay(file)
+		if err != nil {
+			return err
+		}
+		if err := overlay.apply(api, g.overlayServers); err != nil {
+			return fmt.Errorf("failed to apply environment overlay %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// apply adds the variables of the overlay to the base environment and to the servers its
+// environments match, the servers mapping environments to server names or URLs. Other
+// environments become new servers.
+func (o *EnvironmentOverlay) apply(api *API, servers map[string]string) error {
+	for name, value := range o.Variables {
+		if serverVariables[name] || name == "base_url" {
+			return fmt.Errorf("%s cannot be set in the base environment, set it per environment", name)
+		}
+		api.Variables[name] = value
+	}
+
+	names := make([]string, 0, len(o.Environments))
+	for name := range o.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		env := o.Environments[name]
+		if env.Variables["base_url"] != "" {
+			return fmt.Errorf("environment %s: set scheme, host and base_path instead of base_url", name)
+		}
+
+		if env.Server == "" {
+			env.Server = servers[name]
+		}
+		server, err := overlayServer(api, name, env)
+		if err != nil {
+			return err
+		}
+		server.Private = server.Private || env.Private
+		for variable, value := range env.Variables {
+			switch variable {
+			case "scheme":
+				server.Scheme = value
+			case "host":
+				server.Host = value
+			case "base_path":
+				server.BasePath = value
+			default:
+				if server.Variables == nil {
+					server.Variables = make(map[string]string)
+				}
+				server.Variables[variable] = value
+			}
+		}
+	}
+
+	return nil
+}
+
+// overlayServer returns the server an overlay environment overlays: the server it maps
+// to, else the server of its name, else the server of the host it sets. It adds a server
+// when the environment sets the host of none.
+func overlayServer(api *API, name string, env OverlayEnvironment) (*Server, error) {
+	if env.Server != "" {
+		for i := range api.Servers {
+			if strings.EqualFold(api.Servers[i].Name, env.Server) || sameServerURL(api.Servers[i], env.Server) {
+				return &api.Servers[i], nil
+			}
+		}
+		return nil, fmt.Errorf("environment %s: no server is named %s or has its URL (servers: %s)", name, env.Server, serverNames(api))
+	}
+
+	for i := range api.Servers {
+		if strings.EqualFold(api.Servers[i].Name, name) {
+			return &api.Servers[i], nil
+		}
+	}
+
+	host := env.Variables["host"]
+	if host == "" {
+		return nil, fmt.Errorf("environment %s matches no server (servers: %s): name it after one, map it to one (server, overlayServers or -overlay-server) or set its host", name, serverNames(api))
+	}
+	for i := range api.Servers {
+		server := api.Servers[i]
+		if strings.EqualFold(server.Host, host) &&
+			(env.Variables["scheme"] == "" || strings.EqualFold(server.Scheme, env.Variables["scheme"])) &&
+			(env.Variables["base_path"] == "" || strings.TrimSuffix(server.BasePath, "/") == strings.TrimSuffix(env.Variables["base_path"], "/")) {
+			return &api.Servers[i], nil
+		}
+	}
+
+	// The environment's own scheme, host and base_path are set by apply
+	api.Servers = append(api.Servers, Server{Key: "overlay:" + name, Name: name, Scheme: "https"})
+	return &api.Servers[len(api.Servers)-1], nil
+}
+
+// sameServerURL reports whether a URL, with or without its scheme, is the URL of a server
+func sameServerURL(server Server, serverURL string) bool {
+	if scheme, rest, ok := strings.Cut(serverURL, "://"); ok {
+		if !strings.EqualFold(scheme, server.Scheme) {
+			return false
+		}
+		serverURL = rest
+	}
+	return strings.EqualFold(strings.TrimSuffix(serverURL, "/"), strings.TrimSuffix(server.Host+server.BasePath, "/"))
+}
+
+// serverNames lists the names of the servers of an API
+func serverNames(api *API) string {
+	if len(api.Servers) == 0 {
+		return "none"
+	}
+	names := make([]string, len(api.Servers))
+	for i, server := range api.Servers {
+		names[i] = server.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,208 @@
+package insomnia
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const overlaySpec = `
+openapi: 3.0.3
+info:
+  title: Overlay API
+  version: 1.0.0
+servers:
+  - url: https://api.example.com/v1
+    description: Production
+paths:
+  /health:
+    get:
+      summary: Health
+`
+
+// writeTestFile writes a file to dir and returns its path
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	return file
+}
+
+func TestReadDotenvOverlay(t *testing.T) {
+	dir := t.TempDir()
+	file := writeTestFile(t, dir, ".env.stage", "# Stage values\nSCOPE                 = \"stage\"\nexport PORT=8125 # statsd\nTOKEN='a=b'\n")
+
+	overlay, err := ReadEnvironmentOverlay(file)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	stage, ok := overlay.Environments["stage"]
+	if !ok || stage.Private {
+		t.Fatalf("Test failed. Expected a public stage environment, got %v", overlay.Environments)
+	}
+	expected := map[string]string{"SCOPE": "stage", "PORT": "8125", "TOKEN": "a=b"}
+	for name, value := range expected {
+		if stage.Variables[name] != value {
+			t.Errorf("Test failed. Expected %s=%s, got %q", name, value, stage.Variables[name])
+		}
+	}
+
+	overlay, err = ReadEnvironmentOverlay(writeTestFile(t, dir, ".env", "TENANT=acme\n"))
+	if err != nil || overlay.Variables["TENANT"] != "acme" {
+		t.Errorf("Test failed. Expected .env to set base variables, got %v (%v)", overlay, err)
+	}
+
+	overlay, err = ReadEnvironmentOverlay(writeTestFile(t, dir, ".env.dev.private", "TOKEN=secret\n"))
+	if err != nil || !overlay.Environments["dev"].Private {
+		t.Errorf("Test failed. Expected a private dev environment, got %v (%v)", overlay, err)
+	}
+
+	if _, err := ReadEnvironmentOverlay(writeTestFile(t, dir, ".env.bad", "NOT A VARIABLE\n")); err == nil {
+		t.Errorf("Test failed. Expected an error for a malformed line")
+	}
+}
+
+func TestEnvironmentOverlays(t *testing.T) {
+	dir := t.TempDir()
+	overlay := writeTestFile(t, dir, "overlay.yml", `
+variables:
+  tenant: acme
+environments:
+  production:
+    variables:
+      host: prod.example.com
+      region: eu
+  Local secrets:
+    private: true
+    variables:
+      scheme: http
+      host: localhost:8080
+      api_token: secret
+`)
+
+	g := NewDeterministicGenerator(WithEnvironmentOverlays(overlay))
+	spec, err := g.GenerateFromOpenAPI([]byte(overlaySpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	env := spec.Environments
+	if env.Data.Extra["tenant"] != "acme" {
+		t.Errorf("Test failed. Expected the base variable, got %v", env.Data.Extra)
+	}
+	if len(env.SubEnvironments) != 2 {
+		t.Fatalf("Test failed. Expected the server and the private environment, got %v", env.SubEnvironments)
+	}
+
+	production := env.SubEnvironments[0]
+	if production.Data.Host != "prod.example.com" || production.Data.Extra["region"] != "eu" || production.Meta.IsPrivate {
+		t.Errorf("Test failed. Expected the overlay to override the production server, got %v", production)
+	}
+
+	secrets := env.SubEnvironments[1]
+	if secrets.Name != "Local secrets" || !secrets.Meta.IsPrivate || secrets.Data.Scheme != "http" || secrets.Data.Host != "localhost:8080" || secrets.Data.BasePath != "" || secrets.Data.Extra["api_token"] != "secret" {
+		t.Errorf("Test failed. Expected a private environment of its own URL, got %v", secrets)
+	}
+
+	api, err := g.ParseOpenAPI([]byte(overlaySpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	files := g.BuildHTTPFiles(api)
+	if strings.Contains(files[httpEnvironmentFile], "api_token") || !strings.Contains(files[httpPrivateEnvironmentFile], `"api_token": "secret"`) {
+		t.Errorf("Test failed. Expected private variables in %s only, got %v", httpPrivateEnvironmentFile, files)
+	}
+}
+
+func TestEnvironmentOverlayServerMatching(t *testing.T) {
+	spec := strings.Replace(overlaySpec, "    description: Production", `    description: Production
+  - url: https://trafi.stage.com/v1
+    description: Staging server`, 1)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		options []Option
+	}{
+		{"mapped in the overlay", "overlay.yml", "environments:\n  stage:\n    server: Staging server\n    variables:\n      SCOPE: stage\n", nil},
+		{"mapped to a URL", "overlay.yml", "environments:\n  stage:\n    server: https://trafi.stage.com/v1/\n    variables:\n      SCOPE: stage\n", nil},
+		{"mapped by option", ".env.stage", "SCOPE=stage\n", []Option{WithOverlayServers(map[string]string{"stage": "staging server"})}},
+		{"matched by host", ".env.stage", "host=TRAFI.stage.com\nSCOPE=stage\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]Option{WithEnvironmentOverlays(writeTestFile(t, dir, tt.file, tt.content))}, tt.options...)
+			api, err := NewDeterministicGenerator(options...).ParseOpenAPI([]byte(spec))
+			if err != nil {
+				t.Fatalf("Test shouldnt have failed: %v", err)
+			}
+
+			if len(api.Servers) != 2 {
+				t.Fatalf("Test failed. Expected the overlay to add no server, got %v", api.Servers)
+			}
+			staging := api.Servers[1]
+			if staging.Name != "Staging server" || !strings.EqualFold(staging.Host, "trafi.stage.com") || staging.Variables["SCOPE"] != "stage" {
+				t.Errorf("Test failed. Expected the overlay to set SCOPE of the staging server, got %v", staging)
+			}
+			if api.Servers[0].Variables["SCOPE"] != "" {
+				t.Errorf("Test failed. Expected the production server to be left alone, got %v", api.Servers[0])
+			}
+		})
+	}
+
+	g := NewDeterministicGenerator(WithEnvironmentOverlays(writeTestFile(t, dir, ".env.stage", "SCOPE=stage\n")))
+	if _, err := g.ParseOpenAPI([]byte(spec)); err == nil || !strings.Contains(err.Error(), "matches no server") {
+		t.Errorf("Test failed. Expected an error for an environment matching no server, got %v", err)
+	}
+
+	g = NewDeterministicGenerator(WithEnvironmentOverlays(writeTestFile(t, dir, ".env.stage", "SCOPE=stage\n")), WithOverlayServers(map[string]string{"stage": "QA server"}))
+	if _, err := g.ParseOpenAPI([]byte(spec)); err == nil || !strings.Contains(err.Error(), "no server is named QA server") {
+		t.Errorf("Test failed. Expected an error for a mapping to a missing server, got %v", err)
+	}
+}
+
+func TestEnvironmentOverlayEKSConfiguration(t *testing.T) {
+	overlay := filepath.Join("..", "..", "configuration", "eks", ".env.dev")
+	servers, err := ParseOverlayServers("dev=Local development server")
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	g := NewDeterministicGenerator(WithEnvironmentOverlays(overlay), WithOverlayServers(servers))
+	api, err := g.ParseFile(filepath.Join("..", "..", "address.yml"))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	local := api.Servers[0]
+	if local.Name != "Local development server" || local.Host != "localhost:8082" || local.Variables["SCOPE"] != "dev" || local.Variables["DOGSTATSD_PORT"] != "8125" {
+		t.Errorf("Test failed. Expected .env.dev to overlay the local development server, got %+v", local)
+	}
+	if len(api.Servers) != 3 || api.Servers[1].Variables["SCOPE"] != "" {
+		t.Errorf("Test failed. Expected the other servers to be left alone, got %+v", api.Servers)
+	}
+
+	if _, err := ParseOverlayServers("dev"); err == nil {
+		t.Errorf("Test failed. Expected an error for an overlay server without a server")
+	}
+}
+
+func TestEnvironmentOverlayErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	g := NewDeterministicGenerator(WithEnvironmentOverlays(writeTestFile(t, dir, "overlay.json", `{"variables": {"host": "example.com"}}`)))
+	if _, err := g.ParseOpenAPI([]byte(overlaySpec)); err == nil || !strings.Contains(err.Error(), "host cannot be set") {
+		t.Errorf("Test failed. Expected an error for a server variable in the base environment, got %v", err)
+	}
+
+	g = NewDeterministicGenerator(WithEnvironmentOverlays(filepath.Join(dir, "missing.yml")))
+	if _, err := g.ParseOpenAPI([]byte(overlaySpec)); err == nil {
+		t.Errorf("Test failed. Expected an error for a missing overlay")
+	}
+}
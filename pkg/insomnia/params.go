@@ -0,0 +1,142 @@
+package insomnia
+
+import (
+	"sort"
+	"strings"
+)
+
+// reservedHeaders are header parameters OpenAPI says to ignore, since they are
+// controlled by the request body, security and content negotiation
+var reservedHeaders = map[string]bool{
+	"accept":        true,
+	"content-type":  true,
+	"authorization": true,
+}
+
+// collectParameters merges path-level and operation-level parameters, letting the
+// operation override path-level parameters with the same name and location
+func collectParameters(pathItem, operation map[string]interface{}, refs *refResolver) []map[string]interface{} {
+	var params []map[string]interface{}
+	index := make(map[string]int)
+
+	for _, source := range []map[string]interface{}{pathItem, operation} {
+		list, _ := source["parameters"].([]interface{})
+		for _, item := range list {
+			param := refs.resolveMap(item)
+			if param == nil {
+				continue
+			}
+
+			name, _ := param["name"].(string)
+			in, _ := param["in"].(string)
+			if name == "" || in == "" {
+				continue
+			}
+
+			key := in + ":" + name
+			if i, ok := index[key]; ok {
+				params[i] = param
+				continue
+			}
+
+			index[key] = len(params)
+			params = append(params, param)
+		}
+	}
+
+	return params
+}
+
+// buildPathParameters creates the path parameters of a request from their example values
+func buildPathParameters(params []map[string]interface{}, refs *refResolver) []Parameter {
+	var result []Parameter
+	for _, param := range params {
+		if param["in"] != "path" {
+			continue
+		}
+
+		name := param["name"].(string)
+		result = append(result, Parameter{Name: name, Value: parameterValues(param, refs)[0]})
+	}
+	return result
+}
+
+// buildQueryParameters creates the query string parameters of a request.
+// Optional parameters are included but disabled.
+func buildQueryParameters(params []map[string]interface{}, refs *refResolver) []Parameter {
+	var result []Parameter
+	for _, param := range params {
+		if param["in"] != "query" {
+			continue
+		}
+
+		name := param["name"].(string)
+		disabled := param["required"] != true
+		for _, value := range parameterValues(param, refs) {
+			result = append(result, Parameter{Name: name, Value: value, Disabled: disabled})
+		}
+	}
+	return result
+}
+
+// buildHeaders creates the headers of a request, including the Content-Type of its body.
+// Optional headers are included but disabled.
+func buildHeaders(params []map[string]interface{}, body *Body, refs *refResolver) []Parameter {
+	var result []Parameter
+	if body != nil {
+		result = append(result, Parameter{Name: "Content-Type", Value: body.MimeType})
+	}
+
+	for _, param := range params {
+		name := param["name"].(string)
+		if param["in"] != "header" || reservedHeaders[strings.ToLower(name)] {
+			continue
+		}
+
+		result = append(result, Parameter{
+			Name:     name,
+			Value:    strings.Join(parameterValues(param, refs), ","),
+			Disabled: param["required"] != true,
+		})
+	}
+	return result
+}
+
+// parameterValues returns the example values of a parameter, taken from its
+// example, examples, or the example/default of its schema. Array values are
+// expanded into one value per item.
+func parameterValues(param map[string]interface{}, refs *refResolver) []string {
+	value, found := param["example"]
+
+	if !found {
+		if examples := refs.resolveMap(param["examples"]); len(examples) > 0 {
+			names := make([]string, 0, len(examples))
+			for name := range examples {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			if example := refs.resolveMap(examples[names[0]]); example != nil {
+				value, found = example["value"]
+			}
+		}
+	}
+
+	if !found {
+		if schema := refs.resolveMap(param["schema"]); schema != nil {
+			if value, found = schema["example"]; !found {
+				value, found = schema["default"]
+			}
+		}
+	}
+
+	if items, ok := value.([]interface{}); ok && len(items) > 0 {
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			values = append(values, scalarString(item))
+		}
+		return values
+	}
+
+	return []string{scalarString(value)}
+}
@@ -0,0 +1,449 @@
+package insomnia
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// postmanSchema is the schema URL identifying Postman Collection v2.1 files
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// Postman file name suffixes
+const (
+	postmanCollectionSuffix  = ".postman_collection.json"
+	postmanEnvironmentSuffix = ".postman_environment.json"
+)
+
+// PostmanExport holds a Postman collection and one environment per server of the API
+type PostmanExport struct {
+	Collection   PostmanCollection
+	Environments []PostmanEnvironment
+}
+
+// PostmanCollection represents a Postman Collection v2.1
+type PostmanCollection struct {
+	Info     PostmanInfo       `json:"info"`
+	Item     []PostmanItem     `json:"item"`
+	Variable []PostmanVariable `json:"variable,omitempty"`
+}
+
+// PostmanInfo contains the collection information
+type PostmanInfo struct {
+	PostmanID   string `json:"_postman_id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// PostmanItem represents a folder (with Item) or a request (with Request)
+type PostmanItem struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Item        []PostmanItem   `json:"item,omitempty"`
+	Request     *PostmanRequest `json:"request,omitempty"`
+}
+
+// PostmanRequest represents an individual API request
+type PostmanRequest struct {
+	Method      string            `json:"method"`
+	Header      []PostmanKeyValue `json:"header"`
+	Body        *PostmanBody      `json:"body,omitempty"`
+	URL         PostmanURL        `json:"url"`
+	Auth        *PostmanAuth      `json:"auth,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+// PostmanURL represents a request URL, both raw and split into its parts
+type PostmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path,omitempty"`
+	Query    []PostmanKeyValue `json:"query,omitempty"`
+	Variable []PostmanKeyValue `json:"variable,omitempty"`
+}
+
+// PostmanKeyValue represents a header, query parameter, path variable or form field
+type PostmanKeyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Type     string `json:"type,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// PostmanBody contains the request payload in the field named by Mode
+type PostmanBody struct {
+	Mode       string              `json:"mode"`
+	Raw        string              `json:"raw,omitempty"`
+	URLEncoded []PostmanKeyValue   `json:"urlencoded,omitempty"`
+	FormData   []PostmanKeyValue   `json:"formdata,omitempty"`
+	Options    *PostmanBodyOptions `json:"options,omitempty"`
+}
+
+// PostmanBodyOptions tells Postman how to highlight a raw body
+type PostmanBodyOptions struct {
+	Raw PostmanRawOptions `json:"raw"`
+}
+
+// PostmanRawOptions contains the language of a raw body
+type PostmanRawOptions struct {
+	Language string `json:"language"`
+}
+
+// PostmanAuth configures how Postman authenticates a request. Its attributes are
+// stored in the field named after Type.
+type PostmanAuth struct {
+	Type   string                 `json:"type"`
+	Bearer []PostmanAuthAttribute `json:"bearer,omitempty"`
+	Basic  []PostmanAuthAttribute `json:"basic,omitempty"`
+	Digest []PostmanAuthAttribute `json:"digest,omitempty"`
+	APIKey []PostmanAuthAttribute `json:"apikey,omitempty"`
+	OAuth2 []PostmanAuthAttribute `json:"oauth2,omitempty"`
+}
+
+// PostmanAuthAttribute is a single setting of an authentication method
+type PostmanAuthAttribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// PostmanVariable represents a collection variable
+type PostmanVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// PostmanEnvironment represents an exported Postman environment
+type PostmanEnvironment struct {
+	ID     string                    `json:"id"`
+	Name   string                    `json:"name"`
+	Values []PostmanEnvironmentValue `json:"values"`
+	Scope  string                    `json:"_postman_variable_scope"`
+}
+
+// PostmanEnvironmentValue represents a variable of an environment
+type PostmanEnvironmentValue struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
+
+// GeneratePostmanFromOpenAPI converts an OpenAPI spec (YAML or JSON) to a Postman collection
+// and environments
+func (g *Generator) GeneratePostmanFromOpenAPI(openAPIData []byte) (*PostmanExport, error) {
+	api, err := g.ParseOpenAPI(openAPIData)
+	if err != nil {
+		return nil, err
+	}
+	return g.BuildPostman(api), nil
+}
+
+// GeneratePostmanFromFile reads an OpenAPI file and converts it to a Postman collection
+// and environments
+func (g *Generator) GeneratePostmanFromFile(openAPIFile string) (*PostmanExport, error) {
+	api, err := g.ParseFile(openAPIFile)
+	if err != nil {
+		return nil, err
+	}
+	return g.BuildPostman(api), nil
+}
+
+// GeneratePostmanToFile writes the Postman collection of an OpenAPI file to collectionFile
+// and each environment next to it, named <collection>.<server>.postman_environment.json
+func (g *Generator) GeneratePostmanToFile(openAPIFile, collectionFile string) error {
+	export, err := g.GeneratePostmanFromFile(openAPIFile)
+	if err != nil {
+		return fmt.Errorf("failed to generate Postman collection: %w", err)
+	}
+
+	_, err = writePostmanFiles(collectionFile, export)
+	return err
+}
+
+// writePostmanFiles writes a Postman collection to collectionFile and each environment
+// next to it, leaving unchanged files untouched. It reports whether any file was written.
+func writePostmanFiles(collectionFile string, export *PostmanExport) (bool, error) {
+	written, err := writeJSONFile(collectionFile, export.Collection)
+	if err != nil {
+		return written, err
+	}
+
+	for _, env := range export.Environments {
+		changed, err := writeJSONFile(postmanEnvironmentFileName(collectionFile, env.Name), env)
+		if err != nil {
+			return written, err
+		}
+		written = written || changed
+	}
+
+	return written, nil
+}
+
+// BuildPostman converts the API model to a Postman collection and environments
+func (g *Generator) BuildPostman(api *API) *PostmanExport {
+	collection := PostmanCollection{
+		Info: PostmanInfo{
+			PostmanID:   g.generateUUID("collection", api.Title),
+			Name:        fmt.Sprintf("%s %s", api.Title, api.Version),
+			Description: api.Description,
+			Schema:      postmanSchema,
+		},
+		Item: []PostmanItem{},
+	}
+
+	for _, folder := range api.Folders {
+		collection.Item = append(collection.Item, postmanFolder(folder))
+	}
+
+	// Collection variables make the collection usable before an environment is selected
+	baseURL := "http://localhost"
+	if len(api.Servers) > 0 {
+		baseURL = api.Servers[0].URL()
+	}
+	collection.Variable = append(collection.Variable, PostmanVariable{Key: "base_url", Value: baseURL, Type: "string"})
+	for _, name := range sortedKeys(api.Variables) {
+		collection.Variable = append(collection.Variable, PostmanVariable{Key: name, Value: api.Variables[name], Type: "string"})
+	}
+
+	export := &PostmanExport{Collection: collection}
+	for _, server := range api.Servers {
+		// Postman masks secret values and does not share their current value
+		valueType := "default"
+		if server.Private {
+			valueType = "secret"
+		}
+
+		env := PostmanEnvironment{
+			ID:    g.generateUUID("environment", api.Title, server.Key),
+			Name:  server.Name,
+			Scope: "environment",
+			Values: []PostmanEnvironmentValue{
+				{Key: "base_url", Value: server.URL(), Type: "default", Enabled: true},
+			},
+		}
+		for _, name := range sortedKeys(server.Variables) {
+			env.Values = append(env.Values, PostmanEnvironmentValue{Key: name, Value: server.Variables[name], Type: valueType, Enabled: true})
+		}
+		for _, name := range sortedKeys(api.Variables) {
+			env.Values = append(env.Values, PostmanEnvironmentValue{Key: name, Value: api.Variables[name], Type: "default", Enabled: true})
+		}
+		export.Environments = append(export.Environments, env)
+	}
+
+	return export
+}
+
+// postmanFolder converts a folder and its sub-folders to a Postman item group
+func postmanFolder(folder Folder) PostmanItem {
+	item := PostmanItem{Name: folder.Name, Description: folder.Description}
+	for _, subFolder := range folder.Folders {
+		item.Item = append(item.Item, postmanFolder(subFolder))
+	}
+	for _, operation := range folder.Operations {
+		item.Item = append(item.Item, PostmanItem{
+			Name:    operation.Name,
+			Request: postmanRequest(operation),
+		})
+	}
+	return item
+}
+
+// postmanRequest converts an operation to a Postman request
+func postmanRequest(operation Operation) *PostmanRequest {
+	request := &PostmanRequest{
+		Method:      operation.Method,
+		Header:      []PostmanKeyValue{},
+		Body:        postmanBody(operation.Body),
+		URL:         postmanURL(operation),
+		Auth:        postmanAuth(operation.Auth),
+		Description: operation.Description,
+	}
+
+	for _, header := range operation.Headers {
+		request.Header = append(request.Header, PostmanKeyValue{Key: header.Name, Value: header.Value, Disabled: header.Disabled})
+	}
+
+	// Postman API keys cannot be sent as cookies, so send the cookie header instead
+	if auth := operation.Auth; auth != nil && auth.Type == "apikey" && auth.In == "cookie" {
+		request.Header = append(request.Header, PostmanKeyValue{Key: "Cookie", Value: auth.Key + "=" + auth.Value})
+	}
+
+	return request
+}
+
+// postmanURL builds the URL of an operation, turning path parameters into :name path variables
+func postmanURL(operation Operation) PostmanURL {
+	if operation.Webhook {
+		return PostmanURL{Raw: operation.URL, Host: []string{operation.URL}}
+	}
+
+	result := PostmanURL{
+		Host: []string{"{{base_url}}"},
+		Path: pathVariableSegments(operation),
+	}
+
+	for _, param := range operation.PathParameters {
+		result.Variable = append(result.Variable, PostmanKeyValue{Key: param.Name, Value: param.Value})
+	}
+	for _, param := range operation.Query {
+		result.Query = append(result.Query, PostmanKeyValue{Key: param.Name, Value: param.Value, Disabled: param.Disabled})
+	}
+
+	result.Raw = "{{base_url}}/" + strings.Join(result.Path, "/")
+	if query := queryString(operation.Query, false); query != "" {
+		result.Raw += "?" + query
+	}
+	return result
+}
+
+// postmanBody converts a request body to Postman's body modes
+func postmanBody(body *Body) *PostmanBody {
+	if body == nil {
+		return nil
+	}
+
+	switch body.MimeType {
+	case "application/x-www-form-urlencoded":
+		result := &PostmanBody{Mode: "urlencoded"}
+		for _, param := range body.Params {
+			result.URLEncoded = append(result.URLEncoded, PostmanKeyValue{Key: param.Name, Value: param.Value})
+		}
+		return result
+	case "multipart/form-data":
+		result := &PostmanBody{Mode: "formdata"}
+		for _, param := range body.Params {
+			result.FormData = append(result.FormData, PostmanKeyValue{Key: param.Name, Value: param.Value, Type: "text"})
+		}
+		return result
+	}
+
+	language := "text"
+	switch {
+	case isJSONMediaType(body.MimeType):
+		language = "json"
+	case strings.HasSuffix(body.MimeType, "xml"):
+		language = "xml"
+	}
+
+	return &PostmanBody{
+		Mode:    "raw",
+		Raw:     body.Text,
+		Options: &PostmanBodyOptions{Raw: PostmanRawOptions{Language: language}},
+	}
+}
+
+// postmanAuth converts the authentication of an operation to Postman's format
+func postmanAuth(auth *Auth) *PostmanAuth {
+	if auth == nil {
+		return nil
+	}
+
+	switch auth.Type {
+	case "bearer":
+		return &PostmanAuth{Type: "bearer", Bearer: postmanAuthAttributes("token", auth.Token)}
+	case "basic":
+		return &PostmanAuth{Type: "basic", Basic: postmanAuthAttributes("username", auth.Username, "password", auth.Password)}
+	case "digest":
+		return &PostmanAuth{Type: "digest", Digest: postmanAuthAttributes("username", auth.Username, "password", auth.Password)}
+	case "apikey":
+		if auth.In == "cookie" {
+			return nil
+		}
+		in := "header"
+		if auth.In == "query" {
+			in = "query"
+		}
+		return &PostmanAuth{Type: "apikey", APIKey: postmanAuthAttributes("key", auth.Key, "value", auth.Value, "in", in)}
+	case "oauth2":
+		return &PostmanAuth{Type: "oauth2", OAuth2: postmanAuthAttributes(
+			"grant_type", auth.GrantType,
+			"authUrl", auth.AuthorizationURL,
+			"accessTokenUrl", auth.AccessTokenURL,
+			"clientId", auth.ClientID,
+			"clientSecret", auth.ClientSecret,
+			"scope", strings.Join(auth.Scopes, " "),
+			"addTokenTo", "header",
+		)}
+	}
+
+	return nil
+}
+
+// postmanAuthAttributes builds authentication attributes from key/value pairs, skipping empty values
+func postmanAuthAttributes(pairs ...string) []PostmanAuthAttribute {
+	var attributes []PostmanAuthAttribute
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			attributes = append(attributes, PostmanAuthAttribute{Key: pairs[i], Value: pairs[i+1], Type: "string"})
+		}
+	}
+	return attributes
+}
+
+// postmanEnvironmentFileName names the file of an environment after its collection file
+func postmanEnvironmentFileName(collectionFile, envName string) string {
+	base := strings.TrimSuffix(collectionFile, postmanCollectionSuffix)
+	if base == collectionFile {
+		base = strings.TrimSuffix(collectionFile, filepath.Ext(collectionFile))
+	}
+
+	slug := strings.Trim(variableNamePattern.ReplaceAllString(strings.ToLower(envName), "-"), "-")
+	return fmt.Sprintf("%s.%s%s", base, slug, postmanEnvironmentSuffix)
+}
+
+// generateUUID generates a UUID-formatted ID, as used by Postman, hashed from the keys
+// when the generator is deterministic and random otherwise
+func (g *Generator) generateUUID(keys ...string) string {
+	var id [16]byte
+	if g.deterministic {
+		hash := sha256.Sum256([]byte("uuid\x00" + strings.Join(keys, "\x00")))
+		copy(id[:], hash[:])
+	} else if _, err := rand.Read(id[:]); err != nil {
+		// Fallback to timestamp-based ID
+		hash := sha256.Sum256([]byte(fmt.Sprintf("%d%d", g.timestamp, time.Now().UnixNano())))
+		copy(id[:], hash[:])
+	}
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
+}
+
+// writeJSONFile writes a value as indented JSON, without escaping HTML characters, unless
+// the file already holds it. It reports whether the file was written.
+func writeJSONFile(file string, value interface{}) (bool, error) {
+	data, err := marshalJSON(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal %s: %w", filepath.Base(file), err)
+	}
+
+	return writeFileIfChanged(file, data, 0644)
+}
+
+// marshalJSON renders a value as tab-indented JSON, without escaping HTML characters
+func marshalJSON(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "\t")
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sortedKeys returns the keys of a string map in alphabetical order
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
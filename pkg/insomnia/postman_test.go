@@ -0,0 +1,121 @@
+package insomnia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func findPostmanItem(collection PostmanCollection, name string) *PostmanItem {
+	for i := range collection.Item {
+		for j := range collection.Item[i].Item {
+			if collection.Item[i].Item[j].Name == name {
+				return &collection.Item[i].Item[j]
+			}
+		}
+	}
+	return nil
+}
+
+func TestGeneratePostmanCollection(t *testing.T) {
+	export, err := NewDeterministicGenerator().GeneratePostmanFromOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	collection := export.Collection
+	if collection.Info.Schema != postmanSchema || collection.Info.Name != "Test API 1.0.0" {
+		t.Errorf("Test failed. Expected v2.1 collection info, got %v", collection.Info)
+	}
+	if len(collection.Item) != 4 {
+		t.Errorf("Test failed. Expected one folder per tag, got %d", len(collection.Item))
+	}
+
+	address := findPostmanItem(collection, "Create customer address")
+	if address == nil || address.Request == nil {
+		t.Fatalf("Test failed. Expected request Create customer address")
+	}
+	url := address.Request.URL
+	if url.Raw != "{{base_url}}/customers/:customerId/address" {
+		t.Errorf("Test failed. Expected path variable in URL, got %s", url.Raw)
+	}
+	if len(url.Variable) != 1 || url.Variable[0].Key != "customerId" {
+		t.Errorf("Test failed. Expected customerId path variable, got %v", url.Variable)
+	}
+	if body := address.Request.Body; body == nil || body.Mode != "raw" || body.Options.Raw.Language != "json" {
+		t.Errorf("Test failed. Expected raw JSON body, got %v", body)
+	}
+
+	users := findPostmanItem(collection, "Get all users").Request
+	if users.URL.Raw != "{{base_url}}/users?status=active&status=pending" {
+		t.Errorf("Test failed. Expected required query parameters in raw URL, got %s", users.URL.Raw)
+	}
+	if len(users.URL.Query) != 3 || !users.URL.Query[0].Disabled {
+		t.Errorf("Test failed. Expected optional limit parameter to be disabled, got %v", users.URL.Query)
+	}
+
+	if len(export.Environments) != 1 || export.Environments[0].Values[0].Value != "http://localhost:8082/api/v1" {
+		t.Errorf("Test failed. Expected environment with server base_url, got %v", export.Environments)
+	}
+	if collection.Variable[0].Key != "base_url" || collection.Variable[0].Value != "http://localhost:8082/api/v1" {
+		t.Errorf("Test failed. Expected base_url collection variable, got %v", collection.Variable)
+	}
+}
+
+func TestGeneratePostmanAuthentication(t *testing.T) {
+	export, err := NewGenerator().GeneratePostmanFromOpenAPI([]byte(securitySpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	if auth := findPostmanItem(export.Collection, "Health check").Request.Auth; auth != nil {
+		t.Errorf("Test failed. Expected no authentication, got %v", auth)
+	}
+
+	bearer := findPostmanItem(export.Collection, "List orders").Request.Auth
+	if bearer == nil || bearer.Type != "bearer" || bearer.Bearer[0].Value != "{{bearer_token}}" {
+		t.Errorf("Test failed. Expected bearer authentication, got %v", bearer)
+	}
+
+	apiKey := findPostmanItem(export.Collection, "Create order").Request.Auth
+	expected := []PostmanAuthAttribute{
+		{Key: "key", Value: "key", Type: "string"},
+		{Key: "value", Value: "{{api_key}}", Type: "string"},
+		{Key: "in", Value: "query", Type: "string"},
+	}
+	if apiKey == nil || len(apiKey.APIKey) != len(expected) {
+		t.Fatalf("Test failed. Expected API key authentication, got %v", apiKey)
+	}
+	for i, attribute := range expected {
+		if apiKey.APIKey[i] != attribute {
+			t.Errorf("Test failed. Expected attribute %v, got %v", attribute, apiKey.APIKey[i])
+		}
+	}
+}
+
+func TestGeneratePostmanToFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "api.yml")
+	if err := os.WriteFile(input, []byte(testSpec), 0644); err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	output := OutputFileName(input, FormatPostman)
+	if err := NewDeterministicGenerator().ExportToFile(input, output, FormatPostman); err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "api.postman_collection.json"))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	var collection PostmanCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		t.Fatalf("Test failed. Collection is not valid JSON: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "api.openapi-env-localhost-8082.postman_environment.json")); err != nil {
+		t.Errorf("Test failed. Expected environment file next to the collection: %v", err)
+	}
+}
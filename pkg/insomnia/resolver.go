@@ -0,0 +1,252 @@
+package insomnia
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// refResolver resolves JSON references against the raw OpenAPI document. Local
+// references ("#/components/...") point into the document itself; references to
+// other files ("./schemas/address.yaml#/Address") are loaded by loadExternalRefs.
+type refResolver struct {
+	root       interface{}
+	rootPath   string
+	documents  map[string]interface{}
+	loadingErr error
+}
+
+// newRefResolver creates a resolver for the given raw OpenAPI document
+func newRefResolver(root interface{}) *refResolver {
+	return &refResolver{
+		root:      root,
+		documents: make(map[string]interface{}),
+	}
+}
+
+// loadExternalRefs loads the files referenced by the root document, resolving them
+// relative to source (the path of the root document, or empty for the working
+// directory). References to other files are rewritten to absolute paths so they
+// resolve regardless of which document they appear in.
+func (r *refResolver) loadExternalRefs(source string) error {
+	dir := "."
+	if source != "" {
+		abs, err := filepath.Abs(source)
+		if err != nil {
+			return fmt.Errorf("failed to resolve path %s: %w", source, err)
+		}
+		r.rootPath = abs
+		dir = filepath.Dir(abs)
+	}
+
+	r.rewriteRefs(r.root, "", dir)
+	return r.loadingErr
+}
+
+// files returns the absolute paths of the external files loaded by the resolver
+func (r *refResolver) files() []string {
+	files := make([]string, 0, len(r.documents))
+	for file := range r.documents {
+		files = append(files, file)
+	}
+	return files
+}
+
+// rewriteRefs rewrites the references found in node, which belongs to document
+// (empty for the root document) located in dir, loading referenced files on the way
+func (r *refResolver) rewriteRefs(node interface{}, document, dir string) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				value[key] = r.absoluteRef(ref, document, dir)
+				continue
+			}
+			r.rewriteRefs(child, document, dir)
+		}
+	case []interface{}:
+		for _, child := range value {
+			r.rewriteRefs(child, document, dir)
+		}
+	}
+}
+
+// absoluteRef converts a reference found in document into one relative to the root
+// document or to an absolute file path
+func (r *refResolver) absoluteRef(ref, document, dir string) string {
+	if strings.HasPrefix(ref, "#") {
+		if document == "" {
+			return ref
+		}
+		return document + ref
+	}
+
+	if strings.Contains(ref, "://") {
+		return ref
+	}
+
+	file, fragment := ref, ""
+	if i := strings.Index(ref, "#"); i >= 0 {
+		file, fragment = ref[:i], ref[i:]
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, filepath.FromSlash(file))
+	}
+	file = filepath.Clean(file)
+
+	if file == r.rootPath {
+		return "#" + strings.TrimPrefix(fragment, "#")
+	}
+
+	r.loadDocument(file)
+	return file + "#" + strings.TrimPrefix(fragment, "#")
+}
+
+// loadDocument parses an external file and rewrites its own references
+func (r *refResolver) loadDocument(file string) {
+	if _, ok := r.documents[file]; ok {
+		return
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		r.recordError(fmt.Errorf("failed to read referenced file: %w", err))
+		r.documents[file] = nil
+		return
+	}
+
+	doc, err := parseDocument(data)
+	if err != nil {
+		r.recordError(fmt.Errorf("failed to parse referenced file %s: %w", file, err))
+		r.documents[file] = nil
+		return
+	}
+
+	r.documents[file] = doc
+	r.rewriteRefs(doc, file, filepath.Dir(file))
+}
+
+// recordError keeps the first error encountered while loading external files
+func (r *refResolver) recordError(err error) {
+	if r.loadingErr == nil {
+		r.loadingErr = err
+	}
+}
+
+// resolve follows $ref chains until it reaches a node without a reference.
+// Unresolvable or circular references resolve to nil.
+func (r *refResolver) resolve(node interface{}) interface{} {
+	seen := make(map[string]bool)
+	for {
+		ref, ok := refOf(node)
+		if !ok {
+			return node
+		}
+		if seen[ref] {
+			return nil
+		}
+		seen[ref] = true
+		node = r.lookup(ref)
+	}
+}
+
+// resolveMap resolves a node and returns it as a map, or nil if it is not one
+func (r *refResolver) resolveMap(node interface{}) map[string]interface{} {
+	if m, ok := r.resolve(node).(map[string]interface{}); ok {
+		return m
+	}
+	return nil
+}
+
+// lookup evaluates a JSON reference such as "#/components/schemas/Address" or
+// "/abs/path/schemas.yaml#/Address"
+func (r *refResolver) lookup(ref string) interface{} {
+	i := strings.Index(ref, "#")
+	if i < 0 {
+		i = len(ref)
+	}
+
+	node := r.root
+	if file := ref[:i]; file != "" {
+		doc, ok := r.documents[file]
+		if !ok {
+			return nil
+		}
+		node = doc
+	}
+
+	pointer := strings.TrimPrefix(strings.TrimPrefix(ref[i:], "#"), "/")
+	if pointer == "" {
+		return node
+	}
+
+	for _, token := range strings.Split(pointer, "/") {
+		token = unescapePointerToken(token)
+
+		switch current := node.(type) {
+		case map[string]interface{}:
+			next, ok := current[token]
+			if !ok {
+				return nil
+			}
+			node = next
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(current) {
+				return nil
+			}
+			node = current[index]
+		default:
+			return nil
+		}
+	}
+
+	return node
+}
+
+// refOf returns the $ref value of a node, if any
+func refOf(node interface{}) (string, bool) {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	ref, ok := m["$ref"].(string)
+	return ref, ok
+}
+
+// unescapePointerToken decodes a single JSON pointer token (RFC 6901)
+func unescapePointerToken(token string) string {
+	if decoded, err := url.PathUnescape(token); err == nil {
+		token = decoded
+	}
+	token = strings.ReplaceAll(token, "~1", "/")
+	return strings.ReplaceAll(token, "~0", "~")
+}
+
+// normalizeKeys converts map[interface{}]interface{} nodes (produced by YAML mappings
+// with non-string keys such as unquoted status codes) into map[string]interface{}
+func normalizeKeys(node interface{}) interface{} {
+	switch value := node.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[fmt.Sprint(k)] = normalizeKeys(v)
+		}
+		return result
+	case map[string]interface{}:
+		for k, v := range value {
+			value[k] = normalizeKeys(v)
+		}
+		return value
+	case []interface{}:
+		for i, v := range value {
+			value[i] = normalizeKeys(v)
+		}
+		return value
+	default:
+		return node
+	}
+}
@@ -0,0 +1,182 @@
+package insomnia
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// responseDocsHeading starts the section listing the documented responses of a request,
+// appended to its Insomnia description
+const responseDocsHeading = "### Responses\n"
+
+// buildResponses collects the documented responses of an operation, ordered by status
+func (g *Generator) buildResponses(operation map[string]interface{}, refs *refResolver) []Response {
+	responses := refs.resolveMap(operation["responses"])
+
+	statuses := make([]string, 0, len(responses))
+	for status := range responses {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	var result []Response
+	for _, status := range statuses {
+		responseData := refs.resolveMap(responses[status])
+		if responseData == nil {
+			continue
+		}
+
+		response := Response{
+			Status:      strings.ToUpper(status),
+			Description: g.getStringValue(responseData, "description"),
+		}
+		if status == "default" {
+			response.Status = status
+		}
+
+		content := refs.resolveMap(responseData["content"])
+		if mimeType := selectMediaType(content); mimeType != "" {
+			response.MimeType = mimeType
+			media := refs.resolveMap(content[mimeType])
+			if value, found := exampleFromMediaType(media, refs); found {
+				if text, ok := value.(string); ok && !isJSONMediaType(mimeType) {
+					response.Example = text
+				} else {
+					response.Example = renderJSON(value)
+				}
+			}
+			if schema, ok := media["schema"]; ok && isJSONMediaType(mimeType) {
+				response.Schema, _ = jsonSchema(schema, refs, make(map[string]bool)).(map[string]interface{})
+			}
+		}
+
+		result = append(result, response)
+	}
+
+	return result
+}
+
+// jsonSchema converts an OpenAPI schema to a self-contained JSON Schema: references are
+// inlined, circular ones accepting any value, and nullable types admit null
+func jsonSchema(node interface{}, refs *refResolver, visiting map[string]bool) interface{} {
+	if ref, ok := refOf(node); ok {
+		if visiting[ref] {
+			return map[string]interface{}{}
+		}
+		visiting[ref] = true
+		defer delete(visiting, ref)
+		return jsonSchema(refs.lookup(ref), refs, visiting)
+	}
+
+	switch value := node.(type) {
+	case map[string]interface{}:
+		schema := make(map[string]interface{}, len(value))
+		for key, child := range value {
+			schema[key] = jsonSchema(child, refs, visiting)
+		}
+		if schemaType, ok := schema["type"].(string); ok && schema["nullable"] == true {
+			schema["type"] = []interface{}{schemaType, "null"}
+			delete(schema, "nullable")
+		}
+		return schema
+	case []interface{}:
+		items := make([]interface{}, len(value))
+		for i, child := range value {
+			items[i] = jsonSchema(child, refs, visiting)
+		}
+		return items
+	}
+	return node
+}
+
+// responseTestScript returns an Insomnia after-response script asserting that the status
+// code is documented and that JSON bodies match the schema of their response, or an
+// empty string when there is nothing to assert
+func responseTestScript(responses []Response) string {
+	var statuses []string
+	schemas := make(map[string]interface{})
+	hasDefault := false
+	for _, response := range responses {
+		if response.Status == "default" {
+			hasDefault = true
+		} else {
+			statuses = append(statuses, response.Status)
+		}
+		if response.Schema != nil {
+			schemas[response.Status] = response.Schema
+		}
+	}
+	checkStatus := !hasDefault && len(statuses) > 0
+	if !checkStatus && len(schemas) == 0 {
+		return ""
+	}
+
+	var script strings.Builder
+	script.WriteString("// Generated from the documented responses of the OpenAPI spec\n")
+	script.WriteString("const status = String(insomnia.response.code);\n")
+
+	if checkStatus {
+		documented, _ := json.Marshal(statuses)
+		fmt.Fprintf(&script, "const documented = %s;\n\n", documented)
+		script.WriteString("insomnia.test(\"Status code is documented\", () => {\n")
+		script.WriteString("  const found = documented.includes(status) || documented.includes(status[0] + \"XX\");\n")
+		script.WriteString("  insomnia.expect(found, `status ${status} is not documented`).to.be.true;\n")
+		script.WriteString("});\n")
+	}
+
+	if len(schemas) > 0 {
+		fmt.Fprintf(&script, "\nconst schemas = %s;\n", renderJSON(schemas))
+		script.WriteString("const schema = schemas[status] || schemas[status[0] + \"XX\"] || schemas.default;\n\n")
+		script.WriteString("if (schema) {\n")
+		script.WriteString("  insomnia.test(\"Response body matches the schema\", () => {\n")
+		script.WriteString("    const Ajv = require(\"ajv\");\n")
+		script.WriteString("    const validate = new Ajv({ allErrors: true, strict: false, validateFormats: false }).compile(schema);\n")
+		script.WriteString("    insomnia.expect(validate(insomnia.response.json()), JSON.stringify(validate.errors)).to.be.true;\n")
+		script.WriteString("  });\n")
+		script.WriteString("}\n")
+	}
+
+	return script.String()
+}
+
+// describeResponses appends the documented responses to a description, with their examples
+func describeResponses(description string, responses []Response) string {
+	if len(responses) == 0 {
+		return description
+	}
+
+	var docs strings.Builder
+	docs.WriteString(responseDocsHeading + "\n")
+	for _, response := range responses {
+		fmt.Fprintf(&docs, "- `%s` %s\n", response.Status, response.Description)
+	}
+
+	for _, response := range responses {
+		if response.Example == "" {
+			continue
+		}
+		language := "json"
+		if !isJSONMediaType(response.MimeType) {
+			language = ""
+		}
+		fmt.Fprintf(&docs, "\n**%s example** (%s)\n\n```%s\n%s\n```\n", response.Status, response.MimeType, language, response.Example)
+	}
+
+	if description == "" {
+		return strings.TrimRight(docs.String(), "\n")
+	}
+	return description + "\n\n" + strings.TrimRight(docs.String(), "\n")
+}
+
+// stripResponseDocs removes the section appended by describeResponses from a description
+func stripResponseDocs(description string) string {
+	if strings.HasPrefix(description, responseDocsHeading) {
+		return ""
+	}
+	if i := strings.LastIndex(description, "\n\n"+responseDocsHeading); i >= 0 {
+		return description[:i]
+	}
+	return description
+}
@@ -0,0 +1,148 @@
+package insomnia
+
+import (
+	"strings"
+	"testing"
+)
+
+const responseSpec = `
+openapi: 3.0.3
+info:
+  title: Response API
+  version: 1.0.0
+paths:
+  /nodes/{id}:
+    get:
+      summary: Get node
+      description: Returns a node
+      responses:
+        "200":
+          description: The node
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Node'
+        4XX:
+          description: Client error
+  /health:
+    get:
+      summary: Health
+      responses:
+        default:
+          description: Health status
+          content:
+            text/plain:
+              example: OK
+components:
+  schemas:
+    Node:
+      type: object
+      properties:
+        name:
+          type: string
+          nullable: true
+          example: root
+        parent:
+          $ref: '#/components/schemas/Node'
+`
+
+func TestBuildResponses(t *testing.T) {
+	api, err := NewDeterministicGenerator().ParseOpenAPI([]byte(responseSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	var node Operation
+	walkOperations(api.Folders, nil, func(path []string, operation Operation) {
+		if operation.Name == "Get node" {
+			node = operation
+		}
+	})
+
+	if len(node.Responses) != 2 || node.Responses[0].Status != "200" || node.Responses[1].Status != "4XX" {
+		t.Fatalf("Test failed. Expected the 200 and 4XX responses, got %v", node.Responses)
+	}
+	if !strings.Contains(node.Responses[0].Example, `"name": "root"`) {
+		t.Errorf("Test failed. Expected an example synthesized from the schema, got %s", node.Responses[0].Example)
+	}
+
+	properties := node.Responses[0].Schema["properties"].(map[string]interface{})
+	name := properties["name"].(map[string]interface{})
+	if types, ok := name["type"].([]interface{}); !ok || len(types) != 2 || types[1] != "null" {
+		t.Errorf("Test failed. Expected the nullable property to admit null, got %v", name)
+	}
+	if parent, ok := properties["parent"].(map[string]interface{}); !ok || len(parent) != 0 {
+		t.Errorf("Test failed. Expected the circular reference to accept any value, got %v", properties["parent"])
+	}
+}
+
+func TestResponseTestScripts(t *testing.T) {
+	spec, err := NewDeterministicGenerator(WithResponseExamples(), WithResponseTests()).GenerateFromOpenAPI([]byte(responseSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	node := findRequest(spec, "Get node")
+	if node == nil || node.Scripts == nil {
+		t.Fatalf("Test failed. Expected an after-response script, got %v", node)
+	}
+	for _, expected := range []string{`const documented = ["200","4XX"];`, `"200": {`, `require("ajv")`} {
+		if !strings.Contains(node.Scripts.AfterResponse, expected) {
+			t.Errorf("Test failed. Expected the script to contain %s, got %s", expected, node.Scripts.AfterResponse)
+		}
+	}
+	if !strings.HasPrefix(node.Meta.Description, "Returns a node\n\n### Responses\n\n- `200` The node\n- `4XX` Client error") {
+		t.Errorf("Test failed. Expected the responses in the description, got %s", node.Meta.Description)
+	}
+
+	health := findRequest(spec, "Health")
+	if health == nil || health.Scripts != nil {
+		t.Fatalf("Test failed. Expected no script when only a default response without schema is documented, got %v", health)
+	}
+	if !strings.Contains(health.Meta.Description, "**default example** (text/plain)\n\n```\nOK\n```") {
+		t.Errorf("Test failed. Expected the plain text example, got %s", health.Meta.Description)
+	}
+
+	result, err := NewDeterministicGenerator().ReverseInsomnia(spec)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	operation := result.Document["paths"].(map[string]interface{})["/nodes/{id}"].(map[string]interface{})["get"].(map[string]interface{})
+	if operation["description"] != "Returns a node" {
+		t.Errorf("Test failed. Expected the responses section to be stripped, got %v", operation["description"])
+	}
+}
+
+func TestResponseDocsAreOptIn(t *testing.T) {
+	spec, err := NewDeterministicGenerator().GenerateFromOpenAPI([]byte(responseSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	node := findRequest(spec, "Get node")
+	if node == nil || node.Scripts != nil {
+		t.Fatalf("Test failed. Expected no after-response script by default, got %v", node)
+	}
+	if strings.Contains(node.Meta.Description, "### Responses") {
+		t.Errorf("Test failed. Expected no responses in the description by default, got %s", node.Meta.Description)
+	}
+}
+
+func TestUpdateKeepsPreRequestScripts(t *testing.T) {
+	g := NewDeterministicGenerator(WithResponseTests())
+	existing, err := g.GenerateFromOpenAPI([]byte(responseSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	findRequest(existing, "Get node").Scripts.PreRequest = "insomnia.environment.set('id', 1);"
+
+	updated, err := g.UpdateFromOpenAPI([]byte(responseSpec), existing, false)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	scripts := findRequest(updated, "Get node").Scripts
+	if scripts == nil || scripts.PreRequest == "" || scripts.AfterResponse == "" {
+		t.Errorf("Test failed. Expected the pre-request script to be kept next to the generated one, got %v", scripts)
+	}
+}
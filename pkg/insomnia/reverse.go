@@ -0,0 +1,723 @@
+package insomnia
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// insomniaSpecType is the export format of the workspaces the generator writes and reads back
+const insomniaSpecType = "spec.insomnia.rest/5.0"
+
+// insomniaSpecTypePrefix starts the export formats of every Insomnia version, which
+// WithSpecType may declare instead of insomniaSpecType
+const insomniaSpecTypePrefix = "spec.insomnia.rest/"
+
+// openAPIKeyOrder is the order of the top-level keys of written OpenAPI documents
+var openAPIKeyOrder = []string{"openapi", "swagger", "info", "servers", "tags", "x-tagGroups", "paths", "webhooks", "components", "security"}
+
+// pathParameterPattern matches the {name} parameters of an OpenAPI path
+var pathParameterPattern = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// oauth2Flows maps Insomnia grant types to the flows of an OpenAPI oauth2 scheme
+var oauth2Flows = map[string]string{
+	"authorization_code": "authorizationCode",
+	"client_credentials": "clientCredentials",
+	"password":           "password",
+	"implicit":           "implicit",
+}
+
+// ReverseResult is an OpenAPI document recovered from an Insomnia workspace
+type ReverseResult struct {
+	// Document is the spec.contents of the workspace when present, otherwise a skeleton
+	// built from its requests
+	Document map[string]interface{}
+	Embedded bool
+
+	// Drift lists the differences between the requests and spec.contents
+	Drift []Drift
+}
+
+// Drift is a difference between a request of a workspace and the operations of its spec
+type Drift struct {
+	Operation string // method and path, e.g. GET /customers/{customerId}
+	Message   string
+}
+
+// String describes the difference
+func (d Drift) String() string {
+	return fmt.Sprintf("%s: %s", d.Operation, d.Message)
+}
+
+// ParseInsomnia parses an exported spec.insomnia.rest workspace, such as the ones the
+// generator writes
+func ParseInsomnia(data []byte) (*InsomniaSpec, error) {
+	var spec InsomniaSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse Insomnia file: %w", err)
+	}
+	if !strings.HasPrefix(spec.Type, insomniaSpecTypePrefix) {
+		return nil, fmt.Errorf("unsupported Insomnia file type %q, expected %s", spec.Type, insomniaSpecType)
+	}
+	return &spec, nil
+}
+
+// ReverseFile reads an Insomnia workspace file and recovers an OpenAPI document from it
+func (g *Generator) ReverseFile(insomniaFile string) (*ReverseResult, error) {
+	data, err := os.ReadFile(insomniaFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Insomnia file: %w", err)
+	}
+
+	spec, err := ParseInsomnia(data)
+	if err != nil {
+		return nil, err
+	}
+	return g.ReverseInsomnia(spec)
+}
+
+// ReverseToFile writes the OpenAPI document recovered from an Insomnia workspace file,
+// as JSON when openAPIFile ends in .json and as YAML otherwise, and returns its drift
+func (g *Generator) ReverseToFile(insomniaFile, openAPIFile string) ([]Drift, error) {
+	result, err := g.ReverseFile(insomniaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := MarshalOpenAPI(result.Document, strings.EqualFold(filepath.Ext(openAPIFile), ".json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal OpenAPI spec: %w", err)
+	}
+
+	if err := os.WriteFile(openAPIFile, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write output file: %w", err)
+	}
+	return result.Drift, nil
+}
+
+// ReverseInsomnia recovers an OpenAPI document from a workspace. Folders become tags,
+// request URLs become paths with {{ _.name }} variables turned into {name} path parameters,
+// and bodies become example request bodies with inferred schemas. When the workspace
+// embeds its spec in spec.contents, that spec is returned along with its drift from the
+// requests.
+func (g *Generator) ReverseInsomnia(spec *InsomniaSpec) (*ReverseResult, error) {
+	if spec.Spec.Contents == nil {
+		return &ReverseResult{Document: g.buildOpenAPISkeleton(spec)}, nil
+	}
+
+	document, err := embeddedSpec(spec.Spec.Contents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse spec.contents: %w", err)
+	}
+
+	data, err := yaml.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse spec.contents: %w", err)
+	}
+	api, err := g.ParseOpenAPI(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse spec.contents: %w", err)
+	}
+
+	return &ReverseResult{Document: document, Embedded: true, Drift: detectDrift(spec, api)}, nil
+}
+
+// MarshalOpenAPI renders an OpenAPI document as tab-indented JSON, or as YAML with its
+// top-level keys in their conventional order
+func MarshalOpenAPI(document map[string]interface{}, asJSON bool) ([]byte, error) {
+	if asJSON {
+		return marshalJSON(document)
+	}
+
+	keys := make([]string, 0, len(document))
+	for _, key := range openAPIKeyOrder {
+		if _, ok := document[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	var rest []string
+	for key := range document {
+		if !containsAny([]string{key}, openAPIKeyOrder) {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, key := range append(keys, rest...) {
+		var value yaml.Node
+		if err := value.Encode(document[key]); err != nil {
+			return nil, err
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &value)
+	}
+	return yaml.Marshal(root)
+}
+
+// embeddedSpec decodes spec.contents, which Insomnia stores either as a document or as its text
+func embeddedSpec(contents interface{}) (map[string]interface{}, error) {
+	if text, ok := contents.(string); ok {
+		parsed, err := parseDocument([]byte(text))
+		if err != nil {
+			return nil, err
+		}
+		contents = parsed
+	}
+
+	document, ok := normalizeKeys(contents).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("document is not a mapping")
+	}
+	return document, nil
+}
+
+// reverseRequest is a request of a workspace together with the innermost folder it is in
+type reverseRequest struct {
+	folder  string
+	request RequestItem
+}
+
+// workspaceRequests lists the requests of a workspace in collection order, sub-folders
+// first. Requests at the top level of the collection have no folder.
+func workspaceRequests(spec *InsomniaSpec) []reverseRequest {
+	var requests []reverseRequest
+	for _, item := range spec.Collection {
+		if request, ok := collectionRequest(item); ok {
+			requests = append(requests, reverseRequest{request: request})
+			continue
+		}
+		requests = append(requests, folderRequests(item)...)
+	}
+	return requests
+}
+
+// folderRequests lists the requests of a folder and its sub-folders
+func folderRequests(folder CollectionItem) []reverseRequest {
+	var requests []reverseRequest
+	for _, subFolder := range folder.Folders {
+		requests = append(requests, folderRequests(subFolder)...)
+	}
+	for _, request := range folder.Children {
+		requests = append(requests, reverseRequest{folder: folder.Name, request: request})
+	}
+	return requests
+}
+
+// folderTags returns the folders of a tree that become tags: all of them
+// except those that only hold sub-folders
+func folderTags(folders []CollectionItem) []CollectionItem {
+	var tags []CollectionItem
+	for _, folder := range folders {
+		if len(folder.Children) > 0 || len(folder.Folders) == 0 {
+			tags = append(tags, folder)
+		}
+		tags = append(tags, folderTags(folder.Folders)...)
+	}
+	return tags
+}
+
+// collectionRequest decodes a top-level collection item that is a request rather than a folder
+func collectionRequest(item CollectionItem) (RequestItem, bool) {
+	var request RequestItem
+	if _, ok := item.Extra["method"]; !ok || len(item.Children) > 0 || len(item.Folders) > 0 {
+		return request, false
+	}
+
+	data, err := yaml.Marshal(item)
+	if err != nil || yaml.Unmarshal(data, &request) != nil {
+		return request, false
+	}
+	return request, true
+}
+
+// splitRequestURL returns the OpenAPI path of a request URL, without its base URL, and
+// the parameters of its query string
+func splitRequestURL(rawURL string) (string, []RequestParameter) {
+	path := rawURL
+	var query []RequestParameter
+	if i := strings.Index(path, "?"); i >= 0 {
+		for _, pair := range strings.Split(path[i+1:], "&") {
+			if pair == "" {
+				continue
+			}
+			name, value := pair, ""
+			if j := strings.Index(pair, "="); j >= 0 {
+				name, value = pair[:j], pair[j+1:]
+			}
+			if unescaped, err := url.QueryUnescape(value); err == nil {
+				value = unescaped
+			}
+			query = append(query, RequestParameter{Name: name, Value: value})
+		}
+		path = path[:i]
+	}
+
+	if i := strings.Index(path, "://"); i >= 0 {
+		path = path[i+3:]
+		if j := strings.Index(path, "/"); j >= 0 {
+			path = path[j:]
+		} else {
+			path = ""
+		}
+	}
+
+	// Leading variables such as {{ _.base_url }} hold the server, not the path
+	for {
+		loc := templateVariablePattern.FindStringIndex(path)
+		if loc == nil || loc[0] != 0 {
+			break
+		}
+		path = path[loc[1]:]
+	}
+
+	path = templateVariablePattern.ReplaceAllString(path, "{$1}")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path, query
+}
+
+// buildOpenAPISkeleton creates an OpenAPI 3.0 document describing the requests of a workspace
+func (g *Generator) buildOpenAPISkeleton(spec *InsomniaSpec) map[string]interface{} {
+	info := map[string]interface{}{"title": spec.Name, "version": "1.0.0"}
+	if spec.Meta.Description != "" {
+		info["description"] = spec.Meta.Description
+	}
+	document := map[string]interface{}{"openapi": "3.0.3", "info": info}
+
+	if servers := workspaceServers(spec.Environments); len(servers) > 0 {
+		document["servers"] = servers
+	}
+
+	var folders []CollectionItem
+	for _, item := range spec.Collection {
+		if _, ok := collectionRequest(item); !ok {
+			folders = append(folders, item)
+		}
+	}
+
+	var tags, tagGroups []interface{}
+	for _, folder := range folderTags(folders) {
+		tag := map[string]interface{}{"name": folder.Name}
+		if folder.Meta.Description != "" {
+			tag["description"] = folder.Meta.Description
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) > 0 {
+		document["tags"] = tags
+	}
+
+	// Top-level folders holding sub-folders group the tags below them
+	for _, folder := range folders {
+		if len(folder.Folders) == 0 {
+			continue
+		}
+		var names []interface{}
+		for _, tag := range folderTags([]CollectionItem{folder}) {
+			names = append(names, tag.Name)
+		}
+		tagGroups = append(tagGroups, map[string]interface{}{"name": folder.Name, "tags": names})
+	}
+	if len(tagGroups) > 0 {
+		document["x-tagGroups"] = tagGroups
+	}
+
+	paths := make(map[string]interface{})
+	schemes := make(map[string]interface{})
+	for _, entry := range workspaceRequests(spec) {
+		path, query := splitRequestURL(entry.request.URL)
+		pathItem, _ := paths[path].(map[string]interface{})
+		if pathItem == nil {
+			pathItem = make(map[string]interface{})
+			paths[path] = pathItem
+		}
+
+		// Requests duplicating a method and path are skipped, the first one describes the operation
+		method := strings.ToLower(entry.request.Method)
+		if _, ok := pathItem[method]; ok || method == "" {
+			continue
+		}
+		pathItem[method] = reverseOperation(entry.folder, entry.request, path, query, schemes)
+	}
+	document["paths"] = paths
+
+	if len(schemes) > 0 {
+		document["components"] = map[string]interface{}{"securitySchemes": schemes}
+	}
+	return document
+}
+
+// workspaceServers recovers the servers of a workspace from the URLs its environments define
+func workspaceServers(env Environment) []interface{} {
+	var servers []interface{}
+	seen := make(map[string]bool)
+	add := func(serverURL, description string) {
+		if serverURL == "" || strings.Contains(serverURL, "{{") || seen[serverURL] {
+			return
+		}
+		seen[serverURL] = true
+
+		server := map[string]interface{}{"url": serverURL}
+		if description != "" {
+			server["description"] = description
+		}
+		servers = append(servers, server)
+	}
+
+	for _, subEnv := range env.SubEnvironments {
+		if subEnv.Data.Host != "" {
+			scheme := subEnv.Data.Scheme
+			if scheme == "" {
+				scheme = "https"
+			}
+			add(fmt.Sprintf("%s://%s%s", scheme, subEnv.Data.Host, subEnv.Data.BasePath), subEnv.Name)
+		}
+		if baseURL, ok := subEnv.Data.Extra["base_url"].(string); ok {
+			add(baseURL, subEnv.Name)
+		}
+	}
+	add(env.Data.BaseURL, "")
+
+	return servers
+}
+
+// reverseOperation describes a request as an OpenAPI operation, registering its
+// authentication in schemes
+func reverseOperation(folder string, request RequestItem, path string, query []RequestParameter, schemes map[string]interface{}) map[string]interface{} {
+	operation := map[string]interface{}{"summary": strings.TrimPrefix(request.Name, deprecatedPrefix)}
+	if folder != "" && folder != deprecatedFolderName {
+		operation["tags"] = []interface{}{folder}
+	}
+	operationID, description := markedOperationID(request.Meta.Description)
+	if operationID != "" {
+		operation["operationId"] = operationID
+	}
+	if description := stripResponseDocs(description); description != "" {
+		operation["description"] = description
+	}
+	if strings.HasPrefix(request.Name, deprecatedPrefix) || folder == deprecatedFolderName {
+		operation["deprecated"] = true
+	}
+
+	var params []interface{}
+	for _, match := range pathParameterPattern.FindAllStringSubmatch(path, -1) {
+		params = append(params, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	params = append(params, reverseParameters("query", append(query, request.Parameters...))...)
+
+	var headers []RequestParameter
+	for _, header := range request.Headers {
+		if !reservedHeaders[strings.ToLower(header.Name)] {
+			headers = append(headers, RequestParameter(header))
+		}
+	}
+	params = append(params, reverseParameters("header", headers)...)
+	if len(params) > 0 {
+		operation["parameters"] = params
+	}
+
+	if body := reverseRequestBody(request.Body); body != nil {
+		operation["requestBody"] = body
+	}
+
+	operation["responses"] = map[string]interface{}{
+		"200": map[string]interface{}{"description": "Successful response"},
+	}
+
+	if name, scopes, ok := registerSecurityScheme(request.Authentication, schemes); ok {
+		operation["security"] = []interface{}{map[string]interface{}{name: scopes}}
+	}
+
+	return operation
+}
+
+// reverseParameters describes query or header parameters, turning repeated names into
+// array parameters. Disabled parameters are optional.
+func reverseParameters(in string, list []RequestParameter) []interface{} {
+	var names []string
+	values := make(map[string][]string)
+	required := make(map[string]bool)
+	for _, param := range list {
+		if param.Name == "" {
+			continue
+		}
+		if _, ok := values[param.Name]; !ok {
+			names = append(names, param.Name)
+		}
+		values[param.Name] = append(values[param.Name], param.Value)
+		required[param.Name] = required[param.Name] || !param.Disabled
+	}
+
+	var params []interface{}
+	for _, name := range names {
+		param := map[string]interface{}{"name": name, "in": in, "schema": map[string]interface{}{"type": "string"}}
+		if required[name] {
+			param["required"] = true
+		}
+
+		examples := values[name]
+		if len(examples) > 1 {
+			param["schema"] = map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+			param["example"] = examples
+		} else if examples[0] != "" && !strings.Contains(examples[0], "{{") {
+			param["example"] = examples[0]
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// reverseRequestBody describes a request body as an example with an inferred schema
+func reverseRequestBody(body *RequestBody) map[string]interface{} {
+	if body == nil || (body.Text == "" && len(body.Params) == 0) {
+		return nil
+	}
+
+	mimeType := body.MimeType
+	if mimeType == "" {
+		mimeType = "text/plain"
+	}
+
+	media := make(map[string]interface{})
+	switch {
+	case isFormMediaType(mimeType):
+		properties := make(map[string]interface{})
+		example := make(map[string]interface{})
+		for _, param := range body.Params {
+			properties[param.Name] = map[string]interface{}{"type": "string"}
+			example[param.Name] = param.Value
+		}
+		media["schema"] = map[string]interface{}{"type": "object", "properties": properties}
+		media["example"] = example
+	case isJSONMediaType(mimeType):
+		var example interface{}
+		if err := json.Unmarshal([]byte(body.Text), &example); err != nil {
+			// Bodies using template tags are not valid JSON until Insomnia renders them
+			media["schema"] = map[string]interface{}{"type": "object"}
+			break
+		}
+		media["schema"] = inferSchema(example)
+		media["example"] = example
+	default:
+		media["schema"] = map[string]interface{}{"type": "string"}
+		media["example"] = body.Text
+	}
+
+	return map[string]interface{}{
+		"required": true,
+		"content":  map[string]interface{}{mimeType: media},
+	}
+}
+
+// inferSchema derives a schema from an example JSON value
+func inferSchema(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties := make(map[string]interface{}, len(v))
+		for name, property := range v {
+			properties[name] = inferSchema(property)
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	case []interface{}:
+		items := map[string]interface{}{}
+		if len(v) > 0 {
+			items = inferSchema(v[0])
+		}
+		return map[string]interface{}{"type": "array", "items": items}
+	case string:
+		return map[string]interface{}{"type": "string"}
+	case float64:
+		if v == math.Trunc(v) {
+			return map[string]interface{}{"type": "integer"}
+		}
+		return map[string]interface{}{"type": "number"}
+	case bool:
+		return map[string]interface{}{"type": "boolean"}
+	}
+	return map[string]interface{}{"nullable": true}
+}
+
+// registerSecurityScheme adds the security scheme of a request's authentication to schemes,
+// returning its name and the scopes the request needs
+func registerSecurityScheme(auth *RequestAuthentication, schemes map[string]interface{}) (string, []interface{}, bool) {
+	if auth == nil || auth.Disabled {
+		return "", nil, false
+	}
+
+	var name string
+	var scheme map[string]interface{}
+	scopes := []interface{}{}
+	switch auth.Type {
+	case "bearer", "basic", "digest":
+		name = auth.Type + "Auth"
+		scheme = map[string]interface{}{"type": "http", "scheme": auth.Type}
+	case "apikey":
+		in := "header"
+		for location, addTo := range apiKeyLocations {
+			if addTo == auth.AddTo {
+				in = location
+			}
+		}
+		name = "apiKeyAuth"
+		scheme = map[string]interface{}{"type": "apiKey", "name": auth.Key, "in": in}
+	case "oauth2":
+		flow := map[string]interface{}{"scopes": map[string]interface{}{}}
+		for _, scope := range strings.Fields(auth.Scope) {
+			flow["scopes"].(map[string]interface{})[scope] = ""
+			scopes = append(scopes, scope)
+		}
+		if auth.AuthorizationURL != "" {
+			flow["authorizationUrl"] = auth.AuthorizationURL
+		}
+		if auth.AccessTokenURL != "" && auth.GrantType != "implicit" {
+			flow["tokenUrl"] = auth.AccessTokenURL
+		}
+
+		flowName, ok := oauth2Flows[auth.GrantType]
+		if !ok {
+			flowName = "clientCredentials"
+		}
+		name = "oauth2Auth"
+		scheme = map[string]interface{}{"type": "oauth2", "flows": map[string]interface{}{flowName: flow}}
+	default:
+		return "", nil, false
+	}
+
+	// Differing schemes of the same kind are numbered, e.g. apiKeyAuth2
+	base := name
+	for i := 2; ; i++ {
+		existing, ok := schemes[name]
+		if !ok {
+			schemes[name] = scheme
+			break
+		}
+		if reflect.DeepEqual(existing, scheme) {
+			break
+		}
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+
+	return name, scopes, true
+}
+
+// detectDrift compares the requests of a workspace with the operations of its spec.
+// Requests and operations are matched by method and path.
+func detectDrift(spec *InsomniaSpec, api *API) []Drift {
+	operations := make(map[string]Operation)
+	for _, folder := range api.Folders {
+		for _, operation := range folder.Operations {
+			if !operation.Webhook {
+				operations[strings.ToUpper(operation.Method)+" "+operation.Path] = operation
+			}
+		}
+	}
+
+	var drift []Drift
+	matched := make(map[string]bool)
+	for _, entry := range workspaceRequests(spec) {
+		path, query := splitRequestURL(entry.request.URL)
+		key := strings.ToUpper(entry.request.Method) + " " + path
+
+		operation, ok := operations[key]
+		if !ok {
+			drift = append(drift, Drift{Operation: key, Message: fmt.Sprintf("request %q is not in the spec", entry.request.Name)})
+			continue
+		}
+		if matched[key] {
+			drift = append(drift, Drift{Operation: key, Message: fmt.Sprintf("request %q duplicates another request", entry.request.Name)})
+			continue
+		}
+		matched[key] = true
+
+		for _, message := range requestDrift(entry.request, append(query, entry.request.Parameters...), operation) {
+			drift = append(drift, Drift{Operation: key, Message: message})
+		}
+	}
+
+	for _, folder := range api.Folders {
+		for _, operation := range folder.Operations {
+			key := strings.ToUpper(operation.Method) + " " + operation.Path
+			if !operation.Webhook && !matched[key] {
+				drift = append(drift, Drift{Operation: key, Message: fmt.Sprintf("operation %q has no request", operation.Name)})
+			}
+		}
+	}
+
+	return drift
+}
+
+// requestDrift compares the query parameters, headers and body of a request with its operation
+func requestDrift(request RequestItem, query []RequestParameter, operation Operation) []string {
+	var messages []string
+
+	var headers []RequestParameter
+	for _, header := range request.Headers {
+		headers = append(headers, RequestParameter(header))
+	}
+
+	for _, kind := range []struct {
+		name      string
+		request   []RequestParameter
+		operation []Parameter
+		fold      bool
+	}{
+		{"query parameter", query, operation.Query, false},
+		{"header", headers, operation.Headers, true},
+	} {
+		normalize := func(name string) string {
+			if kind.fold {
+				return strings.ToLower(name)
+			}
+			return name
+		}
+
+		declared := make(map[string]bool)
+		for _, param := range kind.operation {
+			declared[normalize(param.Name)] = true
+		}
+		sent := make(map[string]bool)
+		for _, param := range kind.request {
+			name := normalize(param.Name)
+			if sent[name] || (kind.fold && reservedHeaders[name]) {
+				continue
+			}
+			sent[name] = true
+			if !declared[name] {
+				messages = append(messages, fmt.Sprintf("%s %q is not in the spec", kind.name, param.Name))
+			}
+		}
+		for _, param := range kind.operation {
+			name := normalize(param.Name)
+			if !param.Disabled && !sent[name] && !(kind.fold && reservedHeaders[name]) {
+				messages = append(messages, fmt.Sprintf("required %s %q is missing from the request", kind.name, param.Name))
+				sent[name] = true
+			}
+		}
+	}
+
+	switch {
+	case operation.Body != nil && request.Body == nil:
+		messages = append(messages, fmt.Sprintf("request body (%s) is missing from the request", operation.Body.MimeType))
+	case operation.Body == nil && request.Body != nil:
+		messages = append(messages, "request body is not in the spec")
+	case operation.Body != nil && request.Body.MimeType != operation.Body.MimeType:
+		messages = append(messages, fmt.Sprintf("request body is %s, the spec expects %s", request.Body.MimeType, operation.Body.MimeType))
+	}
+
+	return messages
+}
@@ -0,0 +1,151 @@
+package insomnia
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReverseInsomniaSkeleton(t *testing.T) {
+	g := NewDeterministicGenerator()
+	spec, err := g.GenerateFromOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	spec.Spec.Contents = nil
+
+	result, err := g.ReverseInsomnia(spec)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if result.Embedded || len(result.Drift) != 0 {
+		t.Errorf("Test failed. Expected a skeleton without drift, got %v", result)
+	}
+
+	paths := result.Document["paths"].(map[string]interface{})
+	address, ok := paths["/customers/{customerId}/address"].(map[string]interface{})["post"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Test failed. Expected path parameters recovered from template variables, got %v", paths)
+	}
+	if tags := address["tags"].([]interface{}); tags[0] != "addresses" {
+		t.Errorf("Test failed. Expected folder as tag, got %v", tags)
+	}
+	param := address["parameters"].([]interface{})[0].(map[string]interface{})
+	if param["name"] != "customerId" || param["in"] != "path" || param["required"] != true {
+		t.Errorf("Test failed. Expected required customerId path parameter, got %v", param)
+	}
+
+	media := address["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})
+	properties := media["schema"].(map[string]interface{})["properties"].(map[string]interface{})
+	if properties["isDefault"].(map[string]interface{})["type"] != "boolean" || properties["street"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("Test failed. Expected schema inferred from the example body, got %v", properties)
+	}
+	if media["example"].(map[string]interface{})["street"] != "456 Oak Avenue" {
+		t.Errorf("Test failed. Expected the body as example, got %v", media["example"])
+	}
+
+	users := paths["/users"].(map[string]interface{})["get"].(map[string]interface{})
+	for _, item := range users["parameters"].([]interface{}) {
+		param := item.(map[string]interface{})
+		switch param["name"] {
+		case "status":
+			if param["required"] != true || param["schema"].(map[string]interface{})["type"] != "array" {
+				t.Errorf("Test failed. Expected repeated query parameter as required array, got %v", param)
+			}
+		case "limit":
+			if param["required"] == true {
+				t.Errorf("Test failed. Expected disabled query parameter to be optional, got %v", param)
+			}
+		}
+	}
+
+	servers := result.Document["servers"].([]interface{})
+	if servers[0].(map[string]interface{})["url"] != "http://localhost:8082/api/v1" {
+		t.Errorf("Test failed. Expected servers from sub environments, got %v", servers)
+	}
+}
+
+func TestReverseInsomniaSecuritySchemes(t *testing.T) {
+	g := NewDeterministicGenerator()
+	spec, err := g.GenerateFromOpenAPI([]byte(securitySpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	spec.Spec.Contents = nil
+
+	result, err := g.ReverseInsomnia(spec)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	schemes := result.Document["components"].(map[string]interface{})["securitySchemes"].(map[string]interface{})
+	apiKey := schemes["apiKeyAuth"].(map[string]interface{})
+	if apiKey["in"] != "query" || apiKey["name"] != "key" {
+		t.Errorf("Test failed. Expected query API key scheme, got %v", apiKey)
+	}
+	if _, ok := schemes["bearerAuth"]; !ok {
+		t.Errorf("Test failed. Expected bearer scheme, got %v", schemes)
+	}
+
+	health := result.Document["paths"].(map[string]interface{})["/health"].(map[string]interface{})["get"].(map[string]interface{})
+	if _, ok := health["security"]; ok {
+		t.Errorf("Test failed. Expected no security for requests without authentication, got %v", health["security"])
+	}
+}
+
+func TestReverseInsomniaDrift(t *testing.T) {
+	g := NewDeterministicGenerator()
+	spec, err := g.GenerateFromOpenAPI([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	result, err := g.ReverseInsomnia(spec)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if !result.Embedded || len(result.Drift) != 0 {
+		t.Fatalf("Test failed. Expected embedded spec without drift, got %v", result.Drift)
+	}
+
+	// Drop the nodes request and edit the users request
+	var collection []CollectionItem
+	for _, folder := range spec.Collection {
+		if folder.Name == "nodes" {
+			continue
+		}
+		if folder.Name == "users" {
+			folder.Children[0].Parameters = append(folder.Children[0].Parameters, RequestParameter{Name: "page", Value: "2"})
+			folder.Children[0].Headers = nil
+		}
+		collection = append(collection, folder)
+	}
+	spec.Collection = collection
+
+	result, err = g.ReverseInsomnia(spec)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	var drift []string
+	for _, item := range result.Drift {
+		drift = append(drift, item.String())
+	}
+	for _, expected := range []string{
+		`GET /users: query parameter "page" is not in the spec`,
+		`GET /users: required header "X-Tenant" is missing from the request`,
+		`PUT /nodes: operation "Replace node" has no request`,
+	} {
+		if !strings.Contains(strings.Join(drift, "\n"), expected) {
+			t.Errorf("Test failed. Expected drift %q, got %v", expected, drift)
+		}
+	}
+	if len(drift) != 3 {
+		t.Errorf("Test failed. Expected 3 differences, got %v", drift)
+	}
+}
+
+func TestParseInsomniaRejectsOtherFormats(t *testing.T) {
+	if _, err := ParseInsomnia([]byte("_type: export\n__export_format: 4\n")); err == nil {
+		t.Errorf("Test failed. Expected an error for a v4 export")
+	}
+}
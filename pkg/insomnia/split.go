@@ -0,0 +1,99 @@
+package insomnia
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tags returns the tags of the operations of the API in folder order. Untagged
+// operations have the "default" tag.
+func (api *API) Tags() []string {
+	var tags []string
+	seen := make(map[string]bool)
+	walkOperations(api.Folders, nil, func(_ []string, operation Operation) {
+		for _, tag := range operationTags(operation) {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	})
+	return tags
+}
+
+// ForTag returns a copy of the API keeping the operations tagged with tag, named after
+// the tag so that the IDs of its workspace differ from those of the whole API
+func (api *API) ForTag(tag string) *API {
+	split := *api
+	split.Title = api.Title + " - " + tag
+	split.Folders = keepOperations(api.Folders, func(operation Operation) bool {
+		return containsAny(operationTags(operation), []string{tag})
+	})
+	return &split
+}
+
+// SplitFileName names the file of the workspace of a tag after outputFile, e.g.
+// users-insomnia.yml becomes users-admin-insomnia.yml for the admin tag
+func SplitFileName(outputFile, tag string) string {
+	suffix := formatSuffixes[FormatInsomnia]
+	if !strings.HasSuffix(outputFile, suffix) {
+		suffix = filepath.Ext(outputFile)
+	}
+	return strings.TrimSuffix(outputFile, suffix) + "-" + fileName(tag) + suffix
+}
+
+// GenerateSplitToFiles generates one Insomnia workspace per tag of an OpenAPI file,
+// named by SplitFileName, and returns the files written
+func (g *Generator) GenerateSplitToFiles(openAPIFile, outputFile string) ([]string, error) {
+	api, err := g.ParseFile(openAPIFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Insomnia spec: %w", err)
+	}
+
+	var files []string
+	for _, tag := range api.Tags() {
+		yamlData, err := yaml.Marshal(g.BuildInsomnia(api.ForTag(tag)))
+		if err != nil {
+			return files, fmt.Errorf("failed to marshal Insomnia spec of tag %s: %w", tag, err)
+		}
+
+		file := SplitFileName(outputFile, tag)
+		if _, err := writeFileIfChanged(file, yamlData, 0644); err != nil {
+			return files, err
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// operationTags returns the tags of an operation, or the "default" tag when it has none
+func operationTags(operation Operation) []string {
+	if len(operation.Tags) == 0 {
+		return []string{"default"}
+	}
+	return operation.Tags
+}
+
+// keepOperations filters the operations of a folder tree, dropping the folders left empty
+func keepOperations(folders []Folder, keep func(Operation) bool) []Folder {
+	var kept []Folder
+	for _, folder := range folders {
+		var operations []Operation
+		for _, operation := range folder.Operations {
+			if keep(operation) {
+				operations = append(operations, operation)
+			}
+		}
+
+		folder.Operations = operations
+		folder.Folders = keepOperations(folder.Folders, keep)
+		if len(folder.Operations) > 0 || len(folder.Folders) > 0 {
+			kept = append(kept, folder)
+		}
+	}
+	return kept
+}
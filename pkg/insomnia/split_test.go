@@ -0,0 +1,71 @@
+package insomnia
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSplitByTag(t *testing.T) {
+	api, err := NewDeterministicGenerator().ParseOpenAPI([]byte(filterSpec))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	tags := api.Tags()
+	if len(tags) != 3 || tags[0] != "customers" || tags[1] != "addresses" || tags[2] != "default" {
+		t.Fatalf("Test failed. Expected customers, addresses and default, got %v", tags)
+	}
+
+	addresses := api.ForTag("addresses")
+	if addresses.Title != "Filter API - addresses" || len(addresses.Folders) != 1 || len(addresses.Folders[0].Operations) != 2 {
+		t.Errorf("Test failed. Expected the addresses operations only, got %+v", addresses)
+	}
+}
+
+func TestSplitFileName(t *testing.T) {
+	tests := map[string]string{
+		"users-insomnia.yml": "users-admin-insomnia.yml",
+		"out/users.yaml":     "out/users-admin.yaml",
+	}
+
+	for outputFile, expected := range tests {
+		if name := SplitFileName(outputFile, "admin"); name != expected {
+			t.Errorf("Test failed. Expected %s, got %s", expected, name)
+		}
+	}
+}
+
+func TestGenerateSplitToFiles(t *testing.T) {
+	dir := t.TempDir()
+	openAPIFile := writeTestFile(t, dir, "filter.yml", filterSpec)
+
+	g := NewDeterministicGenerator(WithOperationFilter(OperationFilter{Exclude: OperationMatch{Tags: []string{"default"}}}))
+	files, err := g.GenerateSplitToFiles(openAPIFile, filepath.Join(dir, "filter-insomnia.yml"))
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if len(files) != 2 || files[0] != filepath.Join(dir, "filter-customers-insomnia.yml") || files[1] != filepath.Join(dir, "filter-addresses-insomnia.yml") {
+		t.Fatalf("Test failed. Expected one file per tag, got %v", files)
+	}
+
+	var workspaces [2]InsomniaSpec
+	for i, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+		if err := yaml.Unmarshal(data, &workspaces[i]); err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+	}
+
+	if workspaces[0].Name != "Filter API - customers 1.0.0" || findRequest(&workspaces[0], "List customers") == nil || findRequest(&workspaces[0], "Get address") != nil {
+		t.Errorf("Test failed. Expected the customers workspace, got %+v", workspaces[0])
+	}
+	if workspaces[0].Meta.ID == workspaces[1].Meta.ID {
+		t.Errorf("Test failed. Expected workspaces with distinct IDs, got %s", workspaces[0].Meta.ID)
+	}
+}
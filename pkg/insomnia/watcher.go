@@ -1,148 +1,550 @@
 package insomnia
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
-// FileWatcher monitors OpenAPI files and regenerates Insomnia files when they change
+// defaultDebounce is how long the watcher waits for a burst of file events to end
+const defaultDebounce = 100 * time.Millisecond
+
+// eventSource reports the files changed in watched directories, as soon as they change
+type eventSource interface {
+	// Add watches the files of a directory
+	Add(dir string) error
+	// Events returns the changed files; an empty name means events were lost
+	Events() <-chan string
+	// Errors returns the errors reading events
+	Errors() <-chan error
+	// Close stops watching
+	Close() error
+}
+
+// FileWatcher monitors OpenAPI files and regenerates Insomnia files when they change.
+// It uses inotify on Linux, polling directories where it is unavailable, such as
+// network filesystems, and polls every file on other platforms. Its methods are safe
+// to call from several goroutines, including while it runs.
 type FileWatcher struct {
-	generator    *Generator
-	watchedFiles map[string]string // openapi file -> insomnia file mapping
-	lastModified map[string]time.Time
-	pollInterval time.Duration
+	mu            sync.Mutex
+	watchedFiles  map[string]string // openapi file -> output file of the first format
+	lastModified  map[string]time.Time
+	hashes        map[string]string               // openapi file -> hash of its content
+	dependencies  map[string]map[string]fileState // openapi file -> files its references load and overlays
+	identities    map[string]os.FileInfo          // openapi file -> last stat, to follow renames
+	missing       map[string]bool                 // openapi files reported missing
+	roots         map[string]bool                 // directories scanned for new OpenAPI files
+	removeOutputs bool
+	pollInterval  time.Duration
+	deterministic bool
+	formats       []Format
+	options       []Option
+	fileOptions   map[string][]Option // openapi file -> options of that file only
+	polling       bool
+	debounce      time.Duration
+	events        eventSource
+	eventDirs     map[string]bool // directory -> whether events report its changes
+	hooks         WatchHooks
+	queued        []WatchEvent // events waiting to be dispatched to the hooks
+	dispatching   sync.Mutex   // keeps events in order when dispatched from several goroutines
+	cancel        context.CancelFunc
+	done          chan struct{} // closed when a running watcher stops
 }
 
 // NewFileWatcher creates a new file watcher instance
 func NewFileWatcher(pollInterval time.Duration) *FileWatcher {
 	return &FileWatcher{
-		generator:    NewGenerator(),
 		watchedFiles: make(map[string]string),
 		lastModified: make(map[string]time.Time),
+		hashes:       make(map[string]string),
+		dependencies: make(map[string]map[string]fileState),
+		identities:   make(map[string]os.FileInfo),
+		missing:      make(map[string]bool),
+		roots:        make(map[string]bool),
+		fileOptions:  make(map[string][]Option),
+		debounce:     defaultDebounce,
+		eventDirs:    make(map[string]bool),
 		pollInterval: pollInterval,
+		formats:      []Format{FormatInsomnia},
+	}
+}
+
+// SetDeterministicIDs controls whether regenerated files use deterministic IDs and timestamps
+func (w *FileWatcher) SetDeterministicIDs(enabled bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.deterministic = enabled
+}
+
+// SetPolling makes the watcher poll every file each interval instead of using file events.
+// It applies the next time the watcher starts.
+func (w *FileWatcher) SetPolling(enabled bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.polling = enabled
+}
+
+// SetDebounce sets how long the watcher waits after a file event for more events before
+// regenerating, so that editors saving in several writes trigger one regeneration. It
+// applies the next time the watcher starts.
+func (w *FileWatcher) SetDebounce(debounce time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.debounce = debounce
+}
+
+// SetRemoveOutputs makes the watcher delete the output files of specs deleted from the
+// directories it scans
+func (w *FileWatcher) SetRemoveOutputs(enabled bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.removeOutputs = enabled
+}
+
+// SetFormats sets the formats generated for each watched file. The output file given to
+// AddFile is used for the first format; the others are written next to the OpenAPI file.
+func (w *FileWatcher) SetFormats(formats ...Format) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(formats) > 0 {
+		w.formats = formats
 	}
 }
 
-// AddFile adds an OpenAPI file to watch
-func (w *FileWatcher) AddFile(openAPIFile, insomniaFile string) error {
+// SetGeneratorOptions adds options to the generator used for every regeneration. Later
+// options override earlier ones.
+func (w *FileWatcher) SetGeneratorOptions(options ...Option) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.options = append(w.options, options...)
+}
+
+// AddFile adds an OpenAPI file to watch. The options, such as an operation filter, only
+// apply to this file and override those set for every regeneration.
+func (w *FileWatcher) AddFile(openAPIFile, insomniaFile string, options ...Option) error {
 	// Check if OpenAPI file exists
-	if _, err := os.Stat(openAPIFile); os.IsNotExist(err) {
+	openAPIFile = filepath.Clean(openAPIFile)
+	stat, err := os.Stat(openAPIFile)
+	if os.IsNotExist(err) {
 		return fmt.Errorf("OpenAPI file does not exist: %s", openAPIFile)
 	}
 
+	defer w.dispatch()
+	w.mu.Lock()
+	w.addFile(openAPIFile, insomniaFile, options, stat)
+	w.mu.Unlock()
+	w.resolveDependencies(openAPIFile)
+	return nil
+}
+
+// addFile adds an OpenAPI file to watch. Its output files are regenerated on the next check
+// unless stat gives its initial modification time, in which case the caller resolves its
+// dependencies once it releases w.mu. w.mu must be held.
+func (w *FileWatcher) addFile(openAPIFile, insomniaFile string, options []Option, stat os.FileInfo) {
 	// Generate output filename if not provided
 	if insomniaFile == "" {
-		insomniaFile = generateInsomniaFileName(openAPIFile)
+		insomniaFile = OutputFileName(openAPIFile, w.formats[0])
 	}
 
 	w.watchedFiles[openAPIFile] = insomniaFile
+	w.fileOptions[openAPIFile] = options
 
-	// Get initial modification time
-	if stat, err := os.Stat(openAPIFile); err == nil {
+	if w.events != nil {
+		w.watchDir(filepath.Dir(openAPIFile))
+	}
+
+	// Get initial modification time and content hash
+	if stat != nil {
 		w.lastModified[openAPIFile] = stat.ModTime()
+		w.identities[openAPIFile] = stat
+		w.hashes[openAPIFile] = hashFile(openAPIFile)
 	}
 
 	log.Printf("Added file to watch: %s -> %s", openAPIFile, insomniaFile)
-	return nil
+	w.notify(WatchEvent{Type: WatchAdded, Spec: openAPIFile, Output: insomniaFile, Format: w.formats[0]})
 }
 
 // RemoveFile removes a file from being watched
 func (w *FileWatcher) RemoveFile(openAPIFile string) {
+	defer w.dispatch()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.removeFile(filepath.Clean(openAPIFile))
+}
+
+// removeFile removes a file from being watched. w.mu must be held.
+func (w *FileWatcher) removeFile(openAPIFile string) {
+	insomniaFile, ok := w.watchedFiles[openAPIFile]
+	if !ok {
+		return
+	}
 	delete(w.watchedFiles, openAPIFile)
 	delete(w.lastModified, openAPIFile)
+	delete(w.hashes, openAPIFile)
+	delete(w.dependencies, openAPIFile)
+	delete(w.identities, openAPIFile)
+	delete(w.missing, openAPIFile)
+	delete(w.fileOptions, openAPIFile)
 	log.Printf("Removed file from watch: %s", openAPIFile)
+	w.notify(WatchEvent{Type: WatchRemoved, Spec: openAPIFile, Output: insomniaFile, Format: w.formats[0]})
+}
+
+// Start monitors files for changes until the context is canceled or Stop is called. It
+// uses file events when available, and falls back to polling. A regeneration in progress
+// finishes before Start returns.
+func (w *FileWatcher) Start(ctx context.Context) error {
+	w.mu.Lock()
+	if w.done != nil {
+		w.mu.Unlock()
+		return errors.New("file watcher is already running")
+	}
+	ctx, w.cancel = context.WithCancel(ctx)
+	done := make(chan struct{})
+	w.done = done
+	polling := w.polling
+	w.mu.Unlock()
+
+	defer func() {
+		w.mu.Lock()
+		w.cancel()
+		w.cancel, w.done = nil, nil
+		w.mu.Unlock()
+		close(done)
+		log.Printf("Stopped file watcher")
+	}()
+
+	if !polling {
+		events, err := newEventSource()
+		if err == nil {
+			w.watchEvents(ctx, events)
+			return nil
+		}
+		log.Printf("File events unavailable, falling back to polling: %v", err)
+	}
+
+	w.poll(ctx)
+	return nil
+}
+
+// Stop stops a running watcher and waits for the regeneration in progress, if any, to finish
+func (w *FileWatcher) Stop() {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.mu.Unlock()
+
+	if done != nil {
+		cancel()
+		<-done
+	}
 }
 
-// StartWatching begins monitoring files for changes
+// StartWatching begins monitoring files for changes, until Stop is called
 func (w *FileWatcher) StartWatching() {
+	if err := w.Start(context.Background()); err != nil {
+		log.Printf("Error starting file watcher: %v", err)
+	}
+}
+
+// poll checks every file for changes each poll interval until the context is canceled
+func (w *FileWatcher) poll(ctx context.Context) {
 	log.Printf("Starting file watcher with %d second polling interval", int(w.pollInterval.Seconds()))
 
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
 	for {
-		w.checkForChanges()
-		time.Sleep(w.pollInterval)
+		w.rescan()
+		w.regenerateChanged(ctx, func(string) bool { return true })
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
 }
 
-// checkForChanges checks all watched files for modifications
-func (w *FileWatcher) checkForChanges() {
-	for openAPIFile, insomniaFile := range w.watchedFiles {
-		if w.hasFileChanged(openAPIFile) {
-			log.Printf("Detected change in: %s", openAPIFile)
+// watchEvents regenerates files when events report changes, once no more events came for
+// the debounce delay, until the context is canceled. Files in directories events cannot
+// watch are polled.
+func (w *FileWatcher) watchEvents(ctx context.Context, events eventSource) {
+	w.mu.Lock()
+	w.events = events
+	for openAPIFile := range w.watchedFiles {
+		w.watchDir(filepath.Dir(openAPIFile))
+		for file := range w.dependencies[openAPIFile] {
+			w.watchDir(filepath.Dir(file))
+		}
+	}
+	delay := w.debounce
+	w.mu.Unlock()
+
+	defer func() {
+		w.mu.Lock()
+		w.events = nil
+		w.eventDirs = make(map[string]bool)
+		w.mu.Unlock()
+		events.Close()
+	}()
+
+	log.Printf("Starting file watcher with file events, debouncing %v", delay)
+
+	// Catch up with changes made before the directories were watched; rescanning also
+	// watches the directories scanned for new files
+	w.rescan()
+	w.regenerateChanged(ctx, func(string) bool { return true })
+
+	pending := make(map[string]bool)
+	rescan := false
+	debounce := time.NewTimer(delay)
+	debounce.Stop()
+	poll := time.NewTicker(w.pollInterval)
+	defer poll.Stop()
 
-			if err := w.regenerateInsomniaFile(openAPIFile, insomniaFile); err != nil {
-				log.Printf("Error regenerating Insomnia file: %v", err)
-			} else {
-				log.Printf("✅ Successfully regenerated: %s", insomniaFile)
+	for {
+		select {
+		case <-ctx.Done():
+			debounce.Stop()
+			return
+		case file := <-events.Events():
+			// Other files only matter when they may be new or renamed specs, and watched
+			// files that vanished may have been renamed
+			file = filepath.Clean(file)
+			dependents := w.dependents(file)
+			switch {
+			case file == "." || w.isWatched(file):
+				pending[file] = true
+				if _, err := os.Stat(file); err != nil {
+					rescan = true
+				}
+			case len(dependents) > 0:
+				for _, openAPIFile := range dependents {
+					pending[openAPIFile] = true
+				}
+			case isGeneratedPath(file):
+				continue
+			default:
+				rescan = true
 			}
+			debounce.Reset(delay)
+		case err := <-events.Errors():
+			log.Printf("Error reading file events: %v", err)
+			w.mu.Lock()
+			w.notify(WatchEvent{Type: WatchError, Err: fmt.Errorf("error reading file events: %w", err)})
+			w.mu.Unlock()
+			w.dispatch()
+		case <-debounce.C:
+			// An empty name, cleaned to ".", means events were lost, so every file is checked
+			added := make(map[string]bool)
+			if rescan || pending["."] {
+				for _, openAPIFile := range w.rescan() {
+					added[openAPIFile] = true
+				}
+			}
+			w.regenerateChanged(ctx, func(openAPIFile string) bool {
+				return pending["."] || pending[openAPIFile] || added[openAPIFile]
+			})
+			pending = make(map[string]bool)
+			rescan = false
+		case <-poll.C:
+			added := make(map[string]bool)
+			if w.pollsRoots() {
+				for _, openAPIFile := range w.rescan() {
+					added[openAPIFile] = true
+				}
+			}
+			w.regenerateChanged(ctx, func(openAPIFile string) bool {
+				return w.polled(openAPIFile) || added[openAPIFile]
+			})
 		}
 	}
 }
 
-// hasFileChanged checks if a file has been modified since last check
+// watchDir watches a directory for events, or polls it when events cannot watch it.
+// w.mu must be held.
+func (w *FileWatcher) watchDir(dir string) {
+	if _, ok := w.eventDirs[dir]; ok {
+		return
+	}
+
+	if err := w.events.Add(dir); err != nil {
+		log.Printf("Polling %s every %v: %v", dir, w.pollInterval, err)
+		w.eventDirs[dir] = false
+		return
+	}
+	w.eventDirs[dir] = true
+}
+
+// regenerateChanged regenerates the outputs of the watched files selected by match that
+// changed, stopping between files when the context is canceled. match is called with
+// w.mu held.
+func (w *FileWatcher) regenerateChanged(ctx context.Context, match func(openAPIFile string) bool) {
+	for _, openAPIFile := range w.changedFiles(match) {
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Detected change in: %s", openAPIFile)
+		w.regenerateOutputs(openAPIFile)
+	}
+}
+
+// changedFiles returns the watched files selected by match that changed, in name order
+func (w *FileWatcher) changedFiles(match func(openAPIFile string) bool) []string {
+	defer w.dispatch()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var changed []string
+	for openAPIFile := range w.watchedFiles {
+		if match(openAPIFile) && w.hasFileChanged(openAPIFile) {
+			changed = append(changed, openAPIFile)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// regenerateOutputs regenerates the output files of every format of an OpenAPI file
+func (w *FileWatcher) regenerateOutputs(openAPIFile string) {
+	w.mu.Lock()
+	insomniaFile, ok := w.watchedFiles[openAPIFile]
+	formats := w.formats
+	generator := w.newGenerator(openAPIFile)
+	w.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	defer w.dispatch()
+
+	// The spec is parsed once for every format
+	start := time.Now()
+	api, err := generator.ParseFile(openAPIFile)
+	parsing := time.Since(start)
+	if err != nil {
+		log.Printf("Error regenerating %s: %v", openAPIFile, err)
+		w.mu.Lock()
+		w.notify(WatchEvent{Type: WatchError, Spec: openAPIFile, Duration: parsing, Err: err})
+		w.mu.Unlock()
+		return
+	}
+
+	w.mu.Lock()
+	if _, ok := w.watchedFiles[openAPIFile]; ok {
+		w.trackDependencies(openAPIFile, api.Files)
+	}
+	w.mu.Unlock()
+
+	for i, outputFile := range outputFiles(openAPIFile, insomniaFile, formats) {
+		format := formats[i]
+		start := time.Now()
+		written, err := generator.WriteAPI(api, format, outputFile)
+		event := WatchEvent{
+			Type:     WatchRegenerated,
+			Spec:     openAPIFile,
+			Output:   outputFile,
+			Format:   format,
+			Changed:  written,
+			Duration: parsing + time.Since(start),
+			Err:      err,
+		}
+		switch {
+		case err != nil:
+			log.Printf("Error regenerating %s file: %v", format, err)
+			event.Type = WatchError
+		case written:
+			log.Printf("✅ Successfully regenerated: %s", outputFile)
+		default:
+			log.Printf("Unchanged: %s", outputFile)
+		}
+
+		w.mu.Lock()
+		w.notify(event)
+		w.mu.Unlock()
+	}
+}
+
+// hasFileChanged checks if the content of a file, or of a file it references, changed
+// since last check. w.mu must be held.
 func (w *FileWatcher) hasFileChanged(filePath string) bool {
 	stat, err := os.Stat(filePath)
 	if err != nil {
-		log.Printf("Error checking file status for %s: %v", filePath, err)
+		// Report a missing file once, and regenerate its outputs when it comes back
+		if !w.missing[filePath] {
+			log.Printf("Error checking file status for %s: %v", filePath, err)
+			w.notify(WatchEvent{Type: WatchError, Spec: filePath, Err: err})
+			w.missing[filePath] = true
+			delete(w.lastModified, filePath)
+		}
 		return false
 	}
+	delete(w.missing, filePath)
+	w.identities[filePath] = stat
 
 	lastMod, exists := w.lastModified[filePath]
+	w.lastModified[filePath] = stat.ModTime()
 	if !exists {
-		w.lastModified[filePath] = stat.ModTime()
+		w.contentChanged(filePath)
 		return true
 	}
 
-	if stat.ModTime().After(lastMod) {
-		w.lastModified[filePath] = stat.ModTime()
-		return true
-	}
-
-	return false
+	// A touch or a checkout changes the modification time but not the content; files
+	// referenced by the spec are checked either way
+	changed := !stat.ModTime().Equal(lastMod) && w.contentChanged(filePath)
+	return w.dependenciesChanged(filePath) || changed
 }
 
-// regenerateInsomniaFile regenerates the Insomnia file from OpenAPI spec
-func (w *FileWatcher) regenerateInsomniaFile(openAPIFile, insomniaFile string) error {
-	// Create a new generator for each regeneration to ensure fresh timestamps
-	w.generator = NewGenerator()
-
-	return w.generator.GenerateToFile(openAPIFile, insomniaFile)
+// newGenerator creates the generator regenerating an OpenAPI file. A new generator is
+// created for each regeneration to ensure fresh timestamps. w.mu must be held.
+func (w *FileWatcher) newGenerator(openAPIFile string) *Generator {
+	options := append(append([]Option{}, w.options...), w.fileOptions[openAPIFile]...)
+	if w.deterministic {
+		return NewDeterministicGenerator(options...)
+	}
+	return NewGenerator(options...)
 }
 
-// AutoDetectAndWatch automatically detects OpenAPI files and starts watching them
-func (w *FileWatcher) AutoDetectAndWatch(directory string) error {
-	// Walk through directory to find OpenAPI files
-	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
+// DetectFiles adds the OpenAPI files found in a directory and its subdirectories. While
+// watching, the directory is scanned again for new, renamed and deleted OpenAPI files.
+func (w *FileWatcher) DetectFiles(directory string) error {
+	defer w.dispatch()
+	directory = filepath.Clean(directory)
+	found, _, err := w.findOpenAPIFiles([]string{directory})
+	if err != nil {
+		return fmt.Errorf("error walking directory: %w", err)
+	}
 
-		// Check if file looks like an OpenAPI spec
-		if w.isOpenAPIFile(path) {
-			insomniaFile := generateInsomniaFileName(path)
-			if err := w.AddFile(path, insomniaFile); err != nil {
-				log.Printf("Warning: Could not add file to watch: %v", err)
-			}
+	var added []string
+	w.mu.Lock()
+	w.roots[directory] = true
+	for _, openAPIFile := range sortedFiles(found) {
+		if _, ok := w.watchedFiles[openAPIFile]; !ok && !w.isOutput(openAPIFile) {
+			w.addFile(openAPIFile, "", nil, found[openAPIFile])
+			added = append(added, openAPIFile)
 		}
+	}
+	w.mu.Unlock()
 
-		return nil
-	})
+	w.resolveDependencies(added...)
+	return nil
+}
 
-	if err != nil {
-		return fmt.Errorf("error walking directory: %w", err)
+// AutoDetectAndWatch automatically detects OpenAPI files and starts watching them
+func (w *FileWatcher) AutoDetectAndWatch(directory string) error {
+	if err := w.DetectFiles(directory); err != nil {
+		return err
 	}
 
 	// Start watching if we found any files
-	if len(w.watchedFiles) > 0 {
-		log.Printf("Found %d OpenAPI files to watch", len(w.watchedFiles))
+	if count := len(w.GetWatchedFiles()); count > 0 {
+		log.Printf("Found %d OpenAPI files to watch", count)
 		w.StartWatching()
 	} else {
 		log.Printf("No OpenAPI files found in directory: %s", directory)
@@ -155,7 +557,12 @@ func (w *FileWatcher) AutoDetectAndWatch(directory string) error {
 func (w *FileWatcher) isOpenAPIFile(filePath string) bool {
 	// Check file extension
 	ext := strings.ToLower(filepath.Ext(filePath))
-	if ext != ".yml" && ext != ".yaml" {
+	if ext != ".yml" && ext != ".yaml" && ext != ".json" {
+		return false
+	}
+
+	// Skip generated files, which embed the spec they come from
+	if isGeneratedPath(filePath) {
 		return false
 	}
 
@@ -166,22 +573,26 @@ func (w *FileWatcher) isOpenAPIFile(filePath string) bool {
 	}
 
 	contentStr := string(content)
+	if insomniaExportPattern.MatchString(contentStr) {
+		return false
+	}
 
 	// Look for OpenAPI version markers
+	if ext == ".json" {
+		return strings.Contains(contentStr, `"openapi"`) ||
+			strings.Contains(contentStr, `"swagger"`)
+	}
+
 	return strings.Contains(contentStr, "openapi:") ||
 		strings.Contains(contentStr, "swagger:") ||
 		strings.Contains(contentStr, "info:") && strings.Contains(contentStr, "paths:")
 }
 
-// generateInsomniaFileName generates an Insomnia filename from OpenAPI filename
-func generateInsomniaFileName(openAPIFile string) string {
-	dir := filepath.Dir(openAPIFile)
-	baseName := strings.TrimSuffix(filepath.Base(openAPIFile), filepath.Ext(openAPIFile))
-	return filepath.Join(dir, fmt.Sprintf("%s-insomnia.yml", baseName))
-}
-
 // GetWatchedFiles returns a copy of the currently watched files
 func (w *FileWatcher) GetWatchedFiles() map[string]string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	result := make(map[string]string)
 	for k, v := range w.watchedFiles {
 		result[k] = v
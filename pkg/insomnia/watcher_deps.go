@@ -0,0 +1,165 @@
+package insomnia
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileState is the modification time and content hash of a file when last checked
+type fileState struct {
+	modTime time.Time
+	hash    string
+}
+
+// hashFile returns the SHA-256 hash of the content of a file, or an empty string when it
+// cannot be read
+func hashFile(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// contentChanged reports whether the content of an OpenAPI file changed since it was last
+// hashed, recording its new hash. w.mu must be held.
+func (w *FileWatcher) contentChanged(openAPIFile string) bool {
+	hash := hashFile(openAPIFile)
+	previous, ok := w.hashes[openAPIFile]
+	w.hashes[openAPIFile] = hash
+	return !ok || hash != previous
+}
+
+// dependenciesChanged reports whether the content of a file an OpenAPI file depends on
+// changed since it was last checked. Only files whose modification time changed are
+// hashed again. w.mu must be held.
+func (w *FileWatcher) dependenciesChanged(openAPIFile string) bool {
+	changed := false
+	for file, state := range w.dependencies[openAPIFile] {
+		var modTime time.Time
+		if stat, err := os.Stat(file); err == nil {
+			modTime = stat.ModTime()
+		}
+		if modTime.Equal(state.modTime) && !modTime.IsZero() {
+			continue
+		}
+
+		hash := hashFile(file)
+		if hash != state.hash {
+			log.Printf("Detected change in %s, referenced by %s", file, openAPIFile)
+			changed = true
+		}
+		w.dependencies[openAPIFile][file] = fileState{modTime: modTime, hash: hash}
+	}
+	return changed
+}
+
+// trackDependencies records the state of the files an OpenAPI file was read from besides
+// itself, watching their directories. w.mu must be held.
+func (w *FileWatcher) trackDependencies(openAPIFile string, files []string) {
+	self, _ := filepath.Abs(openAPIFile)
+	dependencies := make(map[string]fileState)
+	for _, file := range files {
+		if file == self {
+			continue
+		}
+
+		// Dependencies are named like the OpenAPI file, so events name them alike
+		file = localPath(openAPIFile, file)
+		state := fileState{hash: hashFile(file)}
+		if stat, err := os.Stat(file); err == nil {
+			state.modTime = stat.ModTime()
+		}
+		dependencies[file] = state
+
+		if w.events != nil {
+			w.watchDir(filepath.Dir(file))
+		}
+	}
+	w.dependencies[openAPIFile] = dependencies
+}
+
+// resolveDependencies parses OpenAPI files added without being generated to track the
+// files they depend on. Files removed meanwhile, or whose dependencies a regeneration
+// already tracked, are left alone. w.mu must not be held.
+func (w *FileWatcher) resolveDependencies(openAPIFiles ...string) {
+	for _, openAPIFile := range openAPIFiles {
+		w.mu.Lock()
+		_, ok := w.watchedFiles[openAPIFile]
+		generator := w.newGenerator(openAPIFile)
+		w.mu.Unlock()
+		if !ok {
+			continue
+		}
+
+		api, err := generator.ParseFile(openAPIFile)
+
+		w.mu.Lock()
+		_, watched := w.watchedFiles[openAPIFile]
+		_, tracked := w.dependencies[openAPIFile]
+		switch {
+		case !watched || tracked:
+		case err != nil:
+			log.Printf("Could not resolve the files referenced by %s: %v", openAPIFile, err)
+			w.notify(WatchEvent{Type: WatchError, Spec: openAPIFile, Err: err})
+		default:
+			w.trackDependencies(openAPIFile, api.Files)
+		}
+		w.mu.Unlock()
+	}
+}
+
+// dependents returns the watched files that depend on a file
+func (w *FileWatcher) dependents(file string) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var dependents []string
+	for openAPIFile, dependencies := range w.dependencies {
+		if _, ok := dependencies[file]; ok {
+			dependents = append(dependents, openAPIFile)
+		}
+	}
+	return dependents
+}
+
+// polled reports whether changes to an OpenAPI file or to a file it depends on are not
+// reported by events. w.mu must be held.
+func (w *FileWatcher) polled(openAPIFile string) bool {
+	if !w.eventDirs[filepath.Dir(openAPIFile)] {
+		return true
+	}
+	for file := range w.dependencies[openAPIFile] {
+		if !w.eventDirs[filepath.Dir(file)] {
+			return true
+		}
+	}
+	return false
+}
+
+// localPath returns an absolute path relative to the working directory when the OpenAPI
+// file depending on it is, so that both are named alike
+func localPath(openAPIFile, file string) string {
+	if filepath.IsAbs(openAPIFile) {
+		return file
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return file
+	}
+	if rel, err := filepath.Rel(wd, file); err == nil {
+		return rel
+	}
+	return file
+}
@@ -0,0 +1,105 @@
+package insomnia
+
+import (
+	"time"
+)
+
+// WatchEventType identifies what a watch event reports
+type WatchEventType string
+
+// Watch event types
+const (
+	// WatchRegenerated reports an output regenerated from its spec
+	WatchRegenerated WatchEventType = "regenerated"
+	// WatchError reports a spec or an output that could not be regenerated, or an error
+	// watching files
+	WatchError WatchEventType = "error"
+	// WatchAdded reports a spec added to the watched files, including the new name of a
+	// renamed spec
+	WatchAdded WatchEventType = "added"
+	// WatchRemoved reports a spec removed from the watched files, including the old name
+	// of a renamed spec
+	WatchRemoved WatchEventType = "removed"
+)
+
+// WatchEvent is what a FileWatcher reports to its hooks
+type WatchEvent struct {
+	Type WatchEventType
+	// Spec is the OpenAPI file, empty for errors watching files
+	Spec string
+	// Output is the output file, or directory for the bruno and http formats, when the
+	// event is about one
+	Output string
+	Format Format
+	// Changed reports whether a regenerated output was written, its content having changed
+	Changed bool
+	// Duration is how long the spec took to parse and the output to write
+	Duration time.Duration
+	Err      error
+}
+
+// WatchHooks are called with the events of a FileWatcher in order, one at a time and
+// without holding the watcher's lock, so they may call its methods. They usually run on
+// the goroutine that caused the event, such as the one running the watcher. Hooks left
+// nil are not called; slow hooks delay the next regeneration.
+type WatchHooks struct {
+	OnRegenerated func(event WatchEvent)
+	OnError       func(event WatchEvent)
+	OnAdded       func(event WatchEvent)
+	OnRemoved     func(event WatchEvent)
+}
+
+// SetHooks sets the hooks called with the events of the watcher
+func (w *FileWatcher) SetHooks(hooks WatchHooks) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.hooks = hooks
+}
+
+// notify queues an event for the hooks, which dispatch calls. w.mu must be held.
+func (w *FileWatcher) notify(event WatchEvent) {
+	w.queued = append(w.queued, event)
+}
+
+// dispatch calls the hooks with the queued events. When another goroutine, or a hook
+// calling the watcher, is already dispatching, it dispatches them instead. w.mu must not
+// be held.
+func (w *FileWatcher) dispatch() {
+	for w.dispatching.TryLock() {
+		w.mu.Lock()
+		events, hooks := w.queued, w.hooks
+		w.queued = nil
+		w.mu.Unlock()
+
+		callHooks(hooks, events)
+		w.dispatching.Unlock()
+
+		// Events queued while the hooks ran, or once they had, are dispatched in turn
+		w.mu.Lock()
+		queued := len(w.queued) > 0
+		w.mu.Unlock()
+		if !queued {
+			return
+		}
+	}
+}
+
+// callHooks calls the hooks of each event
+func callHooks(hooks WatchHooks, events []WatchEvent) {
+	for _, event := range events {
+		var hook func(WatchEvent)
+		switch event.Type {
+		case WatchRegenerated:
+			hook = hooks.OnRegenerated
+		case WatchError:
+			hook = hooks.OnError
+		case WatchAdded:
+			hook = hooks.OnAdded
+		case WatchRemoved:
+			hook = hooks.OnRemoved
+		}
+		if hook != nil {
+			hook(event)
+		}
+	}
+}
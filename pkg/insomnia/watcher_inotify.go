@@ -0,0 +1,151 @@
+//go:build linux
+
+package insomnia
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask selects the events of the files of a directory that may change a spec:
+// writes, editors saving by renaming a temporary file over it, and deletions
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_CREATE |
+	syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM | syscall.IN_DELETE
+
+// networkFilesystems are the filesystems whose remote changes inotify does not report,
+// by statfs magic number
+var networkFilesystems = map[uint32]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x65735546: "fuse",
+	0x564c:     "ncp",
+	0x01021997: "9p",
+}
+
+// inotifySource reports the changes of watched directories using inotify
+type inotifySource struct {
+	file   *os.File
+	mu     sync.Mutex
+	dirs   map[int32]string // watch descriptor -> directory
+	events chan string
+	errors chan error
+	done   chan struct{}
+}
+
+// newEventSource starts an inotify instance
+func newEventSource() (eventSource, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+
+	// A non-blocking descriptor is read through the runtime poller, so Close unblocks reads
+	s := &inotifySource{
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		dirs:   make(map[int32]string),
+		events: make(chan string, 64),
+		errors: make(chan error, 1),
+		done:   make(chan struct{}),
+	}
+	go s.read()
+	return s, nil
+}
+
+// Add watches the files of a directory, unless it is on a network filesystem
+func (s *inotifySource) Add(dir string) error {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", dir, err)
+	}
+	if name, ok := networkFilesystems[uint32(stat.Type)]; ok {
+		return fmt.Errorf("%s is on a %s filesystem, whose remote changes are not reported", dir, name)
+	}
+
+	wd, err := syscall.InotifyAddWatch(int(s.file.Fd()), dir, inotifyMask)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	s.mu.Lock()
+	s.dirs[int32(wd)] = dir
+	s.mu.Unlock()
+	return nil
+}
+
+// Events returns the changed files; an empty name means events were lost
+func (s *inotifySource) Events() <-chan string {
+	return s.events
+}
+
+// Errors returns the errors reading events
+func (s *inotifySource) Errors() <-chan error {
+	return s.errors
+}
+
+// Close stops reading events and releases the inotify instance
+func (s *inotifySource) Close() error {
+	close(s.done)
+	return s.file.Close()
+}
+
+// read decodes inotify events until the source is closed
+func (s *inotifySource) read() {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := s.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				s.sendError(err)
+			}
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			name := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				s.sendEvent("")
+				continue
+			}
+			if event.Mask&syscall.IN_IGNORED != 0 {
+				s.mu.Lock()
+				delete(s.dirs, event.Wd)
+				s.mu.Unlock()
+				continue
+			}
+
+			s.mu.Lock()
+			dir, ok := s.dirs[event.Wd]
+			s.mu.Unlock()
+			if ok && len(name) > 0 {
+				s.sendEvent(filepath.Join(dir, string(bytes.TrimRight(name, "\x00"))))
+			}
+		}
+	}
+}
+
+// sendEvent reports a changed file unless the source is closed
+func (s *inotifySource) sendEvent(file string) {
+	select {
+	case s.events <- file:
+	case <-s.done:
+	}
+}
+
+// sendError reports an error unless the source is closed
+func (s *inotifySource) sendError(err error) {
+	select {
+	case s.errors <- err:
+	case <-s.done:
+	}
+}
@@ -0,0 +1,43 @@
+//go:build linux
+
+package insomnia
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInotifySource(t *testing.T) {
+	dir := t.TempDir()
+	source, err := newEventSource()
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	defer source.Close()
+
+	if err := source.Add(dir); err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	// Editors often save by renaming a temporary file over the original
+	temp := writeTestFile(t, dir, ".api.yml.swp", overlaySpec)
+	if err := os.Rename(temp, filepath.Join(dir, "api.yml")); err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case file := <-source.Events():
+			if file == filepath.Join(dir, "api.yml") {
+				return
+			}
+		case err := <-source.Errors():
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		case <-timeout:
+			t.Fatalf("Test failed. Expected an event for the renamed file")
+		}
+	}
+}
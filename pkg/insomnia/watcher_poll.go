@@ -0,0 +1,13 @@
+//go:build !linux
+
+package insomnia
+
+import (
+	"errors"
+)
+
+// newEventSource reports that file events are only supported on Linux, so the watcher
+// polls instead
+func newEventSource() (eventSource, error) {
+	return nil, errors.New("file events are only supported on linux")
+}
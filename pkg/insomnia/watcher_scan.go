@@ -0,0 +1,277 @@
+package insomnia
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// insomniaExportPattern matches the top-level type of Insomnia exports, which embed the
+// spec they were generated from
+var insomniaExportPattern = regexp.MustCompile(`(?m)^(type: \S*insomnia\.rest/|_type: export)`)
+
+// isGeneratedPath reports whether a file or directory is named like the output of a
+// format, a Postman environment or a backup
+func isGeneratedPath(path string) bool {
+	name := filepath.Base(path)
+	if strings.Contains(name, ".backup") || strings.HasSuffix(name, postmanEnvironmentSuffix) {
+		return true
+	}
+	for _, suffix := range formatSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// outputFiles returns the output files of every format of an OpenAPI file, the first
+// format writing to insomniaFile
+func outputFiles(openAPIFile, insomniaFile string, formats []Format) []string {
+	files := make([]string, len(formats))
+	for i, format := range formats {
+		files[i] = insomniaFile
+		if i > 0 {
+			files[i] = OutputFileName(openAPIFile, format)
+		}
+	}
+	return files
+}
+
+// sortedFiles returns the files of a scan in name order
+func sortedFiles(found map[string]os.FileInfo) []string {
+	files := make([]string, 0, len(found))
+	for file := range found {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// findOpenAPIFiles walks directories for OpenAPI files, skipping hidden and generated
+// directories. It returns the files found with their stat, and the directories walked.
+func (w *FileWatcher) findOpenAPIFiles(roots []string) (map[string]os.FileInfo, []string, error) {
+	found := make(map[string]os.FileInfo)
+	var dirs []string
+
+	for _, root := range roots {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				// Files may vanish while walking
+				if os.IsNotExist(err) && path != root {
+					return nil
+				}
+				return err
+			}
+
+			if info.IsDir() {
+				name := info.Name()
+				if path != root && (strings.HasPrefix(name, ".") || isGeneratedPath(name)) {
+					return filepath.SkipDir
+				}
+				dirs = append(dirs, path)
+				return nil
+			}
+
+			// Check if file looks like an OpenAPI spec
+			if w.isOpenAPIFile(path) {
+				found[path] = info
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return found, dirs, nil
+}
+
+// rescan walks the scanned directories again to add new OpenAPI files, follow renamed ones
+// and remove vanished ones, deleting their outputs when set to. It returns the added files,
+// whose outputs are generated on the next check.
+func (w *FileWatcher) rescan() []string {
+	defer w.dispatch()
+	w.mu.Lock()
+	roots := make([]string, 0, len(w.roots))
+	for root := range w.roots {
+		roots = append(roots, root)
+	}
+	w.mu.Unlock()
+	if len(roots) == 0 {
+		return nil
+	}
+
+	sort.Strings(roots)
+	found, dirs, err := w.findOpenAPIFiles(roots)
+	if err != nil {
+		log.Printf("Error scanning for OpenAPI files: %v", err)
+		w.mu.Lock()
+		w.notify(WatchEvent{Type: WatchError, Err: fmt.Errorf("error scanning for OpenAPI files: %w", err)})
+		w.mu.Unlock()
+		return nil
+	}
+
+	w.mu.Lock()
+	added, renamed := w.applyScan(found, dirs)
+	w.mu.Unlock()
+
+	w.resolveDependencies(renamed...)
+	return added
+}
+
+// applyScan adds the OpenAPI files found by a scan, follows renamed ones and removes
+// vanished ones. It returns the added files and the new names of renamed ones. w.mu must
+// be held.
+func (w *FileWatcher) applyScan(found map[string]os.FileInfo, dirs []string) ([]string, []string) {
+	if w.events != nil {
+		// Deleted directories are no longer watched, so they are watched again if recreated
+		for dir := range w.eventDirs {
+			if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+				delete(w.eventDirs, dir)
+			}
+		}
+		for _, dir := range dirs {
+			w.watchDir(dir)
+		}
+	}
+
+	// Files added by hand outside the scanned directories are left to the caller
+	var vanished []string
+	for openAPIFile := range w.watchedFiles {
+		if _, ok := found[openAPIFile]; ok || !w.underRoot(openAPIFile) {
+			continue
+		}
+		if _, err := os.Stat(openAPIFile); errors.Is(err, os.ErrNotExist) {
+			vanished = append(vanished, openAPIFile)
+		}
+	}
+	sort.Strings(vanished)
+
+	var added, renamed []string
+	for _, openAPIFile := range sortedFiles(found) {
+		if _, ok := w.watchedFiles[openAPIFile]; ok || w.isOutput(openAPIFile) {
+			continue
+		}
+
+		if i := w.renamedFrom(vanished, found[openAPIFile]); i >= 0 {
+			w.renameFile(vanished[i], openAPIFile, found[openAPIFile])
+			vanished = append(vanished[:i], vanished[i+1:]...)
+			renamed = append(renamed, openAPIFile)
+			continue
+		}
+
+		w.addFile(openAPIFile, "", nil, nil)
+		added = append(added, openAPIFile)
+	}
+
+	for _, openAPIFile := range vanished {
+		if w.removeOutputs {
+			w.deleteOutputs(openAPIFile)
+		}
+		w.removeFile(openAPIFile)
+	}
+
+	return added, renamed
+}
+
+// underRoot reports whether a file is in one of the scanned directories. w.mu must be held.
+func (w *FileWatcher) underRoot(file string) bool {
+	for root := range w.roots {
+		rel, err := filepath.Rel(root, file)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isOutput reports whether a file is an output of a watched file. w.mu must be held.
+func (w *FileWatcher) isOutput(file string) bool {
+	for openAPIFile, insomniaFile := range w.watchedFiles {
+		for _, outputFile := range outputFiles(openAPIFile, insomniaFile, w.formats) {
+			if filepath.Clean(outputFile) == file {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renamedFrom returns the index of the vanished file that was renamed to the file of the
+// given stat, or -1. w.mu must be held.
+func (w *FileWatcher) renamedFrom(vanished []string, stat os.FileInfo) int {
+	for i, openAPIFile := range vanished {
+		if previous, ok := w.identities[openAPIFile]; ok && os.SameFile(previous, stat) {
+			return i
+		}
+	}
+	return -1
+}
+
+// renameFile follows the rename of a watched file, keeping its options and moving the
+// outputs named after it. The caller resolves the dependencies of the new name once it
+// releases w.mu. w.mu must be held.
+func (w *FileWatcher) renameFile(oldFile, newFile string, stat os.FileInfo) {
+	log.Printf("Detected rename: %s -> %s", oldFile, newFile)
+
+	insomniaFile := w.watchedFiles[oldFile]
+	if insomniaFile == OutputFileName(oldFile, w.formats[0]) {
+		insomniaFile = OutputFileName(newFile, w.formats[0])
+	}
+
+	oldOutputs := outputFiles(oldFile, w.watchedFiles[oldFile], w.formats)
+	for i, newOutput := range outputFiles(newFile, insomniaFile, w.formats) {
+		if oldOutputs[i] == newOutput {
+			continue
+		}
+		if err := os.Rename(oldOutputs[i], newOutput); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Error moving %s: %v", oldOutputs[i], err)
+		}
+	}
+
+	options := w.fileOptions[oldFile]
+	w.removeFile(oldFile)
+	w.addFile(newFile, insomniaFile, options, stat)
+}
+
+// deleteOutputs deletes the output files of every format of a watched file. w.mu must be held.
+func (w *FileWatcher) deleteOutputs(openAPIFile string) {
+	for _, outputFile := range outputFiles(openAPIFile, w.watchedFiles[openAPIFile], w.formats) {
+		if _, err := os.Stat(outputFile); err != nil {
+			continue
+		}
+		if err := os.RemoveAll(outputFile); err != nil {
+			log.Printf("Error deleting %s: %v", outputFile, err)
+		} else {
+			log.Printf("🗑️  Deleted output of removed file: %s", outputFile)
+		}
+	}
+}
+
+// isWatched reports whether a file is watched
+func (w *FileWatcher) isWatched(file string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, ok := w.watchedFiles[file]
+	return ok
+}
+
+// pollsRoots reports whether changes in some scanned directory are not reported by events
+func (w *FileWatcher) pollsRoots() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.roots) == 0 {
+		return false
+	}
+	for _, reported := range w.eventDirs {
+		if !reported {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,339 @@
+package insomnia
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitForFile waits for a file to contain text
+func waitForFile(t *testing.T, file, text string) {
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, err := os.ReadFile(file); err == nil && strings.Contains(string(data), text) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Test failed. Expected %s to contain %q", file, text)
+}
+
+func TestFileWatcherStartStop(t *testing.T) {
+	for _, polling := range []bool{true, false} {
+		dir := t.TempDir()
+		w := NewFileWatcher(20 * time.Millisecond)
+		w.SetPolling(polling)
+		w.SetDebounce(10 * time.Millisecond)
+		w.SetDeterministicIDs(true)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan error, 1)
+		go func() {
+			stopped <- w.Start(ctx)
+		}()
+
+		// Files are added while the watcher runs
+		openAPIFile := writeTestFile(t, dir, "api.yml", overlaySpec)
+		outputFile := filepath.Join(dir, "api-insomnia.yml")
+		if err := w.AddFile(openAPIFile, outputFile); err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+
+		// Modification times may not change within the resolution of the filesystem
+		time.Sleep(50 * time.Millisecond)
+		writeTestFile(t, dir, "api.yml", strings.Replace(overlaySpec, "Overlay API", "Watched API", 1))
+		waitForFile(t, outputFile, "Watched API")
+
+		if err := w.Start(ctx); err == nil {
+			t.Errorf("Test failed. Expected an error starting a running watcher")
+		}
+
+		cancel()
+		select {
+		case err := <-stopped:
+			if err != nil {
+				t.Errorf("Test shouldnt have failed: %v", err)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("Test failed. Expected the watcher to stop when its context is canceled")
+		}
+		w.Stop()
+	}
+}
+
+func TestFileWatcherStop(t *testing.T) {
+	w := NewFileWatcher(20 * time.Millisecond)
+	w.SetPolling(true)
+
+	stopped := make(chan struct{})
+	go func() {
+		w.StartWatching()
+		close(stopped)
+	}()
+
+	// Stop may be called before the watcher runs; it then has nothing to stop
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		w.Stop()
+		select {
+		case <-stopped:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Test failed. Expected Stop to stop the watcher")
+		}
+	}
+}
+
+// waitFor waits for a condition to hold
+func waitFor(t *testing.T, condition func() bool, expected string) {
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Test failed. Expected %s", expected)
+}
+
+func TestFileWatcherFollowsDirectory(t *testing.T) {
+	for _, polling := range []bool{true, false} {
+		dir := t.TempDir()
+		writeTestFile(t, dir, "api.yml", overlaySpec)
+
+		w := NewFileWatcher(20 * time.Millisecond)
+		w.SetPolling(polling)
+		w.SetDebounce(10 * time.Millisecond)
+		w.SetDeterministicIDs(true)
+		w.SetRemoveOutputs(true)
+		if err := w.DetectFiles(dir); err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go w.Start(ctx)
+
+		// New specs are picked up, but not the outputs generated for them
+		newFile := writeTestFile(t, dir, "new.yml", overlaySpec)
+		waitForFile(t, filepath.Join(dir, "new-insomnia.yml"), "Overlay API")
+		time.Sleep(50 * time.Millisecond)
+		if files := w.GetWatchedFiles(); len(files) != 2 || files[newFile] == "" {
+			t.Errorf("Test failed. Expected api.yml and new.yml to be watched, got %v", files)
+		}
+
+		// Renamed specs keep being watched, along with their outputs
+		renamedFile := filepath.Join(dir, "renamed.yml")
+		if err := os.Rename(newFile, renamedFile); err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+		renamedOutput := filepath.Join(dir, "renamed-insomnia.yml")
+		waitForFile(t, renamedOutput, "Overlay API")
+		if files := w.GetWatchedFiles(); files[renamedFile] != renamedOutput || files[newFile] != "" {
+			t.Errorf("Test failed. Expected renamed.yml to be watched instead of new.yml, got %v", files)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "new-insomnia.yml")); !os.IsNotExist(err) {
+			t.Errorf("Test failed. Expected the output of new.yml to be moved, got %v", err)
+		}
+
+		// Deleted specs are no longer watched, and their outputs are deleted
+		if err := os.Remove(renamedFile); err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+		waitFor(t, func() bool {
+			_, err := os.Stat(renamedOutput)
+			return os.IsNotExist(err)
+		}, "the output of a deleted spec to be deleted")
+		if files := w.GetWatchedFiles(); len(files) != 1 || files[renamedFile] != "" {
+			t.Errorf("Test failed. Expected only api.yml to be watched, got %v", files)
+		}
+
+		cancel()
+		w.Stop()
+	}
+}
+
+func TestIsOpenAPIFile(t *testing.T) {
+	dir := t.TempDir()
+	openAPIFile := writeTestFile(t, dir, "api.yml", overlaySpec)
+
+	outputFile := filepath.Join(dir, "api-insomnia.yml")
+	if err := NewDeterministicGenerator().GenerateToFile(openAPIFile, outputFile); err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+
+	// Insomnia exports embed their spec, so they are skipped by name and by content
+	w := NewFileWatcher(time.Second)
+	tests := map[string]bool{
+		openAPIFile: true,
+		outputFile:  false,
+		writeTestFile(t, dir, "workspace.yml", string(content)): false,
+		writeTestFile(t, dir, "api.backup.yml", overlaySpec):    false,
+		writeTestFile(t, dir, "api.yml.backup", overlaySpec):    false,
+		writeTestFile(t, dir, "notes.txt", overlaySpec):         false,
+	}
+	for file, expected := range tests {
+		if got := w.isOpenAPIFile(file); got != expected {
+			t.Errorf("Test failed. Expected isOpenAPIFile(%s) to be %v, got %v", filepath.Base(file), expected, got)
+		}
+	}
+}
+
+func TestFileWatcherTracksContentAndReferences(t *testing.T) {
+	spec := `
+openapi: 3.0.3
+info:
+  title: Referencing API
+  version: 1.0.0
+paths:
+  /addresses:
+    post:
+      summary: Create address
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: './schemas/address.yaml#/Address'
+`
+	schema := `
+Address:
+  type: object
+  properties:
+    street:
+      type: string
+      example: 123 Main Street
+`
+	for _, polling := range []bool{true, false} {
+		dir := t.TempDir()
+		openAPIFile := writeTestFile(t, dir, "api.yml", spec)
+		if err := os.Mkdir(filepath.Join(dir, "schemas"), 0755); err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+		writeTestFile(t, dir, "schemas/address.yaml", schema)
+		outputFile := filepath.Join(dir, "api-insomnia.yml")
+
+		w := NewFileWatcher(20 * time.Millisecond)
+		w.SetPolling(polling)
+		w.SetDebounce(10 * time.Millisecond)
+		w.SetDeterministicIDs(true)
+		if err := w.AddFile(openAPIFile, outputFile); err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go w.Start(ctx)
+
+		// Touching the spec changes its modification time but not its content
+		future := time.Now().Add(time.Hour)
+		if err := os.Chtimes(openAPIFile, future, future); err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+		time.Sleep(200 * time.Millisecond)
+		if _, err := os.Stat(outputFile); !os.IsNotExist(err) {
+			t.Errorf("Test failed. Expected touching the spec not to regenerate it, got %v", err)
+		}
+
+		// Editing a referenced file regenerates the spec
+		writeTestFile(t, dir, "schemas/address.yaml", strings.Replace(schema, "123 Main Street", "456 Oak Avenue", 1))
+		waitForFile(t, outputFile, "456 Oak Avenue")
+
+		// Regenerating the same output leaves it untouched
+		past := time.Now().Add(-time.Hour).Truncate(time.Second)
+		if err := os.Chtimes(outputFile, past, past); err != nil {
+			t.Fatalf("Test shouldnt have failed: %v", err)
+		}
+		writeTestFile(t, dir, "api.yml", spec+"# comment\n")
+		waitFor(t, func() bool {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			return w.hashes[openAPIFile] == hashFile(openAPIFile)
+		}, "the edited spec to be checked")
+		time.Sleep(100 * time.Millisecond)
+		if stat, err := os.Stat(outputFile); err != nil || !stat.ModTime().Equal(past) {
+			t.Errorf("Test failed. Expected the unchanged output not to be written")
+		}
+
+		cancel()
+		w.Stop()
+	}
+}
+
+func TestFileWatcherHooks(t *testing.T) {
+	dir := t.TempDir()
+	openAPIFile := writeTestFile(t, dir, "api.yml", overlaySpec)
+	outputFile := filepath.Join(dir, "api-insomnia.yml")
+
+	w := NewFileWatcher(20 * time.Millisecond)
+	w.SetPolling(true)
+	w.SetDeterministicIDs(true)
+
+	var mu sync.Mutex
+	var events []WatchEvent
+	record := func(event WatchEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	received := func(eventType WatchEventType) *WatchEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		for i := range events {
+			if events[i].Type == eventType {
+				return &events[i]
+			}
+		}
+		return nil
+	}
+
+	// Hooks may call the watcher
+	w.SetHooks(WatchHooks{
+		OnRegenerated: record,
+		OnError:       record,
+		OnAdded: func(event WatchEvent) {
+			w.GetWatchedFiles()
+			record(event)
+		},
+		OnRemoved: record,
+	})
+	if err := w.DetectFiles(dir); err != nil {
+		t.Fatalf("Test shouldnt have failed: %v", err)
+	}
+	if added := received(WatchAdded); added == nil || added.Spec != openAPIFile || added.Output != outputFile {
+		t.Errorf("Test failed. Expected an added event for %s, got %v", openAPIFile, added)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	time.Sleep(50 * time.Millisecond)
+	writeTestFile(t, dir, "api.yml", strings.Replace(overlaySpec, "Overlay API", "Hooked API", 1))
+	waitFor(t, func() bool { return received(WatchRegenerated) != nil }, "a regenerated event")
+	regenerated := received(WatchRegenerated)
+	if regenerated.Spec != openAPIFile || regenerated.Output != outputFile || regenerated.Format != FormatInsomnia ||
+		!regenerated.Changed || regenerated.Duration <= 0 || regenerated.Err != nil {
+		t.Errorf("Test failed. Expected a regenerated event for %s, got %+v", outputFile, regenerated)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	writeTestFile(t, dir, "api.yml", "openapi: [")
+	waitFor(t, func() bool { return received(WatchError) != nil }, "an error event")
+	if failed := received(WatchError); failed.Spec != openAPIFile || failed.Err == nil {
+		t.Errorf("Test failed. Expected an error event for %s, got %+v", openAPIFile, failed)
+	}
+
+	w.RemoveFile(openAPIFile)
+	if removed := received(WatchRemoved); removed == nil || removed.Spec != openAPIFile {
+		t.Errorf("Test failed. Expected a removed event for %s, got %v", openAPIFile, removed)
+	}
+	w.Stop()
+}
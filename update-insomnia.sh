@@ -2,7 +2,10 @@
 
 # Update/Create Insomnia File Script
 # This script generates or updates an Insomnia file from an OpenAPI spec
-# while preserving original IDs and structure when updating
+# while preserving original IDs and structure when updating.
+#
+# It is a thin wrapper around `insomnia-generator -update`, which can be
+# called directly instead.
 
 set -e
 
@@ -108,81 +111,26 @@ else
     fi
 fi
 
-TEMP_FILE="temp-insomnia-$(date +%s).yml"
 BACKUP_FILE="${INSOMNIA_FILE}.backup"
 
-# Extract original metadata if updating
+# Generate or merge with the Go generator, which matches folders and requests
+# by tag and operation and keeps their IDs and any edits made in Insomnia
 if [ "$UPDATE_MODE" = true ]; then
     echo "📦 Creating backup: $BACKUP_FILE"
     cp "$INSOMNIA_FILE" "$BACKUP_FILE"
-    
-    echo "🔍 Extracting original metadata..."
-    ORIGINAL_ID=$(grep "id: wrk_" "$INSOMNIA_FILE" | head -1 | sed 's/.*id: //' | tr -d ' ')
-    ORIGINAL_CREATED=$(grep "created:" "$INSOMNIA_FILE" | head -1 | sed 's/.*created: //' | tr -d ' ')
-    FOLDER_ID=$(grep "id: fld_" "$INSOMNIA_FILE" | head -1 | sed 's/.*id: //' | tr -d ' ')
-    COOKIE_JAR_ID=$(grep "id: jar_" "$INSOMNIA_FILE" | head -1 | sed 's/.*id: //' | tr -d ' ')
-    ENV_ID=$(grep "id: env_" "$INSOMNIA_FILE" | head -1 | sed 's/.*id: //' | tr -d ' ')
-    
-    # Handle spec ID (may not exist in all files)
-    SPEC_ID=$(grep "id: spc_" "$INSOMNIA_FILE" | head -1 | sed 's/.*id: //' | tr -d ' ' || echo "")
-    
-    echo "📝 Original IDs:"
-    echo "   Workspace: $ORIGINAL_ID"
-    echo "   Folder: $FOLDER_ID" 
-    echo "   Cookie Jar: $COOKIE_JAR_ID"
-    echo "   Environment: $ENV_ID"
-    if [ -n "$SPEC_ID" ]; then
-        echo "   Spec: $SPEC_ID"
-    fi
-fi
-
-# Generate new file with our generator
-echo "⚙️  Generating Insomnia file from OpenAPI spec..."
-go run cmd/insomnia-generator/main.go -input "$OPENAPI_FILE" -output "$TEMP_FILE"
-
-if [ ! -f "$TEMP_FILE" ]; then
-    echo "❌ Error: Failed to generate temporary file"
-    exit 1
-fi
 
-# If updating, replace the new IDs with original IDs
-if [ "$UPDATE_MODE" = true ]; then
-    echo "🔧 Restoring original IDs..."
-    
-    # Get new IDs from generated file
-    NEW_WORKSPACE_ID=$(grep "id: wrk_" "$TEMP_FILE" | head -1 | sed 's/.*id: //' | tr -d ' ')
-    NEW_FOLDER_ID=$(grep "id: fld_" "$TEMP_FILE" | head -1 | sed 's/.*id: //' | tr -d ' ')
-    NEW_COOKIE_JAR_ID=$(grep "id: jar_" "$TEMP_FILE" | head -1 | sed 's/.*id: //' | tr -d ' ')
-    NEW_ENV_ID=$(grep "id: env_" "$TEMP_FILE" | head -1 | sed 's/.*id: //' | tr -d ' ')
-    NEW_SPEC_ID=$(grep "id: spc_" "$TEMP_FILE" | head -1 | sed 's/.*id: //' | tr -d ' ' || echo "")
-    
-    # Replace IDs in temp file
-    sed -i.bak "s/$NEW_WORKSPACE_ID/$ORIGINAL_ID/g" "$TEMP_FILE"
-    sed -i.bak "s/$NEW_FOLDER_ID/$FOLDER_ID/g" "$TEMP_FILE"
-    sed -i.bak "s/$NEW_COOKIE_JAR_ID/$COOKIE_JAR_ID/g" "$TEMP_FILE"
-    sed -i.bak "s/$NEW_ENV_ID/$ENV_ID/g" "$TEMP_FILE"
-    
-    if [ -n "$SPEC_ID" ] && [ -n "$NEW_SPEC_ID" ]; then
-        sed -i.bak "s/$NEW_SPEC_ID/$SPEC_ID/g" "$TEMP_FILE"
-    fi
-    
-    # Preserve the original creation timestamp
-    NEW_CREATED=$(grep "created:" "$TEMP_FILE" | head -1 | sed 's/.*created: //' | tr -d ' ')
-    sed -i.bak "s/created: $NEW_CREATED/created: $ORIGINAL_CREATED/" "$TEMP_FILE"
-    
-    # Clean up backup files
-    rm -f "$TEMP_FILE.bak"
+    echo "⚙️  Merging OpenAPI spec into existing Insomnia file..."
+    go run ./cmd/insomnia-generator -input "$OPENAPI_FILE" -output "$INSOMNIA_FILE" -update
+else
+    echo "⚙️  Generating Insomnia file from OpenAPI spec..."
+    go run ./cmd/insomnia-generator -input "$OPENAPI_FILE" -output "$INSOMNIA_FILE"
 fi
 
-# Replace/create the final file
-echo "📝 Writing final Insomnia file..."
-mv "$TEMP_FILE" "$INSOMNIA_FILE"
-
 # Success message
 if [ "$UPDATE_MODE" = true ]; then
     echo "✅ Successfully updated $INSOMNIA_FILE"
     echo "📋 Summary:"
-    echo "   - Preserved original workspace ID: $ORIGINAL_ID"
+    echo "   - Preserved workspace, folder and request IDs and Insomnia edits"
     echo "   - Updated API endpoints from $OPENAPI_FILE"
     echo "   - Backup saved as: $BACKUP_FILE"
 else